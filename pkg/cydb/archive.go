@@ -0,0 +1,373 @@
+package cydb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fj1981/infrakit/pkg/cylog"
+)
+
+// DefaultArchiveCheckpointTable 归档断点表默认表名
+const DefaultArchiveCheckpointTable = "cy_archive_checkpoint"
+
+var archiveCheckpointDDL = map[string][]string{
+	"mysql": {
+		"CREATE TABLE %[1]s (name VARCHAR(255) NOT NULL PRIMARY KEY, last_key VARCHAR(2000), updated_at BIGINT NOT NULL)",
+	},
+	"postgresql": {
+		"CREATE TABLE %[1]s (name VARCHAR(255) NOT NULL PRIMARY KEY, last_key VARCHAR(2000), updated_at BIGINT NOT NULL)",
+	},
+	"oracle": {
+		"CREATE TABLE %[1]s (name VARCHAR2(255) NOT NULL PRIMARY KEY, last_key VARCHAR2(2000), updated_at NUMBER(19) NOT NULL)",
+	},
+	"sqlite": {
+		"CREATE TABLE %[1]s (name VARCHAR(255) NOT NULL PRIMARY KEY, last_key VARCHAR(2000), updated_at INTEGER NOT NULL)",
+	},
+}
+
+// ArchiveOptions 归档任务配置
+type ArchiveOptions struct {
+	// Target 归档目标库，为 nil 时只删除不归档（清理）
+	Target *DBCli
+	// TargetTable 归档表名，默认与源表同名
+	TargetTable string
+	// Data 与 Where 为筛选条件及其参数，同 List/Delete
+	Data  map[string]interface{}
+	Where []FuncWithBuilder
+	// BatchSize 每批行数，每批一个事务
+	BatchSize int
+	// Throttle 批次之间的停顿，降低对线上业务的影响
+	Throttle time.Duration
+	// MaxBatches 单次 Run 最多处理的批数，0 表示不限制；未处理完的部分下次从断点继续
+	MaxBatches int
+	// Checkpoint 断点名，默认为源表名；同一张表有多个归档任务时需区分
+	Checkpoint      string
+	CheckpointTable string
+}
+
+type ArchiveOption func(*ArchiveOptions)
+
+// WithArchiveTarget 将行归档到 target 的 table 表，target 可以是另一个库
+func WithArchiveTarget(target *DBCli, table ...string) ArchiveOption {
+	return func(o *ArchiveOptions) {
+		o.Target = target
+		if len(table) > 0 {
+			o.TargetTable = table[0]
+		}
+	}
+}
+
+// WithArchiveWhere 设置归档条件，如 WithArchiveWhere(map[string]interface{}{"created_at": t}, WithLT("created_at"))
+func WithArchiveWhere(data map[string]interface{}, cc ...FuncWithBuilder) ArchiveOption {
+	return func(o *ArchiveOptions) {
+		o.Data = data
+		o.Where = cc
+	}
+}
+
+func WithArchiveBatchSize(n int) ArchiveOption {
+	return func(o *ArchiveOptions) { o.BatchSize = n }
+}
+
+func WithArchiveThrottle(d time.Duration) ArchiveOption {
+	return func(o *ArchiveOptions) { o.Throttle = d }
+}
+
+func WithArchiveMaxBatches(n int) ArchiveOption {
+	return func(o *ArchiveOptions) { o.MaxBatches = n }
+}
+
+// WithArchiveCheckpoint 指定断点名，可选指定断点表
+func WithArchiveCheckpoint(name string, table ...string) ArchiveOption {
+	return func(o *ArchiveOptions) {
+		o.Checkpoint = name
+		if len(table) > 0 {
+			o.CheckpointTable = table[0]
+		}
+	}
+}
+
+// ArchiveResult 一次 Run 的统计
+type ArchiveResult struct {
+	Batches  int
+	Archived int64
+	Deleted  int64
+	// LastKey 最后处理的主键值，与主键列顺序一致
+	LastKey []interface{}
+	// Done 表示没有剩余满足条件的行，断点已清除
+	Done bool
+}
+
+// Archiver 按主键顺序分批将满足条件的行从源表搬到归档表并删除
+// 每批在独立事务中完成，断点与删除一同提交，中断后从断点继续
+type Archiver struct {
+	src   *DBCli
+	table string
+	pk    []string
+	opts  *ArchiveOptions
+}
+
+// NewArchiver 创建归档任务，源表必须有主键；未设置 WithArchiveTarget 时仅分批删除
+func NewArchiver(src *DBCli, table string, opts ...ArchiveOption) (*Archiver, error) {
+	o := &ArchiveOptions{BatchSize: 500, CheckpointTable: DefaultArchiveCheckpointTable}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 500
+	}
+	if o.TargetTable == "" {
+		o.TargetTable = table
+	}
+	if o.Checkpoint == "" {
+		o.Checkpoint = table
+	}
+	if o.CheckpointTable == "" {
+		o.CheckpointTable = DefaultArchiveCheckpointTable
+	}
+	if o.Target != nil && o.Target.oper() == src.oper() && o.TargetTable == table {
+		return nil, fmt.Errorf("archive target is the source table %s", table)
+	}
+	pk, err := src.GetPK(table)
+	if err != nil {
+		return nil, err
+	}
+	if len(pk) == 0 {
+		return nil, fmt.Errorf("table %s has no primary key", table)
+	}
+	if err := src.ensureTable(o.CheckpointTable, archiveCheckpointDDL); err != nil {
+		return nil, err
+	}
+	// 归档与删除都针对物理行，不受软删除影响
+	return &Archiver{src: src.Unscoped(), table: table, pk: pk, opts: o}, nil
+}
+
+// Run 循环处理批次，直到没有剩余行、达到 MaxBatches 或 ctx 结束
+// ctx 结束时返回 ctx.Err()，已提交的批次及断点保留
+func (a *Archiver) Run(ctx context.Context) (*ArchiveResult, error) {
+	last, err := a.loadCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+	res := &ArchiveResult{LastKey: last}
+	for a.opts.MaxBatches <= 0 || res.Batches < a.opts.MaxBatches {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		if res.Batches > 0 && a.opts.Throttle > 0 {
+			select {
+			case <-ctx.Done():
+				return res, ctx.Err()
+			case <-time.After(a.opts.Throttle):
+			}
+		}
+		n, key, err := a.batch(res.LastKey)
+		if err != nil {
+			return res, err
+		}
+		if n == 0 {
+			if res.LastKey != nil {
+				if err := a.Reset(); err != nil {
+					return res, err
+				}
+			}
+			res.Done = true
+			return res, nil
+		}
+		res.Batches++
+		res.Deleted += n
+		if a.opts.Target != nil {
+			res.Archived += n
+		}
+		res.LastKey = key
+	}
+	return res, nil
+}
+
+// Handler 返回可注册到 cydist.DistributedScheduler 的任务函数
+// 任务超时视为正常结束，下次调度从断点继续
+func (a *Archiver) Handler() func(context.Context) error {
+	return func(ctx context.Context) error {
+		res, err := a.Run(ctx)
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = nil
+		}
+		if res != nil {
+			cylog.Infof("archive %s: %d batches, %d rows, done=%v", a.opts.Checkpoint, res.Batches, res.Deleted, res.Done)
+		}
+		return err
+	}
+}
+
+// Reset 清除断点，下次 Run 从头扫描
+func (a *Archiver) Reset() error {
+	_, err := a.src.nExcute(fmt.Sprintf("DELETE FROM %s WHERE name = :name", a.opts.CheckpointTable),
+		map[string]interface{}{"name": a.opts.Checkpoint})
+	return err
+}
+
+// batch 处理 last 之后的一批行，返回处理行数与本批最后一行的主键
+func (a *Archiver) batch(last []interface{}) (int64, []interface{}, error) {
+	var (
+		n   int64
+		key []interface{}
+	)
+	err := a.src.runTransaction(func(tx *DBCli) error {
+		rows, err := a.fetch(tx, last)
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+		if err := a.archive(tx, rows); err != nil {
+			return err
+		}
+		if n, err = a.delete(tx, rows); err != nil {
+			return err
+		}
+		key = a.keyOf(rows[len(rows)-1])
+		return a.saveCheckpoint(tx, key)
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return n, key, nil
+}
+
+func (a *Archiver) fetch(tx *DBCli, last []interface{}) ([]map[string]interface{}, error) {
+	data := make(map[string]interface{}, len(a.opts.Data)+len(last))
+	for k, v := range a.opts.Data {
+		data[k] = v
+	}
+	cc := append([]FuncWithBuilder{}, a.opts.Where...)
+	if last != nil {
+		var ors []Where
+		for i := range a.pk {
+			var ands []Where
+			for j := 0; j < i; j++ {
+				ands = append(ands, EQ(a.pk[j], WithParameter(fmt.Sprintf("archive_k%d", j))))
+			}
+			ands = append(ands, GT(a.pk[i], WithParameter(fmt.Sprintf("archive_k%d", i))))
+			ors = append(ors, AND(ands...))
+		}
+		for i, v := range last {
+			data[fmt.Sprintf("archive_k%d", i)] = v
+		}
+		cc = append(cc, WithWhere(OR(ors...)))
+	}
+	cc = append(cc, WithOrderBy(ASC(strings.Join(a.pk, ","))), WithLimit(a.opts.BatchSize))
+	return tx.List(a.table, data, cc...)
+}
+
+// archive 写入归档表，使用 Replace 保证重试时幂等；与源表同库时共用源库事务
+// 跨库归档时目标库单独开事务并先于源库提交，源库提交失败时下次重试会覆盖已写入的行
+func (a *Archiver) archive(tx *DBCli, rows []map[string]interface{}) error {
+	target := a.opts.Target
+	if target == nil {
+		return nil
+	}
+	write := func(cli *DBCli) error {
+		for _, row := range rows {
+			if _, err := cli.Replace(a.opts.TargetTable, row); err != nil {
+				return fmt.Errorf("archive into %s: %w", a.opts.TargetTable, err)
+			}
+		}
+		return nil
+	}
+	if target.oper() == a.src.oper() {
+		return write(tx)
+	}
+	return target.runTransaction(write)
+}
+
+func (a *Archiver) delete(tx *DBCli, rows []map[string]interface{}) (int64, error) {
+	data := map[string]interface{}{}
+	var cond Where
+	if len(a.pk) == 1 {
+		values := make([]any, 0, len(rows))
+		for i, row := range rows {
+			name := fmt.Sprintf("archive_d%d", i)
+			data[name] = rowValue(row, a.pk[0])
+			values = append(values, &ParameterValue{Name: name})
+		}
+		cond = IN(a.pk[0], values)
+	} else {
+		ors := make([]Where, 0, len(rows))
+		for i, row := range rows {
+			ands := make([]Where, 0, len(a.pk))
+			for j, col := range a.pk {
+				name := fmt.Sprintf("archive_d%d_%d", i, j)
+				data[name] = rowValue(row, col)
+				ands = append(ands, EQ(col, WithParameter(name)))
+			}
+			ors = append(ors, AND(ands...))
+		}
+		cond = OR(ors...)
+	}
+	n, err := tx.Delete(a.table, data, WithWhere(cond))
+	if err != nil {
+		return 0, err
+	}
+	if n != int64(len(rows)) {
+		return 0, fmt.Errorf("archive %s: expected to delete %d rows, deleted %d", a.table, len(rows), n)
+	}
+	return n, nil
+}
+
+func (a *Archiver) keyOf(row map[string]interface{}) []interface{} {
+	key := make([]interface{}, 0, len(a.pk))
+	for _, col := range a.pk {
+		key = append(key, rowValue(row, col))
+	}
+	return key
+}
+
+func (a *Archiver) loadCheckpoint() ([]interface{}, error) {
+	rows, err := a.src.nQuery(fmt.Sprintf("SELECT last_key FROM %s WHERE name = :name", a.opts.CheckpointTable),
+		map[string]interface{}{"name": a.opts.Checkpoint})
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	raw := fmt.Sprint(rowValue(rows[0], "last_key"))
+	if raw == "" || raw == "<nil>" {
+		return nil, nil
+	}
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	var key []interface{}
+	if err := dec.Decode(&key); err != nil {
+		return nil, fmt.Errorf("invalid archive checkpoint %s: %w", a.opts.Checkpoint, err)
+	}
+	if len(key) != len(a.pk) {
+		return nil, fmt.Errorf("archive checkpoint %s has %d keys, table %s has %d", a.opts.Checkpoint, len(key), a.table, len(a.pk))
+	}
+	for i, v := range key {
+		if num, ok := v.(json.Number); ok {
+			if n, err := num.Int64(); err == nil {
+				key[i] = n
+			} else if f, err := num.Float64(); err == nil {
+				key[i] = f
+			}
+		}
+	}
+	return key, nil
+}
+
+func (a *Archiver) saveCheckpoint(tx *DBCli, key []interface{}) error {
+	b, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	data := map[string]interface{}{"name": a.opts.Checkpoint, "last_key": string(b), "updated_at": time.Now().UnixMilli()}
+	n, err := tx.nExcute(fmt.Sprintf("UPDATE %s SET last_key = :last_key, updated_at = :updated_at WHERE name = :name", a.opts.CheckpointTable), data)
+	if err != nil || n > 0 {
+		return err
+	}
+	_, err = tx.nExcute(fmt.Sprintf("INSERT INTO %s (name, last_key, updated_at) VALUES (:name, :last_key, :updated_at)", a.opts.CheckpointTable), data)
+	return err
+}
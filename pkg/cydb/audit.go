@@ -0,0 +1,287 @@
+package cydb
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditPolicy 审计列策略，列名为空表示不启用对应功能
+// 仅当表中实际存在对应列时才会生效
+type AuditPolicy struct {
+	CreatedAt string
+	UpdatedAt string
+	CreatedBy string
+	UpdatedBy string
+	// DeletedAt 不为空时 Delete 变为软删除，查询自动追加 DeletedAt IS NULL
+	DeletedAt string
+	DeletedBy string
+	// Now 返回当前时间，默认 time.Now
+	Now func() time.Time
+	// Actor 从上下文中获取操作人，默认 ActorFromContext
+	Actor func(ctx context.Context) (any, bool)
+}
+
+// DefaultAuditPolicy 返回常用的审计列命名
+func DefaultAuditPolicy() *AuditPolicy {
+	return &AuditPolicy{
+		CreatedAt: "created_at",
+		UpdatedAt: "updated_at",
+		CreatedBy: "created_by",
+		UpdatedBy: "updated_by",
+		DeletedAt: "deleted_at",
+		DeletedBy: "deleted_by",
+	}
+}
+
+func (p *AuditPolicy) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+func (p *AuditPolicy) actor(ctx context.Context) (any, bool) {
+	if p.Actor != nil {
+		return p.Actor(ctx)
+	}
+	return ActorFromContext(ctx)
+}
+
+type actorCtxKey struct{}
+
+// ContextWithActor 将操作人写入上下文，配合 DBCli.WithContext 使用
+func ContextWithActor(ctx context.Context, actor any) context.Context {
+	return context.WithValue(ctx, actorCtxKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) (any, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	v := ctx.Value(actorCtxKey{})
+	return v, v != nil
+}
+
+type auditConf struct {
+	lock   sync.RWMutex
+	global *AuditPolicy
+	tables map[string]*AuditPolicy
+}
+
+func newAuditConf() *auditConf {
+	return &auditConf{tables: map[string]*AuditPolicy{}}
+}
+
+// SetAuditPolicy 设置审计列策略，不指定 tables 时作为全局策略，p 为 nil 时移除对应策略
+// 策略在同一连接的副本与事务间共享，可在运行中修改
+func (d *DBCli) SetAuditPolicy(p *AuditPolicy, tables ...string) {
+	d.audit.lock.Lock()
+	defer d.audit.lock.Unlock()
+	if len(tables) == 0 {
+		d.audit.global = p
+		return
+	}
+	for _, t := range tables {
+		if p == nil {
+			delete(d.audit.tables, strings.ToLower(t))
+		} else {
+			d.audit.tables[strings.ToLower(t)] = p
+		}
+	}
+}
+
+// WithContext 返回绑定上下文的 DBCli 副本，审计列中的操作人从该上下文中获取
+func (d *DBCli) WithContext(ctx context.Context) *DBCli {
+	cp := *d
+	cp.ctx = ctx
+	return &cp
+}
+
+func (d *DBCli) Context() context.Context {
+	if d.ctx == nil {
+		return context.Background()
+	}
+	return d.ctx
+}
+
+// Unscoped 返回忽略软删除的 DBCli 副本：查询包含已删除数据，Delete 为物理删除
+func (d *DBCli) Unscoped() *DBCli {
+	cp := *d
+	cp.unscoped = true
+	return &cp
+}
+
+func auditTableName(tableName any) string {
+	switch v := tableName.(type) {
+	case string:
+		return TABLE(v).(*Table).Name
+	case *Table:
+		return v.Name
+	}
+	return ""
+}
+
+func (d *DBCli) auditPolicy(tableName any) *AuditPolicy {
+	if d.audit == nil {
+		return nil
+	}
+	name := auditTableName(tableName)
+	if name == "" {
+		return nil
+	}
+	d.audit.lock.RLock()
+	defer d.audit.lock.RUnlock()
+	if p, ok := d.audit.tables[strings.ToLower(name)]; ok {
+		return p
+	}
+	return d.audit.global
+}
+
+// AuditColumns 返回表的审计策略中配置的列，未启用审计时返回 nil
+// 这些列由 DBCli 写入，调用方传入数据时可以排除，例如生成的 Repo
+func (d *DBCli) AuditColumns(tableName string) []string {
+	p := d.auditPolicy(tableName)
+	if p == nil {
+		return nil
+	}
+	var r []string
+	for _, c := range []string{p.CreatedAt, p.UpdatedAt, p.CreatedBy, p.UpdatedBy, p.DeletedAt, p.DeletedBy} {
+		if c != "" {
+			r = append(r, c)
+		}
+	}
+	return r
+}
+
+func (d *DBCli) hasColumn(tableName string, col string) bool {
+	if col == "" {
+		return false
+	}
+	ok, err := d.FieldExists(tableName, col)
+	return err == nil && ok
+}
+
+// auditData 返回补充审计列后的数据副本，不修改调用方传入的数据
+func (d *DBCli) auditData(tableName string, data map[string]interface{}, create bool) map[string]interface{} {
+	p := d.auditPolicy(tableName)
+	if p == nil {
+		return data
+	}
+	r := cloneData(data)
+	now := p.now()
+	actor, hasActor := p.actor(d.Context())
+	set := func(col string, v any) {
+		if col == "" {
+			return
+		}
+		if _, ok := r[col]; !ok {
+			r[col] = v
+		}
+	}
+	if create {
+		set(p.CreatedAt, now)
+		if hasActor {
+			set(p.CreatedBy, actor)
+		}
+	}
+	set(p.UpdatedAt, now)
+	if hasActor {
+		set(p.UpdatedBy, actor)
+	}
+	return r
+}
+
+// conflictUpdateFields 返回 Upsert、Replace 命中已有行时更新的字段，去掉审计策略的创建列以保留原创建时间与创建人
+// 未启用审计或 fields 不含创建列时返回 nil，表示更新全部字段
+func (d *DBCli) conflictUpdateFields(tableName string, fields []string) []string {
+	p := d.auditPolicy(tableName)
+	if p == nil {
+		return nil
+	}
+	r := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if (p.CreatedAt != "" && strings.EqualFold(f, p.CreatedAt)) || (p.CreatedBy != "" && strings.EqualFold(f, p.CreatedBy)) {
+			continue
+		}
+		r = append(r, f)
+	}
+	if len(r) == len(fields) || len(r) == 0 {
+		return nil
+	}
+	return r
+}
+
+// conflictUpdate 按 conflictUpdateFields 设置构建器冲突时的更新字段
+func (d *DBCli) conflictUpdate(builder SQLBuilder, tableName string, fields []string) SQLBuilder {
+	if upd := d.conflictUpdateFields(tableName, fields); upd != nil {
+		return builder.Update(strings.Join(upd, ","))
+	}
+	return builder
+}
+
+func cloneData(data map[string]interface{}) map[string]interface{} {
+	if data == nil {
+		return map[string]interface{}{}
+	}
+	return maps.Clone(data)
+}
+
+// softDeleteScope 为查询追加软删除过滤条件
+func (d *DBCli) softDeleteScope(builder SQLBuilder, tableName any) SQLBuilder {
+	if d.unscoped {
+		return builder
+	}
+	p := d.auditPolicy(tableName)
+	if p == nil || !d.hasColumn(auditTableName(tableName), p.DeletedAt) {
+		return builder
+	}
+	col := p.DeletedAt
+	if t, ok := tableName.(*Table); ok && t.Alias != "" {
+		col = t.Alias + "." + col
+	}
+	return builder.Where(IS_NULL(col))
+}
+
+// softDelete 软删除，返回 false 表示该表未启用软删除
+func (d *DBCli) softDelete(tableName any, data map[string]interface{}, cc ...FuncWithBuilder) (int64, bool, error) {
+	if d.unscoped {
+		return 0, false, nil
+	}
+	p := d.auditPolicy(tableName)
+	name := auditTableName(tableName)
+	if p == nil || !d.hasColumn(name, p.DeletedAt) {
+		return 0, false, nil
+	}
+	sqlFunc, ok := GetSqlTransformer(d.dbtype)
+	if !ok {
+		return 0, true, errors.New("not support db type: " + d.dbtype)
+	}
+	r := cloneData(data)
+	r[p.DeletedAt] = p.now()
+	fields := []string{p.DeletedAt}
+	if actor, ok := p.actor(d.Context()); ok && d.hasColumn(name, p.DeletedBy) {
+		r[p.DeletedBy] = actor
+		fields = append(fields, p.DeletedBy)
+	}
+	builder := d.builder().Table(tableName).Fields(fields)
+	for _, c := range cc {
+		builder = c(builder)
+	}
+	if err := d.guardBuilder(builder, "DELETE", tableName); err != nil {
+		return 0, true, err
+	}
+	builder = d.softDeleteScope(builder, tableName)
+	sqlContent, err := builder.Type(SQLOperationUpdate).Build(sqlFunc)
+	if err != nil {
+		return 0, true, err
+	}
+	affected, err := d.nExcute(sqlContent.SQL, r)
+	if err == nil {
+		d.invalidateBuilder(builder)
+	}
+	return affected, true, err
+}
@@ -29,27 +29,57 @@ type DBConnection struct {
 	Un     string `yaml:"un,omitempty"`
 	Pw     string `yaml:"pw,omitempty"`
 	DBName string `yaml:"dbname,omitempty"`
+	// PwRef 密码引用，如 env:DB_PW、file:/run/secrets/db_pw 或已注册 SecretProvider 的 scheme:name，设置后忽略 Pw
+	// Pw 本身也可以是 ENC(...) 形式的 SM4 加密值
+	PwRef string `yaml:"pw_ref,omitempty"`
 
 	// Fields for Oracle
 	Service string `yaml:"service,omitempty"`
 	Role    string `yaml:"role,omitempty"`
+	// SID 以 SID 而非服务名连接，设置后忽略 Service
+	SID string `yaml:"sid,omitempty"`
+	// TNS 完整的连接描述符，如 (DESCRIPTION=(ADDRESS=...)(CONNECT_DATA=...))，设置后忽略 Host、Port、Service、SID
+	TNS string `yaml:"tns,omitempty"`
+	// Sequences 表名到主键序列名的映射，插入未提供单列主键时取序列 NEXTVAL 填充
+	Sequences map[string]string `yaml:"sequences,omitempty"`
 
 	// Field for SQLite
 	Path string `yaml:"path,omitempty"`
 
 	// Fields for PostgreSQL
 	SSLMode string `yaml:"sslmode,omitempty"` // disable, require, verify-ca, verify-full
-	Schema  string `yaml:"schema,omitempty"`  // default schema (search_path)
+	// Schema 默认 schema：PostgreSQL 为 search_path，Oracle 为会话的 CURRENT_SCHEMA 及未指定 owner 时的对象 owner，与登录用户无关
+	Schema string `yaml:"schema,omitempty"`
+
+	// Guard 危险语句检查，为空时不检查
+	Guard *GuardPolicy `yaml:"guard,omitempty"`
+	// Tenant 多租户隔离策略，为空时不隔离
+	Tenant *TenantPolicy `yaml:"tenant,omitempty"`
+	// Translate Query 的原生 SQL 也按 MySQL 写法转换为目标库方言
+	Translate bool `yaml:"translate,omitempty"`
+	// SchemaCacheTTL 表结构缓存有效期，为 0 时使用 DefaultSchemaCacheTTL，小于 0 时不缓存
+	SchemaCacheTTL time.Duration `yaml:"schema_cache_ttl,omitempty"`
 }
 
+// GetDBAndTable name 为表名或 database, table；未指定库名时使用 DefaultSchema
 func GetDBAndTable(cli DatabaseClient, name ...string) (string, string) {
 	if len(name) == 1 {
-		return cli.Database(), name[0]
+		return DefaultSchema(cli), name[0]
 	}
 	if len(name) == 2 {
 		return name[0], name[1]
 	}
-	return cli.Database(), ""
+	return DefaultSchema(cli), ""
+}
+
+// DefaultSchema 客户端实现了 Schema() 时返回其结果，否则为 Database()
+func DefaultSchema(cli DatabaseClient) string {
+	if s, ok := cli.(interface{ Schema() string }); ok {
+		if schema := s.Schema(); schema != "" {
+			return schema
+		}
+	}
+	return cli.Database()
 }
 
 // Helper function to format values based on their expected type.
@@ -149,6 +179,7 @@ func FormatValue(v interface{}, colType DBFieldType) (string, error) {
 	return "", fmt.Errorf("unknown type: %v, colType: %v", v, colType)
 }
 
+// Deprecated: 值直接拼接进 SQL，请在 NQuery/NExcute 中使用切片类型的命名参数，如 IN (:ids)
 func InParam(ids []string) string {
 	r := []string{}
 	for _, l := range ids {
@@ -157,6 +188,7 @@ func InParam(ids []string) string {
 	return strings.Join(r, ",")
 }
 
+// Deprecated: 请在 NQuery/NExcute 中使用 [][]any 类型的命名参数，如 (a, b) IN (:pairs)
 func InParam2(ids [][]string) string {
 	r := []string{}
 	for _, l := range ids {
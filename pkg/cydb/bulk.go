@@ -0,0 +1,297 @@
+package cydb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/duke-git/lancet/v2/maputil"
+)
+
+// BulkLoadOptions 批量导入配置
+type BulkLoadOptions struct {
+	// Columns 导入的列，为空时使用所有数据中出现过的列（并按表结构过滤）
+	Columns []string
+	// ChunkSize 每批行数上限，实际批大小还受方言绑定参数上限限制
+	ChunkSize int
+	// DisableNative 禁用方言的原生导入方式，统一使用分批多行 INSERT
+	DisableNative bool
+	// Progress 每批完成后回调，loaded 为已导入行数，total 为总行数
+	Progress func(loaded, total int64)
+}
+
+type BulkLoadOption func(*BulkLoadOptions)
+
+func WithBulkColumns(columns ...string) BulkLoadOption {
+	return func(o *BulkLoadOptions) { o.Columns = columns }
+}
+
+func WithBulkChunkSize(n int) BulkLoadOption {
+	return func(o *BulkLoadOptions) { o.ChunkSize = n }
+}
+
+func WithBulkNative(enable bool) BulkLoadOption {
+	return func(o *BulkLoadOptions) { o.DisableNative = !enable }
+}
+
+func WithBulkProgress(fn func(loaded, total int64)) BulkLoadOption {
+	return func(o *BulkLoadOptions) { o.Progress = fn }
+}
+
+// BulkLoader 由方言可选实现的原生批量导入，例如 PostgreSQL COPY、MySQL LOAD DATA
+// 调用时 cli 已处于事务中；返回 ErrBulkNativeUnavailable 时回退到分批 INSERT
+type BulkLoader interface {
+	BulkLoad(cli *DBCli, table string, columns []string, rows [][]any, opt *BulkLoadOptions) (int64, error)
+}
+
+// BindParamLimiter 由方言可选实现，返回单条语句允许的最大绑定参数个数
+type BindParamLimiter interface {
+	MaxBindParams() int
+}
+
+// ErrBulkNativeUnavailable 原生导入方式不可用（例如服务端未开启 local_infile）
+var ErrBulkNativeUnavailable = errors.New("native bulk load unavailable")
+
+const (
+	defaultMaxBindParams = 65535
+	defaultBulkChunkSize = 1000
+)
+
+// BulkChunks 按绑定参数上限和 ChunkSize 切分数据，供方言实现复用
+func (o *BulkLoadOptions) BulkChunks(rows [][]any, columns int, maxParams int) [][][]any {
+	size := defaultBulkChunkSize
+	if o.ChunkSize > 0 {
+		size = o.ChunkSize
+	}
+	if columns > 0 && maxParams > 0 {
+		limit := max(1, maxParams/columns)
+		if o.ChunkSize <= 0 || limit < size {
+			size = limit
+		}
+	}
+	return slices.Collect(slices.Chunk(rows, size))
+}
+
+// Report 回调进度
+func (o *BulkLoadOptions) Report(loaded, total int64) {
+	if o.Progress != nil {
+		o.Progress(loaded, total)
+	}
+}
+
+func (d *DBCli) maxBindParams() int {
+	if sqlFunc, ok := GetSqlDialect(d.dbtype); ok {
+		if l, ok := sqlFunc.(BindParamLimiter); ok {
+			return l.MaxBindParams()
+		}
+	}
+	return defaultMaxBindParams
+}
+
+// Prepare 预编译语句，供方言原生批量导入使用，语句同样经过危险语句检查
+func (d *DBCli) Prepare(query string) (*sql.Stmt, error) {
+	if err := d.guardSQL(query); err != nil {
+		return nil, err
+	}
+	return d.oper().Prepare(query)
+}
+
+// Rebind 将 ? 占位符转换为当前驱动的占位符
+func (d *DBCli) Rebind(query string) string {
+	if r, ok := d.oper().(interface{ Rebind(string) string }); ok {
+		return r.Rebind(query)
+	}
+	return query
+}
+
+func bulkColumns(data []map[string]interface{}) []string {
+	seen := map[string]struct{}{}
+	var r []string
+	for _, row := range data {
+		for _, k := range maputil.Keys(row) {
+			if _, ok := seen[k]; !ok {
+				seen[k] = struct{}{}
+				r = append(r, k)
+			}
+		}
+	}
+	slices.Sort(r)
+	return r
+}
+
+// BulkInsert 高吞吐批量导入，在单个事务中执行
+// 优先使用方言的原生导入方式，否则按绑定参数上限切分为多行 INSERT
+// 租户隔离的表会强制写入上下文中的租户，未指定租户时返回 ErrTenantRequired
+func (d *DBCli) BulkInsert(tableName string, data []map[string]interface{}, opts ...BulkLoadOption) (int64, error) {
+	o := &BulkLoadOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if len(data) == 0 {
+		return 0, nil
+	}
+	tenantCol, tenant, scoped, err := d.tenantValue(tableName)
+	if err != nil {
+		return 0, err
+	}
+	items := make([]map[string]interface{}, 0, len(data))
+	for _, item := range data {
+		if item, err = d.fillSequenceKey(tableName, d.auditData(tableName, item, true)); err != nil {
+			return 0, err
+		}
+		if scoped {
+			// 原生导入不经过构建器，在这里强制写入当前租户
+			item = cloneData(item)
+			for k := range item {
+				if strings.EqualFold(k, tenantCol) {
+					delete(item, k)
+				}
+			}
+			item[tenantCol] = tenant
+		}
+		items = append(items, item)
+	}
+	// 审计列、序列主键和租户列在补充之后才出现，需要在补充后再计算导入的列
+	columns := o.Columns
+	if len(columns) == 0 {
+		columns = d.filterFields(tableName, bulkColumns(items))
+	} else {
+		columns = slices.Clone(columns)
+		if scoped {
+			columns = appendColumn(columns, tenantCol)
+		}
+		seq, pk, err := d.sequenceKey(tableName)
+		if err != nil {
+			return 0, err
+		}
+		if seq != "" {
+			columns = appendColumn(columns, pk.Name)
+		}
+	}
+	if len(columns) == 0 {
+		return 0, errors.New("no columns to load for table " + tableName)
+	}
+	rows := make([][]any, 0, len(items))
+	for _, item := range items {
+		row := make([]any, len(columns))
+		for i, c := range columns {
+			row[i] = item[c]
+		}
+		rows = append(rows, row)
+	}
+	var total int64
+	err = d.WithTransaction(func(tx *DBCli) error {
+		var err error
+		if !o.DisableNative {
+			if sqlFunc, ok := GetSqlDialect(d.dbtype); ok {
+				if loader, ok := sqlFunc.(BulkLoader); ok {
+					total, err = loader.BulkLoad(tx, tableName, columns, rows, o)
+					if !errors.Is(err, ErrBulkNativeUnavailable) {
+						return err
+					}
+					DBLog().Warn("native bulk load unavailable, fallback to insert", "table", tableName, "err", err)
+				}
+			}
+		}
+		total, err = tx.bulkInsertValues(tableName, columns, rows, o)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	d.invalidateBuilder(Builder().Table(tableName))
+	return total, nil
+}
+
+// bulkInsertValues 分批多行 INSERT
+func (d *DBCli) bulkInsertValues(tableName string, columns []string, rows [][]any, o *BulkLoadOptions) (int64, error) {
+	if d.dbtype == "oracle" {
+		// Oracle 不支持多行 VALUES
+		return d.BulkInsertPrepared(tableName, columns, rows, o)
+	}
+	table, cols, err := d.bulkTarget(tableName, columns)
+	if err != nil {
+		return 0, err
+	}
+	rowHolder := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	var loaded int64
+	for _, chunk := range o.BulkChunks(rows, len(columns), d.maxBindParams()) {
+		var sb strings.Builder
+		sb.WriteString("INSERT INTO ")
+		sb.WriteString(table)
+		sb.WriteString(" (")
+		sb.WriteString(strings.Join(cols, ", "))
+		sb.WriteString(") VALUES ")
+		args := make([]any, 0, len(chunk)*len(columns))
+		for i, row := range chunk {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(rowHolder)
+			args = append(args, row...)
+		}
+		query := d.Rebind(sb.String())
+		if err := d.guardSQL(query); err != nil {
+			return loaded, err
+		}
+		affected, err := d.excute(query, args...)
+		if err != nil {
+			return loaded, err
+		}
+		loaded += affected
+		o.Report(loaded, int64(len(rows)))
+	}
+	return loaded, nil
+}
+
+func (d *DBCli) bulkTarget(tableName string, columns []string) (string, []string, error) {
+	sqlFunc, ok := GetSqlTransformer(d.dbtype)
+	if !ok {
+		return "", nil, errors.New("not support db type: " + d.dbtype)
+	}
+	table, err := TABLE(tableName).ToSQL(sqlFunc)
+	if err != nil {
+		return "", nil, err
+	}
+	cols := make([]string, len(columns))
+	for i, c := range columns {
+		cols[i] = sqlFunc.EscapeColumnName(c)
+	}
+	return table, cols, nil
+}
+
+// BulkInsertPrepared 使用单条预编译 INSERT 逐行写入，需在事务中调用，供方言实现复用
+func (d *DBCli) BulkInsertPrepared(tableName string, columns []string, rows [][]any, o *BulkLoadOptions) (int64, error) {
+	table, cols, err := d.bulkTarget(tableName, columns)
+	if err != nil {
+		return 0, err
+	}
+	holders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	query := d.Rebind("INSERT INTO " + table + " (" + strings.Join(cols, ", ") + ") VALUES (" + holders + ")")
+	stmt, err := d.Prepare(query)
+	if err != nil {
+		return 0, fmt.Errorf("[BulkInsertPrepared]: %s | => %w", query, err)
+	}
+	defer stmt.Close()
+	var loaded int64
+	for _, chunk := range o.BulkChunks(rows, 0, 0) {
+		for _, row := range chunk {
+			if _, err := stmt.Exec(row...); err != nil {
+				return loaded, fmt.Errorf("[BulkInsertPrepared]: %s | => %w", query, err)
+			}
+			loaded++
+		}
+		o.Report(loaded, int64(len(rows)))
+	}
+	return loaded, nil
+}
+
+// appendColumn columns 中没有同名（忽略大小写）的列时追加 col
+func appendColumn(columns []string, col string) []string {
+	if slices.ContainsFunc(columns, func(c string) bool { return strings.EqualFold(c, col) }) {
+		return columns
+	}
+	return append(columns, col)
+}
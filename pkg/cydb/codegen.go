@@ -0,0 +1,416 @@
+package cydb
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// CodegenHeader 生成文件的首行，重新生成时只会删除带有该标记的文件
+const CodegenHeader = "// Code generated by cydb codegen. DO NOT EDIT."
+
+// codegenFileSuffix 生成文件的后缀，避免表名被识别为 _test 或 GOOS/GOARCH 构建约束
+const codegenFileSuffix = ".gen.go"
+
+// CodegenOptions 代码生成配置
+type CodegenOptions struct {
+	// Package 包名，默认 models
+	Package string
+	// CRUD 同时生成基于 DBCli 的 Repo
+	CRUD bool
+	// TypeName 表名到结构体名的转换，默认 GoIdentifier
+	TypeName func(table string) string
+	// Filter 表过滤条件
+	Filter []SchemaDocOption
+}
+
+type CodegenOption func(*CodegenOptions)
+
+func WithCodegenPackage(pkg string) CodegenOption {
+	return func(o *CodegenOptions) { o.Package = pkg }
+}
+
+func WithCodegenCRUD(enable bool) CodegenOption {
+	return func(o *CodegenOptions) { o.CRUD = enable }
+}
+
+func WithCodegenTypeName(fn func(table string) string) CodegenOption {
+	return func(o *CodegenOptions) { o.TypeName = fn }
+}
+
+func WithCodegenFilter(opts ...SchemaDocOption) CodegenOption {
+	return func(o *CodegenOptions) { o.Filter = append(o.Filter, opts...) }
+}
+
+func newCodegenOptions(opts []CodegenOption) *CodegenOptions {
+	o := &CodegenOptions{Package: "models", TypeName: GoIdentifier}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true, "GUID": true,
+	"HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true, "QPS": true, "RAM": true,
+	"RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true, "TCP": true, "TLS": true, "TTL": true,
+	"UDP": true, "UI": true, "UID": true, "UUID": true, "URI": true, "URL": true, "UTF8": true, "VM": true,
+	"XML": true,
+}
+
+// GoIdentifier 将表名、列名转换为导出的 Go 标识符，如 user_id -> UserID
+func GoIdentifier(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var sb strings.Builder
+	for _, w := range words {
+		if commonInitialisms[strings.ToUpper(w)] {
+			sb.WriteString(strings.ToUpper(w))
+			continue
+		}
+		// 全大写的单词（如 Oracle 列名）按小写处理
+		if strings.ToUpper(w) == w {
+			w = strings.ToLower(w)
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		sb.WriteString(string(r))
+	}
+	s := sb.String()
+	if s == "" || !unicode.IsLetter([]rune(s)[0]) {
+		s = "X" + s
+	}
+	return s
+}
+
+type codegenField struct {
+	Name    string
+	Type    string
+	Column  string
+	JSON    string
+	Comment string
+	PK      bool
+	Param   string
+	// Auto 自增列，为零值时插入不带该列
+	Auto bool
+}
+
+func (f *codegenField) ZeroCheck() string {
+	if strings.HasPrefix(f.Type, "sql.Null") {
+		return "!m." + f.Name + ".Valid"
+	}
+	return "m." + f.Name + " == 0"
+}
+
+type codegenTable struct {
+	Header  string
+	Package string
+	Imports []string
+	Table   string
+	Type    string
+	Comment string
+	Fields  []*codegenField
+	PK      []*codegenField
+	CRUD    bool
+}
+
+func (t *codegenTable) AutoFields() []*codegenField {
+	var r []*codegenField
+	for _, f := range t.Fields {
+		if f.Auto && (f.Type == "int64" || f.Type == "sql.NullInt64") {
+			r = append(r, f)
+		}
+	}
+	return r
+}
+
+// codegenType 根据列类型选择 Go 类型，可空列使用 sql.NullXXX
+// 主键列总是按非空处理（SQLite 的 INTEGER PRIMARY KEY 在 PRAGMA 中报告为可空）
+func codegenType(c *SchemaColumn) (string, string) {
+	nullable := c.Nullable && !c.PrimaryKey
+	switch strings.ToLower(c.DataType) {
+	case "boolean", "bool":
+		if nullable {
+			return "sql.NullBool", "database/sql"
+		}
+		return "bool", ""
+	}
+	switch c.FieldType {
+	case DBFieldTypeInt:
+		if nullable {
+			return "sql.NullInt64", "database/sql"
+		}
+		return "int64", ""
+	case DBFieldTypeFloat:
+		if nullable {
+			return "sql.NullFloat64", "database/sql"
+		}
+		return "float64", ""
+	case DBFieldTypeTime:
+		if nullable {
+			return "sql.NullTime", "database/sql"
+		}
+		return "time.Time", "time"
+	case DBFieldTypeBinary, DBFieldTypeBit:
+		return "[]byte", ""
+	case DBFieldTypeJson:
+		return "json.RawMessage", "encoding/json"
+	default:
+		if nullable {
+			return "sql.NullString", "database/sql"
+		}
+		return "string", ""
+	}
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func newCodegenTable(ts *TableSchema, o *CodegenOptions) *codegenTable {
+	t := &codegenTable{
+		Header:  CodegenHeader,
+		Package: o.Package,
+		Table:   ts.Name,
+		Type:    o.TypeName(ts.Name),
+		Comment: singleLine(ts.Comment),
+		CRUD:    o.CRUD,
+	}
+	imports := map[string]bool{}
+	used := map[string]int{}
+	for _, c := range ts.Columns {
+		name := GoIdentifier(c.Name)
+		if n := used[name]; n > 0 {
+			used[name]++
+			name = fmt.Sprintf("%s%d", name, n+1)
+		} else {
+			used[name] = 1
+		}
+		tp, imp := codegenType(c)
+		if imp != "" {
+			imports[imp] = true
+		}
+		param := []rune(name)
+		param[0] = unicode.ToLower(param[0])
+		p := string(param)
+		if strings.ToUpper(name) == name {
+			p = strings.ToLower(name)
+		}
+		if token.IsKeyword(p) {
+			p += "Val"
+		}
+		f := &codegenField{
+			Name:    name,
+			Type:    tp,
+			Column:  c.Name,
+			JSON:    strings.ToLower(c.Name),
+			Comment: singleLine(c.Comment),
+			PK:      c.PrimaryKey,
+			Param:   p,
+			Auto:    c.AutoIncrement,
+		}
+		t.Fields = append(t.Fields, f)
+		if f.PK {
+			t.PK = append(t.PK, f)
+		}
+	}
+	imports["strings"] = true
+	if o.CRUD {
+		imports["github.com/fj1981/infrakit/pkg/cydb"] = true
+	}
+	for imp := range imports {
+		t.Imports = append(t.Imports, imp)
+	}
+	slices.Sort(t.Imports)
+	return t
+}
+
+var codegenTemplate = template.Must(template.New("model").Parse(`{{.Header}}
+
+package {{.Package}}
+{{if .Imports}}
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+{{end}}
+// {{.Type}}Table 表名
+const {{.Type}}Table = "{{.Table}}"
+
+// {{.Type}} {{if .Comment}}{{.Comment}}{{else}}{{.Table}} 表{{end}}
+type {{.Type}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} ` + "`" + `db:"{{.Column}}" json:"{{.JSON}}"{{if .PK}} pk:"true"{{end}}` + "`" + `{{if .Comment}} // {{.Comment}}{{end}}
+{{- end}}
+}
+
+// ToMap 转为 DBCli 增删改使用的数据，键为列名
+func (m *{{.Type}}) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+{{- range .Fields}}
+		"{{.Column}}": m.{{.Name}},
+{{- end}}
+	}
+}
+
+// ToMapOmit 同 ToMap，去掉 columns 中的列（不区分大小写），用于排除审计列等由 DBCli 维护的列
+func (m *{{.Type}}) ToMapOmit(columns ...string) map[string]interface{} {
+	data := m.ToMap()
+	for _, c := range columns {
+		for k := range data {
+			if strings.EqualFold(k, c) {
+				delete(data, k)
+			}
+		}
+	}
+	return data
+}
+{{- if .CRUD}}
+
+// {{.Type}}Repo {{.Table}} 表的增删改查，实现 cydb.IRepo
+type {{.Type}}Repo struct {
+	cli *cydb.DBCli
+}
+
+func New{{.Type}}Repo(cli *cydb.DBCli) *{{.Type}}Repo {
+	return &{{.Type}}Repo{cli: cli}
+}
+
+func (r *{{.Type}}Repo) GetDBCli() *cydb.DBCli {
+	return r.cli
+}
+
+func (r *{{.Type}}Repo) SetDBCli(cli *cydb.DBCli) {
+	r.cli = cli
+}
+
+func (r *{{.Type}}Repo) List(data map[string]interface{}, cc ...cydb.FuncWithBuilder) ([]*{{.Type}}, error) {
+	return cydb.ListAs[{{.Type}}](r.cli, {{.Type}}Table, data, cc...)
+}
+
+func (r *{{.Type}}Repo) First(data map[string]interface{}, cc ...cydb.FuncWithBuilder) (*{{.Type}}, error) {
+	return cydb.FirstAs[{{.Type}}](r.cli, {{.Type}}Table, data, cc...)
+}
+
+func (r *{{.Type}}Repo) Count(data map[string]interface{}, cc ...cydb.FuncWithBuilder) (int64, error) {
+	return r.cli.Count({{.Type}}Table, data, cc...)
+}
+
+// Insert 审计列由 DBCli 按审计策略填充，不使用 m 中的值
+func (r *{{.Type}}Repo) Insert(m *{{.Type}}) (int64, error) {
+	data := m.ToMapOmit(r.cli.AuditColumns({{.Type}}Table)...)
+{{- range .AutoFields}}
+	if {{.ZeroCheck}} {
+		delete(data, "{{.Column}}")
+	}
+{{- end}}
+	return r.cli.Insert({{.Type}}Table, data)
+}
+{{- if .PK}}
+
+func (r *{{.Type}}Repo) Get({{range $i, $f := .PK}}{{if $i}}, {{end}}{{$f.Param}} {{$f.Type}}{{end}}) (*{{.Type}}, error) {
+	return cydb.FirstAs[{{.Type}}](r.cli, {{.Type}}Table, map[string]interface{}{ {{- range $i, $f := .PK}}{{if $i}}, {{end}}"{{$f.Column}}": {{$f.Param}}{{end -}} }, cydb.WithEQ({{range $i, $f := .PK}}{{if $i}}, {{end}}"{{$f.Column}}"{{end}}))
+}
+
+// Update 按主键更新其余列，主键和审计列不会被 m 中的值覆盖
+func (r *{{.Type}}Repo) Update(m *{{.Type}}) (int64, error) {
+	pk := []string{ {{- range $i, $f := .PK}}{{if $i}}, {{end}}"{{$f.Column}}"{{end -}} }
+	return r.cli.Update({{.Type}}Table, m.ToMapOmit(r.cli.AuditColumns({{.Type}}Table)...), cydb.WithEQ(pk...), cydb.WithOmitFields(pk...))
+}
+
+func (r *{{.Type}}Repo) Delete({{range $i, $f := .PK}}{{if $i}}, {{end}}{{$f.Param}} {{$f.Type}}{{end}}) (int64, error) {
+	return r.cli.Delete({{.Type}}Table, map[string]interface{}{ {{- range $i, $f := .PK}}{{if $i}}, {{end}}"{{$f.Column}}": {{$f.Param}}{{end -}} }, cydb.WithEQ({{range $i, $f := .PK}}{{if $i}}, {{end}}"{{$f.Column}}"{{end}}))
+}
+{{- end}}
+{{- end}}
+`))
+
+// CodegenFileName 表对应的生成文件名
+func CodegenFileName(table string) string {
+	return strings.ToLower(erIdent(table)) + codegenFileSuffix
+}
+
+// GenerateModels 根据表结构生成 Go 代码，返回文件名到内容的映射，相同输入总是生成相同输出
+func GenerateModels(schemas []*TableSchema, opts ...CodegenOption) (map[string][]byte, error) {
+	o := newCodegenOptions(opts)
+	files := map[string][]byte{}
+	for _, ts := range schemas {
+		var buf bytes.Buffer
+		if err := codegenTemplate.Execute(&buf, newCodegenTable(ts, o)); err != nil {
+			return nil, err
+		}
+		src, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("format code of %s: %w", ts.Name, err)
+		}
+		name := CodegenFileName(ts.Name)
+		if _, ok := files[name]; ok {
+			return nil, fmt.Errorf("duplicate generated file %s for table %s", name, ts.Name)
+		}
+		files[name] = src
+	}
+	return files, nil
+}
+
+// GenerateModels 读取表结构并生成 Go 代码
+func (d *DBCli) GenerateModels(opts ...CodegenOption) (map[string][]byte, error) {
+	schemas, err := d.LoadTableSchemas(newCodegenOptions(opts).Filter...)
+	if err != nil {
+		return nil, err
+	}
+	return GenerateModels(schemas, opts...)
+}
+
+// GenerateModelsToDir 生成代码并写入目录，同时删除目录中不再对应任何表的旧生成文件
+func (d *DBCli) GenerateModelsToDir(dir string, opts ...CodegenOption) error {
+	files, err := d.GenerateModels(opts...)
+	if err != nil {
+		return err
+	}
+	return WriteGeneratedFiles(dir, files)
+}
+
+// WriteGeneratedFiles 写入生成的文件，只删除带有 CodegenHeader 标记的旧文件
+func WriteGeneratedFiles(dir string, files map[string][]byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, codegenFileSuffix) {
+			continue
+		}
+		if _, ok := files[name]; ok {
+			continue
+		}
+		p := filepath.Join(dir, name)
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if bytes.HasPrefix(content, []byte(CodegenHeader)) {
+			if err := os.Remove(p); err != nil {
+				return err
+			}
+		}
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package cydb
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fj1981/infrakit/pkg/cyutil"
+)
+
+// SecretProvider 外部密钥来源，如 Vault、KMS，按名称返回明文
+type SecretProvider interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// SecretProviderFunc 函数形式的 SecretProvider
+type SecretProviderFunc func(ctx context.Context, name string) (string, error)
+
+// GetSecret implements SecretProvider.
+func (f SecretProviderFunc) GetSecret(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+var (
+	secretProvidersMu sync.RWMutex
+	secretProviders   = map[string]SecretProvider{}
+)
+
+// RegisterSecretProvider 注册凭据引用 scheme:name 的解析方，p 为 nil 时取消注册；env、file 为内置 scheme，注册同名解析方不生效
+func RegisterSecretProvider(scheme string, p SecretProvider) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+	if p == nil {
+		delete(secretProviders, scheme)
+		return
+	}
+	secretProviders[scheme] = p
+}
+
+// ResolveCredential 解析凭据引用：
+// env:NAME 读取环境变量，file:PATH 读取文件并去掉首尾空白，scheme:name 交给 RegisterSecretProvider 注册的解析方，
+// 解析结果或不带 scheme 的值为 ENC(...) 时按 cyutil.RealVal 解密
+func ResolveCredential(ctx context.Context, ref string) (string, error) {
+	scheme, name, ok := strings.Cut(ref, ":")
+	if !ok {
+		return cyutil.RealVal(ref), nil
+	}
+	var (
+		val string
+		err error
+	)
+	switch scheme {
+	case "env":
+		var found bool
+		if val, found = os.LookupEnv(name); !found {
+			return "", fmt.Errorf("credential env %s not set", name)
+		}
+	case "file":
+		var b []byte
+		if b, err = os.ReadFile(name); err != nil {
+			return "", fmt.Errorf("read credential file: %w", err)
+		}
+		val = strings.TrimSpace(string(b))
+	default:
+		secretProvidersMu.RLock()
+		p, found := secretProviders[scheme]
+		secretProvidersMu.RUnlock()
+		if !found {
+			return "", fmt.Errorf("unknown credential scheme %q", scheme)
+		}
+		if val, err = p.GetSecret(ctx, name); err != nil {
+			return "", fmt.Errorf("resolve credential %s: %w", ref, err)
+		}
+	}
+	return cyutil.RealVal(val), nil
+}
+
+// ResolvePassword 返回连接使用的明文密码，设置了 PwRef 时优先解析 PwRef，否则 Pw 可为 ENC(...) 加密值
+func (v *DBConnection) ResolvePassword(ctx context.Context) (string, error) {
+	if v.PwRef != "" {
+		return ResolveCredential(ctx, v.PwRef)
+	}
+	return cyutil.RealVal(v.Pw), nil
+}
+
+type credentialRefresher struct {
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// StartCredentialRefresh 每隔 interval 重新解析设置了 PwRef 的连接的密码，变化时用新密码原地替换连接池
+// interval 须大于 0；重复调用时只保留第一次启动的刷新
+func (s *DBMgr) StartCredentialRefresh(interval time.Duration) error {
+	if interval <= 0 {
+		return &DatabaseError{Code: ErrCodeInvalidParam, Message: fmt.Sprintf("credential refresh interval must be positive, got %s", interval)}
+	}
+	s.refresher.mu.Lock()
+	defer s.refresher.mu.Unlock()
+	if s.refresher.stop != nil {
+		return nil
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	s.refresher.stop, s.refresher.done = stop, done
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				if err := s.RefreshCredentials(ctx); err != nil {
+					DBLog().Warn("refresh db credentials failed", "err", err)
+				}
+				cancel()
+			}
+		}
+	}()
+	return nil
+}
+
+// StopCredentialRefresh 停止凭据刷新并等待正在进行的刷新结束
+func (s *DBMgr) StopCredentialRefresh() {
+	s.refresher.mu.Lock()
+	stop, done := s.refresher.stop, s.refresher.done
+	s.refresher.stop, s.refresher.done = nil, nil
+	s.refresher.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// RefreshCredentials 立即重新解析一次所有连接的 PwRef，密码变化的连接用新密码重新打开连接池并原地替换
+// DBCli 本身不变，调用方持有的 DBCli 及其副本继续可用，旧连接池在正在执行的查询结束后关闭
+func (s *DBMgr) RefreshCredentials(ctx context.Context) error {
+	s.reconnectMu.Lock()
+	defer s.reconnectMu.Unlock()
+	var errs []error
+	clis, keys := s.uniqueClis()
+	for _, cli := range clis {
+		if cli.conn == nil || cli.conn.PwRef == "" || cli.pool == nil || cli.pool.isClosed() {
+			continue
+		}
+		ks := keys[cli]
+		pw, err := cli.conn.ResolvePassword(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ks[0], err))
+			continue
+		}
+		if sha256.Sum256([]byte(pw)) == cli.pool.pwSum {
+			continue
+		}
+		if err := cli.reopenWith(pw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: reconnect with rotated credential: %w", ks[0], err))
+			continue
+		}
+		DBLog().Info("db credential rotated", "key", ks[0])
+	}
+	return errors.Join(errs...)
+}
+
+// uniqueClis 返回去重后的 DBCli 及各自注册的 key，key 已排序
+func (s *DBMgr) uniqueClis() ([]*DBCli, map[*DBCli][]string) {
+	keys := map[*DBCli][]string{}
+	var order []*DBCli
+	s.dbclis.Range(func(k, v interface{}) bool {
+		cli, ok := v.(*DBCli)
+		if !ok {
+			return true
+		}
+		if _, ok := keys[cli]; !ok {
+			order = append(order, cli)
+		}
+		keys[cli] = append(keys[cli], k.(string))
+		return true
+	})
+	for _, ks := range keys {
+		slices.Sort(ks)
+	}
+	return order, keys
+}
@@ -0,0 +1,83 @@
+package cydbtest_test
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/fj1981/infrakit/pkg/cydb/cydbtest"
+	_ "github.com/fj1981/infrakit/pkg/cydb/sql/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	cli, rec, err := cydbtest.Record(&cydb.DBConnection{Key: "rec", Type: "sqlite", Path: filepath.Join(dir, "rec.db")})
+	require.NoError(t, err)
+	_, err = cli.GetDB().Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, created DATETIME)")
+	require.NoError(t, err)
+	rec.Reset()
+
+	run := func(cli *cydb.DBCli) []map[string]interface{} {
+		n, err := cli.NExcute("INSERT INTO users (id, name) VALUES (:id, :name)", map[string]interface{}{"id": 1, "name": "alice"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		_, err = cli.NExcute("INSERT INTO users (id, name) VALUES (:id, :name)", map[string]interface{}{"id": 2, "name": "bob"})
+		require.NoError(t, err)
+		rows, err := cli.NQuery("SELECT id, name FROM users WHERE id IN (:ids) ORDER BY id", map[string]interface{}{"ids": []int{1, 2}})
+		require.NoError(t, err)
+		return rows
+	}
+	recorded := run(cli)
+	require.Len(t, recorded, 2)
+	_, err = cli.Query("SELECT * FROM missing")
+	require.Error(t, err)
+	fixture := filepath.Join(dir, "users.json")
+	require.NoError(t, rec.Save(fixture))
+	require.NoError(t, cli.Close())
+
+	replay, rp, err := cydbtest.ReplayFile("sqlite", fixture)
+	require.NoError(t, err)
+	assert.Equal(t, recorded, run(replay))
+	_, err = replay.Query("SELECT * FROM missing")
+	assert.EqualError(t, err, "[query]: SELECT * FROM missing | => SQL logic error: no such table: missing (1)")
+	require.NoError(t, rp.ExpectationsWereMet())
+
+	_, err = replay.Query("SELECT 1")
+	assert.True(t, errors.Is(err, cydbtest.ErrUnexpectedSQL))
+}
+
+func TestRecordResolvesPassword(t *testing.T) {
+	conn := &cydb.DBConnection{Key: "rec_pw", Type: "sqlite", Path: filepath.Join(t.TempDir(), "rec.db"), PwRef: "env:CYDBTEST_RECORD_PW"}
+	_, _, err := cydbtest.Record(conn)
+	require.Error(t, err, "unset PwRef must fail like TryConnect")
+
+	t.Setenv("CYDBTEST_RECORD_PW", "secret")
+	cli, _, err := cydbtest.Record(conn)
+	require.NoError(t, err)
+	require.NoError(t, cli.Close())
+}
+
+func TestMock(t *testing.T) {
+	cli, mock, err := cydbtest.NewMock("sqlite")
+	require.NoError(t, err)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name FROM users WHERE id = ?")).WithArgs(7).
+		WillReturnRows([]string{"id", "name"}, []any{7, "alice"})
+	mock.ExpectExec("^DELETE FROM users").WillReturnResult(0, 3)
+
+	row, err := cli.Query("SELECT id, name FROM users WHERE id = ?", 7)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"id": int64(7), "name": "alice"}}, row)
+	n, err := cli.NExcute("DELETE FROM users WHERE name = :name", map[string]interface{}{"name": "x"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	mock.ExpectExec("^UPDATE").WithArgs("a").WillReturnError(errors.New("boom"))
+	_, err = cli.Query("SELECT 1")
+	assert.True(t, errors.Is(err, cydbtest.ErrUnexpectedSQL))
+	assert.Error(t, mock.ExpectationsWereMet())
+}
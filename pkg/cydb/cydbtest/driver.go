@@ -0,0 +1,288 @@
+// Package cydbtest 为 cydb.DBCli 提供无需数据库的测试替身：
+// Record 记录真实数据库上的 SQL 交互并保存为 fixture，Replay 按 fixture 回放，NewMock 按预期声明返回结果。
+// 三者都基于一个假的 database/sql 驱动，返回的 DBCli 与正式连接行为一致。
+package cydbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	KindQuery = "query"
+	KindExec  = "exec"
+)
+
+// Value 可序列化的驱动值
+type Value struct {
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+}
+
+// EncodeValue 将驱动值编码为 Value，未知类型按 %v 保存，只用于比较
+func EncodeValue(v any) Value {
+	switch x := v.(type) {
+	case nil:
+		return Value{Type: "null"}
+	case int64:
+		return Value{Type: "int64", Value: strconv.FormatInt(x, 10)}
+	case float64:
+		return Value{Type: "float64", Value: strconv.FormatFloat(x, 'g', -1, 64)}
+	case bool:
+		return Value{Type: "bool", Value: strconv.FormatBool(x)}
+	case string:
+		return Value{Type: "string", Value: x}
+	case []byte:
+		return Value{Type: "bytes", Value: base64.StdEncoding.EncodeToString(x)}
+	case time.Time:
+		return Value{Type: "time", Value: x.Format(time.RFC3339Nano)}
+	default:
+		return Value{Type: fmt.Sprintf("%T", v), Value: fmt.Sprintf("%v", v)}
+	}
+}
+
+// Decode 还原驱动值
+func (v Value) Decode() (driver.Value, error) {
+	switch v.Type {
+	case "null":
+		return nil, nil
+	case "int64":
+		return strconv.ParseInt(v.Value, 10, 64)
+	case "float64":
+		return strconv.ParseFloat(v.Value, 64)
+	case "bool":
+		return strconv.ParseBool(v.Value)
+	case "string":
+		return v.Value, nil
+	case "bytes":
+		return base64.StdEncoding.DecodeString(v.Value)
+	case "time":
+		return time.Parse(time.RFC3339Nano, v.Value)
+	default:
+		return nil, fmt.Errorf("cydbtest: cannot decode value of type %s", v.Type)
+	}
+}
+
+// Interaction 一次 SQL 调用及其结果
+type Interaction struct {
+	Kind         string    `json:"kind"`
+	SQL          string    `json:"sql"`
+	Args         []Value   `json:"args,omitempty"`
+	Columns      []string  `json:"columns,omitempty"`
+	Rows         [][]Value `json:"rows,omitempty"`
+	LastInsertID int64     `json:"last_insert_id,omitempty"`
+	RowsAffected int64     `json:"rows_affected,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+func (it *Interaction) err() error {
+	if it.Error == "" {
+		return nil
+	}
+	return errors.New(it.Error)
+}
+
+// ErrUnexpectedSQL 回放或 Mock 遇到没有对应记录或预期的 SQL
+var ErrUnexpectedSQL = errors.New("cydbtest: unexpected sql")
+
+// NormalizeSQL 合并空白字符，用于比较 SQL
+func NormalizeSQL(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+func encodeArgs(args []driver.NamedValue) []Value {
+	r := make([]Value, 0, len(args))
+	for _, a := range args {
+		r = append(r, EncodeValue(a.Value))
+	}
+	return r
+}
+
+// handler 处理一个连接上的调用
+type handler interface {
+	handle(ctx context.Context, kind, query string, args []driver.NamedValue) (*Interaction, error)
+	begin(ctx context.Context, opts driver.TxOptions) (driver.Tx, error)
+	checkNamedValue(nv *driver.NamedValue) error
+	close() error
+}
+
+type connector struct {
+	open func() (handler, error)
+}
+
+func (c *connector) Connect(context.Context) (driver.Conn, error) {
+	h, err := c.open()
+	if err != nil {
+		return nil, err
+	}
+	return &conn{h: h}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return fakeDriver{c}
+}
+
+type fakeDriver struct {
+	c *connector
+}
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	return d.c.Connect(context.Background())
+}
+
+type conn struct {
+	h handler
+}
+
+var (
+	_ driver.QueryerContext    = (*conn)(nil)
+	_ driver.ExecerContext     = (*conn)(nil)
+	_ driver.ConnBeginTx       = (*conn)(nil)
+	_ driver.NamedValueChecker = (*conn)(nil)
+)
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{c: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return c.h.close()
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.h.begin(ctx, opts)
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	return c.h.checkNamedValue(nv)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	it, err := c.h.handle(ctx, KindQuery, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(it)
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	it, err := c.h.handle(ctx, KindExec, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return result{it.LastInsertID, it.RowsAffected}, nil
+}
+
+type stmt struct {
+	c     *conn
+	query string
+}
+
+func (s *stmt) Close() error  { return nil }
+func (s *stmt) NumInput() int { return -1 }
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.c.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.c.QueryContext(ctx, s.query, args)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	r := make([]driver.NamedValue, len(args))
+	for i, a := range args {
+		r[i] = driver.NamedValue{Ordinal: i + 1, Value: a}
+	}
+	return r
+}
+
+type result struct {
+	lastID, affected int64
+}
+
+func (r result) LastInsertId() (int64, error) { return r.lastID, nil }
+func (r result) RowsAffected() (int64, error) { return r.affected, nil }
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+	pos     int
+}
+
+func newRows(it *Interaction) (*rows, error) {
+	r := &rows{columns: it.Columns}
+	for _, row := range it.Rows {
+		vals := make([]driver.Value, len(row))
+		for i, v := range row {
+			d, err := v.Decode()
+			if err != nil {
+				return nil, err
+			}
+			vals[i] = d
+		}
+		r.values = append(r.values, vals)
+	}
+	return r, nil
+}
+
+func (r *rows) Columns() []string { return slices.Clone(r.columns) }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.pos])
+	r.pos++
+	return nil
+}
+
+type noopTx struct{}
+
+func (noopTx) Commit() error   { return nil }
+func (noopTx) Rollback() error { return nil }
+
+// checkNamedValue 按默认规则转换参数，无法转换的驱动专有类型原样保留
+func checkNamedValue(nv *driver.NamedValue) error {
+	v, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err == nil {
+		nv.Value = v
+	}
+	return nil
+}
+
+// newDBCli 使用假驱动创建 DBCli，驱动名取自方言以保证占位符风格一致
+func newDBCli(dbtype, key, database string, c *connector) (*cydb.DBCli, error) {
+	dialect, ok := cydb.GetSqlDialect(dbtype)
+	if !ok {
+		return nil, errors.New("not support db type: " + dbtype)
+	}
+	driverName, _ := dialect.GetConnectStr(&cydb.DBConnection{Type: dbtype})
+	db := sqlx.NewDb(sql.OpenDB(c), driverName)
+	return cydb.NewDBCli(db, dbtype, key, database, "", ""), nil
+}
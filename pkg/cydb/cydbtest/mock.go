@@ -0,0 +1,150 @@
+package cydbtest
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/fj1981/infrakit/pkg/cydb"
+)
+
+// Mock 按声明顺序匹配 SQL 的预期式替身
+type Mock struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	next         int
+	errs         []error
+}
+
+// Expectation 一条预期的 SQL 调用
+type Expectation struct {
+	kind    string
+	pattern *regexp.Regexp
+	args    []Value
+	anyArgs bool
+	result  Interaction
+}
+
+// NewMock 创建预期式 DBCli，dbtype 决定 SQL 方言与占位符
+func NewMock(dbtype string) (*cydb.DBCli, *Mock, error) {
+	m := &Mock{}
+	cli, err := newDBCli(dbtype, "mock", "", &connector{open: func() (handler, error) { return m, nil }})
+	if err != nil {
+		return nil, nil, err
+	}
+	return cli, m, nil
+}
+
+func (m *Mock) expect(kind, pattern string) *Expectation {
+	e := &Expectation{kind: kind, pattern: regexp.MustCompile(pattern), anyArgs: true}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// ExpectQuery 预期一条查询，pattern 为匹配规整后 SQL 的正则表达式，字面量可用 regexp.QuoteMeta
+func (m *Mock) ExpectQuery(pattern string) *Expectation {
+	return m.expect(KindQuery, pattern)
+}
+
+// ExpectExec 预期一条执行语句
+func (m *Mock) ExpectExec(pattern string) *Expectation {
+	return m.expect(KindExec, pattern)
+}
+
+// WithArgs 要求参数完全一致，未调用时不检查参数
+func (e *Expectation) WithArgs(args ...any) *Expectation {
+	e.anyArgs = false
+	e.args = make([]Value, len(args))
+	for i, a := range args {
+		e.args[i] = EncodeValue(mustConvert(a))
+	}
+	return e
+}
+
+// WillReturnRows 设置查询结果
+func (e *Expectation) WillReturnRows(columns []string, rows ...[]any) *Expectation {
+	e.result.Columns = columns
+	for _, row := range rows {
+		vals := make([]Value, len(row))
+		for i, v := range row {
+			vals[i] = EncodeValue(mustConvert(v))
+		}
+		e.result.Rows = append(e.result.Rows, vals)
+	}
+	return e
+}
+
+// WillReturnResult 设置执行结果
+func (e *Expectation) WillReturnResult(lastInsertID, rowsAffected int64) *Expectation {
+	e.result.LastInsertID, e.result.RowsAffected = lastInsertID, rowsAffected
+	return e
+}
+
+// WillReturnError 调用返回错误
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.result.Error = err.Error()
+	return e
+}
+
+func mustConvert(v any) driver.Value {
+	r, err := driver.DefaultParameterConverter.ConvertValue(v)
+	if err != nil {
+		panic(fmt.Sprintf("cydbtest: unsupported value %v: %v", v, err))
+	}
+	return r
+}
+
+// ExpectationsWereMet 所有预期都已满足且没有出现意外调用时返回 nil
+func (m *Mock) ExpectationsWereMet() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	errs := slices.Clone(m.errs)
+	if m.next < len(m.expectations) {
+		e := m.expectations[m.next]
+		errs = append(errs, fmt.Errorf("cydbtest: %d expectations were not met, next: %s %s", len(m.expectations)-m.next, e.kind, e.pattern))
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Mock) handle(_ context.Context, kind, query string, args []driver.NamedValue) (*Interaction, error) {
+	sql, values := NormalizeSQL(query), encodeArgs(args)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var err error
+	if m.next >= len(m.expectations) {
+		err = fmt.Errorf("%w: %s %s %v, all expectations were already met", ErrUnexpectedSQL, kind, sql, values)
+	} else {
+		e := m.expectations[m.next]
+		switch {
+		case e.kind != kind || !e.pattern.MatchString(sql):
+			err = fmt.Errorf("%w: %s %s, expected %s %s", ErrUnexpectedSQL, kind, sql, e.kind, e.pattern)
+		case !e.anyArgs && !slices.Equal(e.args, values):
+			err = fmt.Errorf("%w: %s with args %v, expected %v", ErrUnexpectedSQL, sql, values, e.args)
+		default:
+			m.next++
+			res := e.result
+			res.Kind, res.SQL, res.Args = kind, query, values
+			return &res, res.err()
+		}
+	}
+	m.errs = append(m.errs, err)
+	return nil, err
+}
+
+func (m *Mock) begin(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return noopTx{}, nil
+}
+
+func (m *Mock) checkNamedValue(nv *driver.NamedValue) error {
+	return checkNamedValue(nv)
+}
+
+func (m *Mock) close() error {
+	return nil
+}
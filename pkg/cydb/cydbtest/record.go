@@ -0,0 +1,209 @@
+package cydbtest
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/fj1981/infrakit/pkg/cydb"
+)
+
+// Recorder 记录真实数据库上的所有 SQL 交互
+type Recorder struct {
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// Record 按 conn 连接真实数据库，密码解析及方言的连接方式与 TryConnect 相同，返回的 DBCli 执行的每条 SQL 及其结果都会被记录
+func Record(conn *cydb.DBConnection) (*cydb.DBCli, *Recorder, error) {
+	drv, dsn, err := cydb.OpenDriver(conn)
+	if err != nil {
+		return nil, nil, err
+	}
+	open := func() (driver.Conn, error) { return drv.Open(dsn) }
+	if dc, ok := drv.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		open = func() (driver.Conn, error) { return c.Connect(context.Background()) }
+	}
+	r := &Recorder{}
+	cli, err := newDBCli(conn.Type, conn.Key, conn.DBName, &connector{open: func() (handler, error) {
+		c, err := open()
+		if err != nil {
+			return nil, err
+		}
+		return &recordHandler{r: r, c: c}, nil
+	}})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cli.GetDB().Ping(); err != nil {
+		_ = cli.Close()
+		return nil, nil, err
+	}
+	return cli, r, nil
+}
+
+// Interactions 已记录的交互
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.interactions)
+}
+
+// Reset 清空记录，例如忽略建表等准备阶段的 SQL
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = nil
+}
+
+// Save 保存为 JSON fixture 文件
+func (r *Recorder) Save(path string) error {
+	data, err := json.MarshalIndent(r.Interactions(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (r *Recorder) add(it *Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, *it)
+}
+
+type recordHandler struct {
+	r *Recorder
+	c driver.Conn
+}
+
+func (h *recordHandler) handle(ctx context.Context, kind, query string, args []driver.NamedValue) (*Interaction, error) {
+	it := &Interaction{Kind: kind, SQL: query, Args: encodeArgs(args)}
+	var err error
+	if kind == KindQuery {
+		err = h.query(ctx, it, args)
+	} else {
+		err = h.exec(ctx, it, args)
+	}
+	if err != nil {
+		it.Error = err.Error()
+	}
+	h.r.add(it)
+	return it, err
+}
+
+func (h *recordHandler) query(ctx context.Context, it *Interaction, args []driver.NamedValue) error {
+	var rs driver.Rows
+	var err error = driver.ErrSkip
+	if qc, ok := h.c.(driver.QueryerContext); ok {
+		rs, err = qc.QueryContext(ctx, it.SQL, args)
+	}
+	if errors.Is(err, driver.ErrSkip) {
+		st, perr := h.prepare(ctx, it.SQL)
+		if perr != nil {
+			return perr
+		}
+		defer st.Close()
+		rs, err = stmtQuery(ctx, st, args)
+	}
+	if err != nil {
+		return err
+	}
+	defer rs.Close()
+	it.Columns = rs.Columns()
+	dest := make([]driver.Value, len(it.Columns))
+	for {
+		if err := rs.Next(dest); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		row := make([]Value, len(dest))
+		for i, v := range dest {
+			row[i] = EncodeValue(v)
+		}
+		it.Rows = append(it.Rows, row)
+	}
+}
+
+func (h *recordHandler) exec(ctx context.Context, it *Interaction, args []driver.NamedValue) error {
+	var res driver.Result
+	var err error = driver.ErrSkip
+	if ec, ok := h.c.(driver.ExecerContext); ok {
+		res, err = ec.ExecContext(ctx, it.SQL, args)
+	}
+	if errors.Is(err, driver.ErrSkip) {
+		st, perr := h.prepare(ctx, it.SQL)
+		if perr != nil {
+			return perr
+		}
+		defer st.Close()
+		res, err = stmtExec(ctx, st, args)
+	}
+	if err != nil {
+		return err
+	}
+	// 部分驱动不支持 LastInsertId，记录为 0
+	it.LastInsertID, _ = res.LastInsertId()
+	it.RowsAffected, _ = res.RowsAffected()
+	return nil
+}
+
+func (h *recordHandler) prepare(ctx context.Context, query string) (driver.Stmt, error) {
+	if pc, ok := h.c.(driver.ConnPrepareContext); ok {
+		return pc.PrepareContext(ctx, query)
+	}
+	return h.c.Prepare(query)
+}
+
+func stmtQuery(ctx context.Context, st driver.Stmt, args []driver.NamedValue) (driver.Rows, error) {
+	if sq, ok := st.(driver.StmtQueryContext); ok {
+		return sq.QueryContext(ctx, args)
+	}
+	return st.Query(plainValues(args))
+}
+
+func stmtExec(ctx context.Context, st driver.Stmt, args []driver.NamedValue) (driver.Result, error) {
+	if se, ok := st.(driver.StmtExecContext); ok {
+		return se.ExecContext(ctx, args)
+	}
+	return st.Exec(plainValues(args))
+}
+
+func plainValues(args []driver.NamedValue) []driver.Value {
+	r := make([]driver.Value, len(args))
+	for i, a := range args {
+		r[i] = a.Value
+	}
+	return r
+}
+
+func (h *recordHandler) begin(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if bc, ok := h.c.(driver.ConnBeginTx); ok {
+		return bc.BeginTx(ctx, opts)
+	}
+	return h.c.Begin()
+}
+
+func (h *recordHandler) checkNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := h.c.(driver.NamedValueChecker); ok {
+		err := nc.CheckNamedValue(nv)
+		if !errors.Is(err, driver.ErrSkip) {
+			return err
+		}
+	}
+	return checkNamedValue(nv)
+}
+
+func (h *recordHandler) close() error {
+	return h.c.Close()
+}
@@ -0,0 +1,100 @@
+package cydbtest
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/fj1981/infrakit/pkg/cydb"
+)
+
+// Replayer 按记录的交互返回结果，SQL 与参数都相同才算匹配，相同的调用按记录顺序依次返回
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// LoadFixture 读取 Recorder.Save 保存的文件
+func LoadFixture(path string) ([]Interaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r []Interaction
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("cydbtest: invalid fixture %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Replay 创建回放记录的 DBCli，dbtype 决定 SQL 方言与占位符，必须与记录时一致
+func Replay(dbtype string, interactions []Interaction) (*cydb.DBCli, *Replayer, error) {
+	r := &Replayer{interactions: interactions, used: make([]bool, len(interactions))}
+	cli, err := newDBCli(dbtype, "replay", "", &connector{open: func() (handler, error) { return r, nil }})
+	if err != nil {
+		return nil, nil, err
+	}
+	return cli, r, nil
+}
+
+// ReplayFile 从 fixture 文件回放
+func ReplayFile(dbtype, path string) (*cydb.DBCli, *Replayer, error) {
+	interactions, err := LoadFixture(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Replay(dbtype, interactions)
+}
+
+// Unused 尚未被回放的交互
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res []Interaction
+	for i, it := range r.interactions {
+		if !r.used[i] {
+			res = append(res, it)
+		}
+	}
+	return res
+}
+
+// ExpectationsWereMet 所有记录的交互都已回放时返回 nil
+func (r *Replayer) ExpectationsWereMet() error {
+	if unused := r.Unused(); len(unused) > 0 {
+		return fmt.Errorf("cydbtest: %d recorded interactions were not replayed, first: %s", len(unused), unused[0].SQL)
+	}
+	return nil
+}
+
+func (r *Replayer) handle(_ context.Context, kind, query string, args []driver.NamedValue) (*Interaction, error) {
+	sql, values := NormalizeSQL(query), encodeArgs(args)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.interactions {
+		it := &r.interactions[i]
+		if r.used[i] || it.Kind != kind || NormalizeSQL(it.SQL) != sql || !slices.Equal(it.Args, values) {
+			continue
+		}
+		r.used[i] = true
+		return it, it.err()
+	}
+	return nil, fmt.Errorf("%w: %s %s %v", ErrUnexpectedSQL, kind, sql, values)
+}
+
+func (r *Replayer) begin(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return noopTx{}, nil
+}
+
+func (r *Replayer) checkNamedValue(nv *driver.NamedValue) error {
+	return checkNamedValue(nv)
+}
+
+func (r *Replayer) close() error {
+	return nil
+}
@@ -0,0 +1,611 @@
+package cydb
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fj1981/infrakit/pkg/cyutil"
+)
+
+// DataDiffKind 行差异类型
+type DataDiffKind string
+
+const (
+	// DataMissing 源库有、目标库没有
+	DataMissing DataDiffKind = "missing"
+	// DataExtra 目标库有、源库没有
+	DataExtra DataDiffKind = "extra"
+	// DataDifferent 主键相同但列值不同
+	DataDifferent DataDiffKind = "different"
+)
+
+// DataDiff 一行数据的差异，行数据的 key 为小写列名
+type DataDiff struct {
+	Kind DataDiffKind
+	PK   map[string]interface{}
+	// Columns 值不同的列，仅 DataDifferent 时有值
+	Columns []string
+	Source  map[string]interface{}
+	Target  map[string]interface{}
+	// FixSQL 使目标库与源库一致的语句，需开启 WithCompareFixSQL
+	FixSQL string
+}
+
+// DataChunk 按主键范围划分的一个数据块，范围为 (From, To]，nil 表示无边界
+type DataChunk struct {
+	From           []interface{}
+	To             []interface{}
+	SourceRows     int
+	TargetRows     int
+	SourceChecksum string
+	TargetChecksum string
+}
+
+func (c *DataChunk) Match() bool {
+	return c.SourceChecksum == c.TargetChecksum
+}
+
+// DataCompareReport 数据比对结果
+type DataCompareReport struct {
+	Table      string
+	PK         []string
+	Columns    []string
+	Chunks     int
+	SourceRows int64
+	TargetRows int64
+	// Mismatched 校验和不一致的数据块
+	Mismatched []*DataChunk
+	Diffs      []*DataDiff
+	// Truncated 差异数达到 MaxDiffs 后提前结束
+	Truncated bool
+}
+
+// Equal 两边数据完全一致
+func (r *DataCompareReport) Equal() bool {
+	return len(r.Diffs) == 0 && !r.Truncated
+}
+
+// FixSQL 汇总所有修复语句
+func (r *DataCompareReport) FixSQL() []string {
+	var ret []string
+	for _, d := range r.Diffs {
+		if d.FixSQL != "" {
+			ret = append(ret, d.FixSQL)
+		}
+	}
+	return ret
+}
+
+// DataCompareOptions 数据比对配置
+type DataCompareOptions struct {
+	// ChunkSize 每个数据块的源库行数
+	ChunkSize int
+	// Columns 参与比对的列，默认为两边共有的全部列
+	Columns []string
+	// TargetTable 目标库表名，默认与源库相同
+	TargetTable string
+	// MaxDiffs 差异数上限，0 表示不限制
+	MaxDiffs int
+	// FixSQL 为每个差异生成目标库的修复语句：缺失的行 REPLACE/UPSERT/MERGE，不同的行 UPDATE，多余的行 DELETE
+	FixSQL bool
+	// Normalize 将列值转换为可比较的字符串，用于抹平不同数据库的类型差异
+	Normalize func(column string, v interface{}) string
+}
+
+type DataCompareOption func(*DataCompareOptions)
+
+func WithCompareChunkSize(n int) DataCompareOption {
+	return func(o *DataCompareOptions) { o.ChunkSize = n }
+}
+
+func WithCompareColumns(cols ...string) DataCompareOption {
+	return func(o *DataCompareOptions) { o.Columns = cols }
+}
+
+func WithCompareTargetTable(table string) DataCompareOption {
+	return func(o *DataCompareOptions) { o.TargetTable = table }
+}
+
+func WithCompareMaxDiffs(n int) DataCompareOption {
+	return func(o *DataCompareOptions) { o.MaxDiffs = n }
+}
+
+func WithCompareFixSQL() DataCompareOption {
+	return func(o *DataCompareOptions) { o.FixSQL = true }
+}
+
+func WithCompareNormalizer(fn func(column string, v interface{}) string) DataCompareOption {
+	return func(o *DataCompareOptions) { o.Normalize = fn }
+}
+
+// CanonicalValue 默认的列值归一化：[]byte 转字符串，时间按 "2006-01-02 15:04:05.999999999" 格式化，布尔转 1/0
+func CanonicalValue(column string, v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "\x00"
+	case []byte:
+		return string(x)
+	case time.Time:
+		return x.Format("2006-01-02 15:04:05.999999999")
+	case bool:
+		if x {
+			return "1"
+		}
+		return "0"
+	case float32:
+		return strconv.FormatFloat(float64(x), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	default:
+		return cyutil.ToStr(x)
+	}
+}
+
+// CompareTableData 按主键分块比对两个库中同一张表的数据，可跨数据库类型
+// 每块先比较校验和，不一致时逐行比对并给出缺失、多余、不同的行
+// 校验和在客户端计算，两边的值经 Normalize 归一化后再比较，因此两边的数据会全部读取到本地，
+// 按 ChunkSize 分页读取，内存占用与块大小相关，但网络传输量与表大小相当
+func CompareTableData(source, target *DBCli, table string, opts ...DataCompareOption) (*DataCompareReport, error) {
+	o := &DataCompareOptions{ChunkSize: 1000, Normalize: CanonicalValue}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = 1000
+	}
+	if o.Normalize == nil {
+		o.Normalize = CanonicalValue
+	}
+	if o.TargetTable == "" {
+		o.TargetTable = table
+	}
+	c, err := newDataComparer(source, target, table, o)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.run(); err != nil {
+		return nil, err
+	}
+	return c.report, nil
+}
+
+type compareSide struct {
+	cli   *DBCli
+	dt    DatabaseTransformer
+	table string
+	cols  []*DBColumn
+	// names 小写列名到实际列名
+	names map[string]string
+}
+
+func newCompareSide(cli *DBCli, table string) (*compareSide, error) {
+	dt, ok := GetSqlTransformer(cli.dbtype)
+	if !ok {
+		return nil, errors.New("not support db type: " + cli.dbtype)
+	}
+	cols, err := cli.GetTableColumns(table)
+	if err != nil {
+		return nil, err
+	}
+	s := &compareSide{cli: cli, dt: dt, table: table, cols: cols, names: map[string]string{}}
+	for _, col := range cols {
+		s.names[strings.ToLower(col.Name)] = col.Name
+	}
+	return s, nil
+}
+
+// column 按该库方言转义的列名，用于直接在该库执行的修复语句，col 为小写列名
+func (s *compareSide) column(col string) string {
+	return s.dt.EscapeColumnName(s.names[col])
+}
+
+func (s *compareSide) tableName() string {
+	return s.dt.EscapeTableName(s.table)
+}
+
+type dataComparer struct {
+	src, dst *compareSide
+	pk       []string
+	cols     []string
+	opts     *DataCompareOptions
+	report   *DataCompareReport
+
+	// 跨块未匹配的行，数据库排序规则不同时同一主键可能落在不同块中，最后统一配对
+	missing map[string]map[string]interface{}
+	extra   map[string]map[string]interface{}
+	order   []string
+}
+
+func newDataComparer(source, target *DBCli, table string, o *DataCompareOptions) (*dataComparer, error) {
+	src, err := newCompareSide(source, table)
+	if err != nil {
+		return nil, fmt.Errorf("source table %s: %w", table, err)
+	}
+	dst, err := newCompareSide(target, o.TargetTable)
+	if err != nil {
+		return nil, fmt.Errorf("target table %s: %w", o.TargetTable, err)
+	}
+	pk, err := source.GetPK(table)
+	if err != nil {
+		return nil, err
+	}
+	if len(pk) == 0 {
+		return nil, fmt.Errorf("table %s has no primary key", table)
+	}
+	dstPK, err := target.GetPK(o.TargetTable)
+	if err != nil {
+		return nil, err
+	}
+	c := &dataComparer{src: src, dst: dst, opts: o, missing: map[string]map[string]interface{}{}, extra: map[string]map[string]interface{}{}}
+	for _, p := range pk {
+		c.pk = append(c.pk, strings.ToLower(p))
+	}
+	lowerDstPK := make([]string, 0, len(dstPK))
+	for _, p := range dstPK {
+		lowerDstPK = append(lowerDstPK, strings.ToLower(p))
+	}
+	if !slices.Equal(c.pk, lowerDstPK) {
+		return nil, fmt.Errorf("primary key mismatch: source %v, target %v", pk, dstPK)
+	}
+	if len(o.Columns) > 0 {
+		for _, col := range o.Columns {
+			c.cols = append(c.cols, strings.ToLower(col))
+		}
+	} else {
+		for _, col := range src.cols {
+			if _, ok := dst.names[strings.ToLower(col.Name)]; ok {
+				c.cols = append(c.cols, strings.ToLower(col.Name))
+			}
+		}
+	}
+	for _, p := range c.pk {
+		if !slices.Contains(c.cols, p) {
+			c.cols = append(c.cols, p)
+		}
+	}
+	for _, col := range c.cols {
+		if _, ok := src.names[col]; !ok {
+			return nil, fmt.Errorf("column %s not found in source table %s", col, table)
+		}
+		if _, ok := dst.names[col]; !ok {
+			return nil, fmt.Errorf("column %s not found in target table %s", col, o.TargetTable)
+		}
+	}
+	c.report = &DataCompareReport{Table: table, PK: c.pk, Columns: c.cols}
+	return c, nil
+}
+
+func (c *dataComparer) run() error {
+	var lower []interface{}
+	for {
+		srcRows, err := c.fetch(c.src, lower, nil, c.opts.ChunkSize)
+		if err != nil {
+			return err
+		}
+		if len(srcRows) == c.opts.ChunkSize {
+			upper := c.pkValues(srcRows[len(srcRows)-1])
+			dstRows, err := c.fetch(c.dst, lower, upper, 0)
+			if err != nil {
+				return err
+			}
+			if c.compareChunk(lower, upper, srcRows, dstRows) {
+				break
+			}
+			lower = upper
+			continue
+		}
+		// 源库最后一块，目标库剩余的行分页读取
+		dstRows, err := c.fetch(c.dst, lower, nil, c.opts.ChunkSize)
+		if err != nil {
+			return err
+		}
+		stop := c.compareChunk(lower, nil, srcRows, dstRows)
+		for !stop && len(dstRows) == c.opts.ChunkSize {
+			lower = c.pkValues(dstRows[len(dstRows)-1])
+			if dstRows, err = c.fetch(c.dst, lower, nil, c.opts.ChunkSize); err != nil {
+				return err
+			}
+			stop = c.compareChunk(lower, nil, nil, dstRows)
+		}
+		break
+	}
+	return c.finish()
+}
+
+// fetch 读取主键在 (lower, upper] 范围内的行，limit 为 0 时不限制
+func (c *dataComparer) fetch(side *compareSide, lower, upper []interface{}, limit int) ([]map[string]interface{}, error) {
+	// 查询按 MySQL 写法经 NQuery 转换为目标方言，标识符用反引号，由方言重新转义
+	cols := make([]string, 0, len(c.cols))
+	for _, col := range c.cols {
+		cols = append(cols, "`"+side.names[col]+"`")
+	}
+	pkCols := make([]string, 0, len(c.pk))
+	for _, p := range c.pk {
+		pkCols = append(pkCols, "`"+side.names[p]+"`")
+	}
+	data := map[string]interface{}{}
+	var where []string
+	if lower != nil {
+		where = append(where, keysetCond(pkCols, lower, ">", ">", "lo", data))
+	}
+	if upper != nil {
+		where = append(where, keysetCond(pkCols, upper, "<", "<=", "hi", data))
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "SELECT %s FROM `%s`", strings.Join(cols, ", "), strings.ReplaceAll(side.table, ".", "`.`"))
+	if len(where) > 0 {
+		sb.WriteString(" WHERE " + strings.Join(where, " AND "))
+	}
+	sb.WriteString(" ORDER BY " + strings.Join(pkCols, ", "))
+	if limit > 0 {
+		fmt.Fprintf(&sb, " LIMIT %d", limit)
+	}
+	rows, err := side.cli.NQuery(sb.String(), data)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		lowered := make(map[string]interface{}, len(row))
+		for k, v := range row {
+			lowered[strings.ToLower(k)] = v
+		}
+		ret = append(ret, lowered)
+	}
+	return ret, nil
+}
+
+// keysetCond 生成 (a, b) > (x, y) 的展开形式 a > x OR (a = x AND b > y)，兼容不支持行值比较的数据库
+// op 用于前缀列，lastOp 用于最后一列，如上界 (a, b) <= (x, y) 为 op "<"、lastOp "<="
+func keysetCond(cols []string, vals []interface{}, op, lastOp, prefix string, data map[string]interface{}) string {
+	var ors []string
+	for i := range cols {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, fmt.Sprintf("%s = :%s%d", cols[j], prefix, j))
+		}
+		cmp := op
+		if i == len(cols)-1 {
+			cmp = lastOp
+		}
+		ands = append(ands, fmt.Sprintf("%s %s :%s%d", cols[i], cmp, prefix, i))
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	for i, v := range vals {
+		data[fmt.Sprintf("%s%d", prefix, i)] = v
+	}
+	return "(" + strings.Join(ors, " OR ") + ")"
+}
+
+func (c *dataComparer) pkValues(row map[string]interface{}) []interface{} {
+	vals := make([]interface{}, 0, len(c.pk))
+	for _, p := range c.pk {
+		vals = append(vals, row[p])
+	}
+	return vals
+}
+
+func (c *dataComparer) pkKey(row map[string]interface{}) string {
+	parts := make([]string, 0, len(c.pk))
+	for _, p := range c.pk {
+		parts = append(parts, c.opts.Normalize(p, row[p]))
+	}
+	return strings.Join(parts, "\x1e")
+}
+
+// checksum 与行顺序无关，避免两边排序规则不同导致误报
+func (c *dataComparer) checksum(rows map[string]map[string]interface{}) string {
+	keys := make([]string, 0, len(rows))
+	for k := range rows {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha1.New()
+	for _, k := range keys {
+		h.Write([]byte(c.rowKey(rows[k])))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *dataComparer) rowKey(row map[string]interface{}) string {
+	parts := make([]string, 0, len(c.cols))
+	for _, col := range c.cols {
+		parts = append(parts, c.opts.Normalize(col, row[col]))
+	}
+	return strings.Join(parts, "\x1f")
+}
+
+func (c *dataComparer) index(rows []map[string]interface{}) map[string]map[string]interface{} {
+	m := make(map[string]map[string]interface{}, len(rows))
+	for _, row := range rows {
+		m[c.pkKey(row)] = row
+	}
+	return m
+}
+
+// compareChunk 比对一个数据块，差异数达到上限时返回 true
+func (c *dataComparer) compareChunk(lower, upper []interface{}, srcRows, dstRows []map[string]interface{}) bool {
+	src, dst := c.index(srcRows), c.index(dstRows)
+	chunk := &DataChunk{
+		From:           lower,
+		To:             upper,
+		SourceRows:     len(srcRows),
+		TargetRows:     len(dstRows),
+		SourceChecksum: c.checksum(src),
+		TargetChecksum: c.checksum(dst),
+	}
+	c.report.Chunks++
+	c.report.SourceRows += int64(len(srcRows))
+	c.report.TargetRows += int64(len(dstRows))
+	if chunk.Match() {
+		return false
+	}
+	c.report.Mismatched = append(c.report.Mismatched, chunk)
+	for _, row := range srcRows {
+		key := c.pkKey(row)
+		if other, ok := dst[key]; ok {
+			c.diffRow(row, other)
+		} else if other, ok := c.extra[key]; ok {
+			delete(c.extra, key)
+			c.diffRow(row, other)
+		} else {
+			c.missing[key] = row
+			c.order = append(c.order, key)
+		}
+		if c.full() {
+			return true
+		}
+	}
+	for _, row := range dstRows {
+		key := c.pkKey(row)
+		if _, ok := src[key]; ok {
+			continue
+		}
+		if other, ok := c.missing[key]; ok {
+			delete(c.missing, key)
+			c.diffRow(other, row)
+		} else {
+			c.extra[key] = row
+			c.order = append(c.order, key)
+		}
+		if c.full() {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *dataComparer) full() bool {
+	if c.opts.MaxDiffs <= 0 {
+		return false
+	}
+	if len(c.report.Diffs)+len(c.missing)+len(c.extra) >= c.opts.MaxDiffs {
+		c.report.Truncated = true
+		return true
+	}
+	return false
+}
+
+func (c *dataComparer) diffRow(src, dst map[string]interface{}) {
+	var cols []string
+	for _, col := range c.cols {
+		if c.opts.Normalize(col, src[col]) != c.opts.Normalize(col, dst[col]) {
+			cols = append(cols, col)
+		}
+	}
+	if len(cols) == 0 {
+		return
+	}
+	c.report.Diffs = append(c.report.Diffs, &DataDiff{Kind: DataDifferent, PK: c.pkMap(src), Columns: cols, Source: src, Target: dst})
+}
+
+func (c *dataComparer) pkMap(row map[string]interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(c.pk))
+	for _, p := range c.pk {
+		m[p] = row[p]
+	}
+	return m
+}
+
+func (c *dataComparer) finish() error {
+	for _, key := range c.order {
+		if row, ok := c.missing[key]; ok {
+			c.report.Diffs = append(c.report.Diffs, &DataDiff{Kind: DataMissing, PK: c.pkMap(row), Source: row})
+			delete(c.missing, key)
+		} else if row, ok := c.extra[key]; ok {
+			c.report.Diffs = append(c.report.Diffs, &DataDiff{Kind: DataExtra, PK: c.pkMap(row), Target: row})
+			delete(c.extra, key)
+		}
+	}
+	if c.opts.MaxDiffs > 0 && len(c.report.Diffs) > c.opts.MaxDiffs {
+		c.report.Diffs = c.report.Diffs[:c.opts.MaxDiffs]
+	}
+	if !c.opts.FixSQL {
+		return nil
+	}
+	for _, d := range c.report.Diffs {
+		sql, err := c.fixSQL(d)
+		if err != nil {
+			return err
+		}
+		d.FixSQL = sql
+	}
+	return nil
+}
+
+// fixSQL 缺失的行使用目标库方言的 GetReplaceSql 写入源库的值，不同的行只 UPDATE 值不同的列，多余的行删除
+// 不同的行不使用 REPLACE，避免 WithCompareColumns 限定列时未参与比对的列被重置
+func (c *dataComparer) fixSQL(d *DataDiff) (string, error) {
+	switch d.Kind {
+	case DataExtra:
+		where, err := c.pkWhere(d.PK)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("DELETE FROM %s WHERE %s;", c.dst.tableName(), where), nil
+	case DataDifferent:
+		sets := make([]string, 0, len(d.Columns))
+		for _, col := range d.Columns {
+			_, dc := getDBColumn(c.dst.cols, col)
+			v, err := c.literal(d.Source[col], dc.DBFieldType)
+			if err != nil {
+				return "", err
+			}
+			sets = append(sets, fmt.Sprintf("%s = %s", c.dst.column(col), v))
+		}
+		where, err := c.pkWhere(d.PK)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("UPDATE %s SET %s WHERE %s;", c.dst.tableName(), strings.Join(sets, ", "), where), nil
+	}
+	row := make(map[string]interface{}, len(c.cols))
+	for _, col := range c.cols {
+		row[c.dst.names[col]] = d.Source[col]
+	}
+	rd, err := newRowData(row, c.dst.cols)
+	if err != nil {
+		return "", err
+	}
+	return rd.GetReplaceSql(c.dst.cli, c.dst.table)
+}
+
+func (c *dataComparer) pkWhere(pk map[string]interface{}) (string, error) {
+	conds := make([]string, 0, len(c.pk))
+	for _, p := range c.pk {
+		if pk[p] == nil {
+			conds = append(conds, c.dst.column(p)+" IS NULL")
+			continue
+		}
+		_, col := getDBColumn(c.dst.cols, p)
+		v, err := c.literal(pk[p], col.DBFieldType)
+		if err != nil {
+			return "", err
+		}
+		conds = append(conds, fmt.Sprintf("%s = %s", c.dst.column(p), v))
+	}
+	return strings.Join(conds, " AND "), nil
+}
+
+// literal MySQL 沿用 FormatValue 的反斜杠转义，其他数据库字符串按标准 SQL 将单引号写两次
+func (c *dataComparer) literal(v interface{}, tp DBFieldType) (string, error) {
+	if tp == DBFieldTypeString && v != nil && c.dst.cli.dbtype != "mysql" {
+		return QuoteLiteral(cyutil.ToStr(v)), nil
+	}
+	s, err := FormatValue(v, tp)
+	if err != nil {
+		return "", err
+	}
+	if s == "" {
+		return "", errors.New("unsupported value: " + cyutil.ToStr(v))
+	}
+	return s, nil
+}
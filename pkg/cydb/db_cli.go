@@ -2,6 +2,7 @@ package cydb
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -10,13 +11,13 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/duke-git/lancet/v2/maputil"
 	"github.com/fj1981/infrakit/pkg/cylog"
 	"github.com/fj1981/infrakit/pkg/cyutil"
 	"github.com/jmoiron/sqlx"
-	"github.com/patrickmn/go-cache"
 )
 
 type IDBOperWrapper interface {
@@ -94,12 +95,86 @@ func DBLog() *cylog.Logger {
 }
 
 type DBCli struct {
+	// cli 事务或自定义的执行对象，连接池使用 pool
 	cli      IDBOperWrapper
+	pool     *dbPool
 	dbtype   string
 	key      string
 	database string
 	un       string
 	pw       string
+
+	qc          *queryCacheConf
+	noCacheRead bool
+	txTables    map[string]struct{}
+
+	ctx      context.Context
+	audit    *auditConf
+	unscoped bool
+
+	// conn 建立连接时的配置，用于健康检查失败后重连
+	conn  *DBConnection
+	stats *cliStats
+	guard *guardConf
+	// tenant 多租户隔离策略，为 nil 时不隔离
+	tenant *tenantConf
+	// translate 为 true 时 Query 也做 MySQL 方言转换
+	translate bool
+	// sc 表结构缓存，同一连接的副本与事务共享
+	sc *schemaCache
+	// vm 查询结果转换，为 nil 时使用 NormalData
+	vm ValueMapper
+	// schema 配置的默认 schema，为空时使用 database
+	schema string
+	// seqs 表名（大写）到主键序列的映射，插入时用于填充主键
+	seqs map[string]string
+}
+
+// dbPool 连接池句柄，DBCli 的副本共享同一个句柄
+// 健康检查重连与凭据轮换时原地替换其中的 *sqlx.DB，持有 DBCli 的调用方无需重新获取
+type dbPool struct {
+	db atomic.Pointer[sqlx.DB]
+	// pwSum 当前连接池所用明文密码的摘要，凭据刷新时据此判断是否变化，由 DBMgr.reconnectMu 保护
+	pwSum [sha256.Size]byte
+	// mu 保护 closed，调用方 Close 之后不再换入新的连接池
+	mu     sync.Mutex
+	closed bool
+}
+
+var errPoolClosed = errors.New("db connection is closed")
+
+func newDBPool(db *sqlx.DB) *dbPool {
+	p := &dbPool{}
+	p.db.Store(db)
+	return p
+}
+
+// swap 替换连接池并关闭旧连接池，旧连接池上已开始的查询与事务执行完后才会断开
+// 句柄已被 Close 时关闭 db 并返回错误
+func (p *dbPool) swap(db *sqlx.DB) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		_ = db.Close()
+		return errPoolClosed
+	}
+	if old := p.db.Swap(db); old != nil && old != db {
+		_ = old.Close()
+	}
+	return nil
+}
+
+func (p *dbPool) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return p.db.Load().Close()
+}
+
+func (p *dbPool) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
 }
 
 var gTxCount = &txCount{
@@ -110,30 +185,53 @@ type PARAMS = map[string]interface{}
 
 // NewDBCli creates a new DBCli instance. This is useful for testing purposes.
 func NewDBCli(cli IDBOperWrapper, dbtype, key, database, un, pw string) *DBCli {
-	return &DBCli{
+	d := &DBCli{
 		cli:      cli,
 		dbtype:   dbtype,
 		key:      key,
 		database: database,
 		un:       un,
 		pw:       pw,
+		sc:       newSchemaCache(key, 0),
+		audit:    newAuditConf(),
 	}
+	if db, ok := cli.(*sqlx.DB); ok {
+		d.cli, d.pool = nil, newDBPool(db)
+	}
+	return d
+}
+
+// oper 返回执行语句的对象，连接池可能被原地替换，每次执行时重新读取
+func (d *DBCli) oper() IDBOperWrapper {
+	if d.pool != nil {
+		return d.pool.db.Load()
+	}
+	return d.cli
 }
 
 func (d *DBCli) Database() string {
 	return d.database
 }
 
+// Schema 未指定库名时对象所属的 schema，配置了 DBConnection.Schema 时为该值，否则为 Database()
+func (d *DBCli) Schema() string {
+	if d.schema != "" {
+		return d.schema
+	}
+	return d.database
+}
+
 func (d *DBCli) DBType() string {
 	return d.dbtype
 }
 
+// PW 返回配置中的 Pw 原值，可能为 ENC(...) 加密值，不包含 PwRef 解析出的明文
 func (d *DBCli) PW() string {
 	return d.pw
 }
 
 func (d *DBCli) GetDB() *sqlx.DB {
-	if db, ok := d.cli.(*sqlx.DB); ok {
+	if db, ok := d.oper().(*sqlx.DB); ok {
 		return db
 	}
 	return nil
@@ -143,8 +241,12 @@ func (d *DBCli) Key() string {
 	return d.key
 }
 
+// Close 关闭连接池，关闭后健康检查与凭据刷新不会再重新连接
 func (d *DBCli) Close() error {
-	if db, ok := d.cli.(*sqlx.DB); ok {
+	if d.pool != nil {
+		return d.pool.close()
+	}
+	if db, ok := d.oper().(*sqlx.DB); ok {
 		return db.Close()
 	}
 	return nil
@@ -156,7 +258,7 @@ func (d *DBCli) BeginX() (r *DBCli, err error) {
 			gTxCount.Inc(r.key)
 		}
 	}()
-	db, ok := d.cli.(*sqlx.DB)
+	db, ok := d.oper().(*sqlx.DB)
 	if !ok {
 		if _, ok := d.cli.(*sqlx.Tx); ok {
 			return d, nil
@@ -169,12 +271,24 @@ func (d *DBCli) BeginX() (r *DBCli, err error) {
 	}
 	key := d.key + cyutil.NanoID() + "_T"
 	return &DBCli{
-		cli:      tx,
-		dbtype:   d.dbtype,
-		database: d.database,
-		un:       d.un,
-		pw:       d.pw,
-		key:      key,
+		cli:       tx,
+		dbtype:    d.dbtype,
+		database:  d.database,
+		un:        d.un,
+		pw:        d.pw,
+		key:       key,
+		qc:        d.qc,
+		ctx:       d.ctx,
+		audit:     d.audit,
+		unscoped:  d.unscoped,
+		stats:     d.stats,
+		guard:     d.guard,
+		tenant:    d.tenant,
+		translate: d.translate,
+		sc:        d.sc,
+		vm:        d.vm,
+		schema:    d.schema,
+		seqs:      d.seqs,
 	}, nil
 }
 
@@ -190,7 +304,10 @@ func (d *DBCli) Commit() error {
 	if tx, ok := d.cli.(*sqlx.Tx); ok {
 		n := gTxCount.Dec(d.key)
 		if n == 0 {
-			return tx.Commit()
+			if err := tx.Commit(); err != nil {
+				return err
+			}
+			d.flushTxTables()
 		}
 	}
 	return nil
@@ -237,12 +354,28 @@ func NormalData2(d interface{}) interface{} {
 	}
 }
 
-func (cli *DBCli) scanSQLRow(rows *sqlx.Rows) (map[string]interface{}, error) {
+// scanSQLRow 读取一行，cols 为 resultColumns 的结果，为 nil 时按 NormalData 转换
+func (cli *DBCli) scanSQLRow(rows *sqlx.Rows, cols []*ResultColumn) (map[string]interface{}, error) {
 	row := make(map[string]interface{})
 	if err := rows.MapScan(row); err != nil {
 		return nil, err
 	}
 	ret := make(map[string]interface{})
+	if cols != nil {
+		for _, col := range cols {
+			v := row[col.Name]
+			if v == nil {
+				ret[col.Name] = nil
+				continue
+			}
+			mv, err := cli.vm.MapValue(col, v)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", col.Name, err)
+			}
+			ret[col.Name] = mv
+		}
+		return ret, nil
+	}
 	for k, v := range row {
 		if nil == v {
 			ret[k] = nil
@@ -265,16 +398,16 @@ func (d *DBCli) IsTableExist(tableName string) (bool, error) {
 }
 
 func (d *DBCli) GetTableColumns(tableName string) ([]*DBColumn, error) {
-	cacheKey := fmt.Sprintf("%s:%s:%s", d.dbtype, d.database, tableName)
-	if cachedColumns, found := tableColumnsCache.Get(cacheKey); found {
-		return cachedColumns.([]*DBColumn), nil
+	cacheKey := d.schemaCacheKey(tableName)
+	if cachedColumns, found := d.sc.get(cacheKey); found {
+		return cachedColumns, nil
 	}
 	if sqlFunc, ok := GetSqlDialect(d.dbtype); ok {
-		columns, err := sqlFunc.GetTableColumns(d, d.database, tableName)
+		columns, err := sqlFunc.GetTableColumns(d, d.Schema(), tableName)
 		if err != nil {
 			return nil, err
 		}
-		tableColumnsCache.Set(cacheKey, columns, 2*time.Minute)
+		d.sc.set(cacheKey, columns)
 		return columns, nil
 	}
 	return nil, errors.New("not support db type: " + d.dbtype)
@@ -304,11 +437,23 @@ func getDBColumn(cols []*DBColumn, name string) (int, *DBColumn) {
 }
 
 func (d *DBCli) procssTravel(row map[string]any, dcs []*DBColumn, fn func(*DBCli, *RowData) error) error {
+	fds, err := newRowData(row, dcs)
+	if err != nil {
+		return err
+	}
+	if err := fn(d, fds); err != nil {
+		return err
+	}
+	return nil
+}
+
+// newRowData 按表结构将一行数据转换为 RowData，字段按列顺序排列
+func newRowData(row map[string]any, dcs []*DBColumn) (*RowData, error) {
 	fds := RowData{Data: make([]*FieldData, 0, len(row))}
 	for k, v := range row {
 		index, col := getDBColumn(dcs, k)
 		if col == nil {
-			return errors.New("column not found: " + k)
+			return nil, errors.New("column not found: " + k)
 		}
 		fds.Data = append(fds.Data, &FieldData{
 			Name:        col.Name,
@@ -324,10 +469,7 @@ func (d *DBCli) procssTravel(row map[string]any, dcs []*DBColumn, fn func(*DBCli
 	slices.SortFunc(fds.Data, func(a, b *FieldData) int {
 		return a.Index - b.Index
 	})
-	if err := fn(d, &fds); err != nil {
-		return err
-	}
-	return nil
+	return &fds, nil
 }
 
 func (d *DBCli) TravelQuery(tableName string, selectSQL string, fn func(*DBCli, *RowData) error) error {
@@ -338,7 +480,7 @@ func (d *DBCli) TravelQuery(tableName string, selectSQL string, fn func(*DBCli,
 	if err != nil {
 		return fmt.Errorf("getting table columns failed: %v", err)
 	}
-	rows, err := d.cli.Queryx(selectSQL)
+	rows, err := d.oper().Queryx(selectSQL)
 	if err != nil {
 		return fmt.Errorf("query execution failed: %s | %s", err.Error(), selectSQL)
 	}
@@ -353,7 +495,7 @@ func (d *DBCli) TravelQuery(tableName string, selectSQL string, fn func(*DBCli,
 			return err
 		}
 	}
-	return nil
+	return rows.Err()
 }
 
 func (d *DBCli) TravelData(tableName string, data []map[string]interface{}, fn func(*DBCli, *RowData) error) error {
@@ -407,7 +549,8 @@ func (d *DBCli) preProcess(sql string) (string, error) {
 		return "", err
 	}
 	if sqlFunc, ok := GetSqlTransformer(d.dbtype); ok {
-		ret, err := builder.Build(sqlFunc)
+		// 原生 SQL 不做租户隔离，由调用方自行添加条件，见 DBCli.SetTenantPolicy
+		ret, err := builder.Tenant(AllTenants).Build(sqlFunc)
 		if err != nil {
 			return "", err
 		}
@@ -418,22 +561,34 @@ func (d *DBCli) preProcess(sql string) (string, error) {
 
 func (d *DBCli) nQuery(sql string, data interface{}) ([]map[string]interface{}, error) {
 	DBLog().Debug("nQuery sql", "sql", sql, "data", data)
-	rows, err := d.cli.NamedQuery(sql, data)
+	start := time.Now()
+	rows, err := d.namedQuery(sql, data)
+	d.observe(start, err)
 	if err != nil {
-		return nil, fmt.Errorf("failed to nQuery: %s | %s", sql, err)
+		return nil, fmt.Errorf("failed to nQuery: %s | %w", sql, err)
 	}
 	r := []map[string]interface{}{}
 	defer rows.Close()
+	cols, err := d.resultColumns(rows)
+	if err != nil {
+		return nil, err
+	}
 	for rows.Next() {
-		l, err := d.scanSQLRow(rows)
+		l, err := d.scanSQLRow(rows, cols)
 		if err != nil {
-			break
+			return nil, fmt.Errorf("[nQuery]: %s | => %w", sql, err)
 		}
 		r = append(r, l)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("[nQuery]: %s | => %w", sql, err)
+	}
 	return r, nil
 }
 func (d *DBCli) NQuery(sql string, data interface{}) ([]map[string]interface{}, error) {
+	if err := d.rawSQL(sql); err != nil {
+		return nil, err
+	}
 	sql, err := d.preProcess(sql)
 	if err != nil {
 		return nil, err
@@ -450,30 +605,57 @@ func InternalNQuery(cli DatabaseClient, sql string, data interface{}) ([]map[str
 
 func (d *DBCli) query(sql string, arguments ...interface{}) ([]map[string]interface{}, error) {
 	DBLog().Debug("query sql", "sql", sql, "arguments", arguments)
-	rows, err := d.cli.Queryx(sql, arguments...)
+	start := time.Now()
+	rows, err := d.oper().Queryx(sql, arguments...)
+	d.observe(start, err)
 	if err != nil {
-		return nil, fmt.Errorf("[query]: %s | => %s", sql, err)
+		return nil, fmt.Errorf("[query]: %s | => %w", sql, err)
 	}
 	r := []map[string]interface{}{}
 	defer rows.Close()
+	cols, err := d.resultColumns(rows)
+	if err != nil {
+		return nil, err
+	}
 	for rows.Next() {
-		l, err := d.scanSQLRow(rows)
+		l, err := d.scanSQLRow(rows, cols)
 		if err != nil {
-			break
+			return nil, fmt.Errorf("[query]: %s | => %w", sql, err)
 		}
 		r = append(r, l)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("[query]: %s | => %w", sql, err)
+	}
 	return r, nil
 }
 
 func (d *DBCli) Query(sql string, arguments ...interface{}) ([]map[string]interface{}, error) {
-	/* sql, err := d.preProcess(sql)
-	if err != nil {
+	if err := d.rawSQL(sql); err != nil {
 		return nil, err
-	}*/
+	}
+	if d.translate && d.dbtype != "mysql" {
+		named, data, err := positionalToNamed(sql, arguments)
+		if err != nil {
+			return nil, err
+		}
+		named, err = d.preProcess(named)
+		if err != nil {
+			return nil, err
+		}
+		return d.nQuery(named, data)
+	}
 	return d.query(sql, arguments...)
 }
 
+// Translated 返回 Query 也经过 preProcess 的 DBCli 副本，MySQL 写法的原生查询可在其他库执行
+// 只转换调用方传入的 SQL，方言自身的目录查询通过 InternalQuery 执行，不做转换
+func (d *DBCli) Translated() *DBCli {
+	cp := *d
+	cp.translate = true
+	return &cp
+}
+
 func InternalQuery(cli DatabaseClient, sql string, arguments ...interface{}) ([]map[string]interface{}, error) {
 	if cli2, ok := cli.(*DBCli); ok {
 		return cli2.query(sql, arguments...)
@@ -483,19 +665,34 @@ func InternalQuery(cli DatabaseClient, sql string, arguments ...interface{}) ([]
 
 func (d *DBCli) queryOne(sql string, args ...interface{}) (map[string]interface{}, error) {
 	DBLog().Debug("queryOne sql", "sql", sql, "args", args)
-	rows, err := d.cli.Queryx(sql, args...)
+	start := time.Now()
+	rows, err := d.oper().Queryx(sql, args...)
+	d.observe(start, err)
 	if err != nil {
-		return nil, fmt.Errorf("[queryOne]: %s | => %s", sql, err)
+		return nil, fmt.Errorf("[queryOne]: %s | => %w", sql, err)
 	}
 	defer rows.Close()
+	cols, err := d.resultColumns(rows)
+	if err != nil {
+		return nil, err
+	}
 	if rows.Next() {
-		l, err := d.scanSQLRow(rows)
-		return l, err
+		l, err := d.scanSQLRow(rows, cols)
+		if err != nil {
+			return nil, fmt.Errorf("[queryOne]: %s | => %w", sql, err)
+		}
+		return l, nil
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("[queryOne]: %s | => %w", sql, err)
 	}
 	return nil, nil
 }
 
 func (d *DBCli) QueryOne(sql string, args ...interface{}) (map[string]interface{}, error) {
+	if err := d.rawSQL(sql); err != nil {
+		return nil, err
+	}
 	sql, err := d.preProcess(sql)
 	if err != nil {
 		return nil, err
@@ -505,14 +702,26 @@ func (d *DBCli) QueryOne(sql string, args ...interface{}) (map[string]interface{
 
 func (d *DBCli) nQueryOne(sql string, data interface{}) (map[string]interface{}, error) {
 	DBLog().Debug("query sql", "sql", sql, "data", data)
-	rows, err := d.cli.NamedQuery(sql, data)
+	start := time.Now()
+	rows, err := d.namedQuery(sql, data)
+	d.observe(start, err)
 	if err != nil {
-		return nil, fmt.Errorf("[nQueryOne]: %s | => %s", sql, err)
+		return nil, fmt.Errorf("[nQueryOne]: %s | => %w", sql, err)
 	}
 	defer rows.Close()
+	cols, err := d.resultColumns(rows)
+	if err != nil {
+		return nil, err
+	}
 	if rows.Next() {
-		l, err := d.scanSQLRow(rows)
-		return l, err
+		l, err := d.scanSQLRow(rows, cols)
+		if err != nil {
+			return nil, fmt.Errorf("[nQueryOne]: %s | => %w", sql, err)
+		}
+		return l, nil
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("[nQueryOne]: %s | => %w", sql, err)
 	}
 	return nil, nil
 }
@@ -525,6 +734,9 @@ func InternalNQueryOne(cli DatabaseClient, sql string, data interface{}) (map[st
 }
 
 func (d *DBCli) NQueryOne(sql string, data interface{}) (map[string]interface{}, error) {
+	if err := d.rawSQL(sql); err != nil {
+		return nil, err
+	}
 	sql, err := d.preProcess(sql)
 	if err != nil {
 		return nil, err
@@ -541,10 +753,13 @@ func InternalQueryOne(cli DatabaseClient, sql string, arguments ...interface{})
 
 func (d *DBCli) nExcute(sql string, data interface{}) (int64, error) {
 	DBLog().Debug("excute sql", "sql", sql, "data", data)
-	r, err := d.cli.NamedExec(sql, data)
+	start := time.Now()
+	r, err := d.namedExec(sql, data)
+	d.observe(start, err)
 	if err != nil {
-		return 0, fmt.Errorf("[nExcute]: %s | => %s | %v", sql, err, data)
+		return 0, fmt.Errorf("[nExcute]: %s | => %w | %v", sql, err, data)
 	}
+	d.afterExec(sql)
 	var rowsAffected int64
 	if r != nil {
 		rowsAffected, err = r.RowsAffected()
@@ -553,6 +768,9 @@ func (d *DBCli) nExcute(sql string, data interface{}) (int64, error) {
 }
 
 func (d *DBCli) NExcute(sql string, data interface{}) (int64, error) {
+	if err := d.rawSQL(sql); err != nil {
+		return 0, err
+	}
 	sql, err := d.preProcess(sql)
 	if err != nil {
 		return 0, err
@@ -568,10 +786,13 @@ func InternalExcute(cli DatabaseClient, sql string, arguments ...interface{}) (i
 
 func (d *DBCli) excute(sql string, arguments ...interface{}) (int64, error) {
 	DBLog().Debug("excute sql", "sql", sql, "arguments", arguments)
-	r, err := d.cli.Exec(sql, arguments...)
+	start := time.Now()
+	r, err := d.oper().Exec(sql, arguments...)
+	d.observe(start, err)
 	if err != nil {
-		return 0, fmt.Errorf("[excute]: %s | => %s | %v", sql, err, arguments)
+		return 0, fmt.Errorf("[excute]: %s | => %w | %v", sql, err, arguments)
 	}
+	d.afterExec(sql)
 	var rowsAffected int64
 	if r != nil {
 		rowsAffected, err = r.RowsAffected()
@@ -580,6 +801,9 @@ func (d *DBCli) excute(sql string, arguments ...interface{}) (int64, error) {
 }
 
 func (d *DBCli) Excute(sql string, arguments ...interface{}) (int64, error) {
+	if err := d.rawSQL(sql); err != nil {
+		return 0, err
+	}
 	sql, err := d.preProcess(sql)
 	if err != nil {
 		return 0, err
@@ -607,9 +831,14 @@ func (d *DBCli) filterFields(tableName string, fields []string) []string {
 
 func (d *DBCli) Insert(tableName string, data map[string]interface{}, cc ...FuncWithBuilder) (int64, error) {
 	if sqlFunc, ok := GetSqlTransformer(d.dbtype); ok {
+		data = d.auditData(tableName, data, true)
+		data, err := d.fillSequenceKey(tableName, data)
+		if err != nil {
+			return 0, err
+		}
 		fields := maputil.Keys(data)
 		fields = d.filterFields(tableName, fields)
-		builder := Builder().Database(d.Database()).Table(tableName).Fields(fields)
+		builder := d.builder().Table(tableName).Fields(fields)
 		for _, c := range cc {
 			builder = c(builder)
 		}
@@ -617,7 +846,11 @@ func (d *DBCli) Insert(tableName string, data map[string]interface{}, cc ...Func
 		if err != nil {
 			return 0, err
 		}
-		return d.nExcute(sqlContent.SQL, data)
+		affected, err := d.nExcute(sqlContent.SQL, data)
+		if err == nil {
+			d.invalidateBuilder(builder)
+		}
+		return affected, err
 	}
 	return 0, errors.New("not support db type: " + d.dbtype)
 }
@@ -629,10 +862,13 @@ func (d *DBCli) BatchInsert(tableName string, data []map[string]interface{}, cc
 
 		err := d.WithTransaction(func(tx *DBCli) error {
 			for _, item := range data {
-
+				item, err := tx.fillSequenceKey(tableName, tx.auditData(tableName, item, true))
+				if err != nil {
+					return err
+				}
 				fields := maputil.Keys(item)
 				fields = tx.filterFields(tableName, fields)
-				builder := Builder().Database(d.Database()).Table(tableName).Fields(fields)
+				builder := d.builder().Table(tableName).Fields(fields)
 				for _, c := range cc {
 					builder = c(builder)
 				}
@@ -653,6 +889,7 @@ func (d *DBCli) BatchInsert(tableName string, data []map[string]interface{}, cc
 		if err != nil {
 			return 0, err
 		}
+		d.invalidateBuilder(Builder().Table(tableName))
 		return totalAffected, nil
 	}
 	return 0, errors.New("not support db type: " + d.dbtype)
@@ -661,17 +898,29 @@ func (d *DBCli) BatchInsert(tableName string, data []map[string]interface{}, cc
 func (d *DBCli) Update(tableName string, data map[string]interface{}, cc ...FuncWithBuilder) (int64, error) {
 	if sqlFunc, ok := GetSqlTransformer(d.dbtype); ok {
 
+		data = d.auditData(tableName, data, false)
 		fields := maputil.Keys(data)
 		fields = d.filterFields(tableName, fields)
-		builder := Builder().Database(d.Database()).Table(tableName).Fields(fields)
+		builder := d.builder().Table(tableName).Fields(fields)
 		for _, c := range cc {
 			builder = c(builder)
 		}
+		if err := d.guardBuilder(builder, "UPDATE", tableName); err != nil {
+			return 0, err
+		}
+		if err := checkVersion(builder, tableName, data); err != nil {
+			return 0, err
+		}
 		sqlContent, err := builder.Type(SQLOperationUpdate).Build(sqlFunc)
 		if err != nil {
 			return 0, err
 		}
-		return d.nExcute(sqlContent.SQL, data)
+		affected, err := d.nExcute(sqlContent.SQL, data)
+		if err == nil {
+			d.invalidateBuilder(builder)
+			err = staleError(builder, tableName, data, affected)
+		}
+		return affected, err
 	}
 	return 0, errors.New("not support db type: " + d.dbtype)
 }
@@ -684,12 +933,19 @@ func (d *DBCli) BatchUpdate(tableName string, data []map[string]interface{}, cc
 
 		err := d.WithTransaction(func(tx *DBCli) error {
 			for _, item := range data {
+				item = tx.auditData(tableName, item, false)
 				fields := maputil.Keys(item)
 				fields = tx.filterFields(tableName, fields)
-				builder := Builder().Database(tx.Database()).Table(tableName).Fields(fields)
+				builder := tx.builder().Table(tableName).Fields(fields)
 				for _, c := range cc {
 					builder = c(builder)
 				}
+				if err := tx.guardBuilder(builder, "UPDATE", tableName); err != nil {
+					return err
+				}
+				if err := checkVersion(builder, tableName, item); err != nil {
+					return err
+				}
 				sqlContent, err := builder.Type(SQLOperationUpdate).Build(sqlFunc)
 				if err != nil {
 					return err
@@ -699,6 +955,9 @@ func (d *DBCli) BatchUpdate(tableName string, data []map[string]interface{}, cc
 				if err != nil {
 					return err
 				}
+				if err := staleError(builder, tableName, item, affected); err != nil {
+					return err
+				}
 				totalAffected += affected
 			}
 			return nil
@@ -707,16 +966,26 @@ func (d *DBCli) BatchUpdate(tableName string, data []map[string]interface{}, cc
 		if err != nil {
 			return 0, err
 		}
+		d.invalidateBuilder(Builder().Table(tableName))
 		return totalAffected, nil
 	}
 	return 0, errors.New("not support db type: " + d.dbtype)
 }
 
+// Upsert 插入一行，主键冲突时更新已有行，审计策略的创建列不参与更新
 func (d *DBCli) Upsert(tableName string, data map[string]interface{}, cc ...FuncWithBuilder) (int64, error) {
+	pk, err := d.GetPK(tableName)
+	if err != nil {
+		return 0, err
+	}
 	if sqlFunc, ok := GetSqlTransformer(d.dbtype); ok {
+		data = d.auditData(tableName, data, true)
+		if data, err = d.fillSequenceKey(tableName, data); err != nil {
+			return 0, err
+		}
 		fields := maputil.Keys(data)
 		fields = d.filterFields(tableName, fields)
-		builder := Builder().Database(d.Database()).Table(tableName).Fields(fields)
+		builder := d.conflictUpdate(d.builder().Table(tableName).Fields(fields).PrimaryKeys(pk...), tableName, fields)
 		for _, c := range cc {
 			builder = c(builder)
 		}
@@ -724,11 +993,17 @@ func (d *DBCli) Upsert(tableName string, data map[string]interface{}, cc ...Func
 		if err != nil {
 			return 0, err
 		}
-		return d.nExcute(sqlContent.SQL, data)
+		affected, err := d.nExcute(sqlContent.SQL, data)
+		if err == nil {
+			d.invalidateBuilder(builder)
+		}
+		return affected, err
 	}
 	return 0, errors.New("not support db type: " + d.dbtype)
 }
 
+// Replace 按主键替换一行；MySQL、SQLite 为 REPLACE INTO，会删除旧行后重新插入，
+// 其他库命中已有行时与 Upsert 相同，不更新审计策略的创建列
 func (d *DBCli) Replace(tableName string, data map[string]interface{}, cc ...FuncWithBuilder) (int64, error) {
 	pk, err := d.GetPK(tableName)
 	if err != nil {
@@ -738,9 +1013,13 @@ func (d *DBCli) Replace(tableName string, data map[string]interface{}, cc ...Fun
 		return 0, errors.New("table " + tableName + " has no primary key")
 	}
 	if sqlFunc, ok := GetSqlTransformer(d.dbtype); ok {
+		data = d.auditData(tableName, data, true)
+		if data, err = d.fillSequenceKey(tableName, data); err != nil {
+			return 0, err
+		}
 		fields := maputil.Keys(data)
 		fields = d.filterFields(tableName, fields)
-		builder := Builder().Database(d.Database()).Table(tableName).Fields(fields).PrimaryKeys(pk...)
+		builder := d.conflictUpdate(d.builder().Table(tableName).Fields(fields).PrimaryKeys(pk...), tableName, fields)
 		for _, c := range cc {
 			builder = c(builder)
 		}
@@ -748,7 +1027,11 @@ func (d *DBCli) Replace(tableName string, data map[string]interface{}, cc ...Fun
 		if err != nil {
 			return 0, err
 		}
-		return d.nExcute(sqlContent.SQL, data)
+		affected, err := d.nExcute(sqlContent.SQL, data)
+		if err == nil {
+			d.invalidateBuilder(builder)
+		}
+		return affected, err
 	}
 	return 0, errors.New("not support db type: " + d.dbtype)
 }
@@ -765,9 +1048,13 @@ func (d *DBCli) BatchReplace(tableName string, data []map[string]interface{}, cc
 		var totalAffected int64
 		err := d.WithTransaction(func(tx *DBCli) error {
 			for _, item := range data {
+				item, err := tx.fillSequenceKey(tableName, tx.auditData(tableName, item, true))
+				if err != nil {
+					return err
+				}
 				fields := maputil.Keys(item)
 				fields = tx.filterFields(tableName, fields)
-				builder := Builder().Database(tx.Database()).Table(tableName).Fields(fields).PrimaryKeys(pk...)
+				builder := tx.conflictUpdate(tx.builder().Table(tableName).Fields(fields).PrimaryKeys(pk...), tableName, fields)
 				for _, c := range cc {
 					builder = c(builder)
 				}
@@ -788,6 +1075,7 @@ func (d *DBCli) BatchReplace(tableName string, data []map[string]interface{}, cc
 		if err != nil {
 			return 0, err
 		}
+		d.invalidateBuilder(Builder().Table(tableName))
 		return totalAffected, nil
 	}
 	return 0, errors.New("not support db type: " + d.dbtype)
@@ -795,15 +1083,18 @@ func (d *DBCli) BatchReplace(tableName string, data []map[string]interface{}, cc
 
 func (d *DBCli) List(tableName any, data map[string]interface{}, cc ...FuncWithBuilder) ([]map[string]interface{}, error) {
 	if sqlFunc, ok := GetSqlTransformer(d.dbtype); ok {
-		builder := Builder().Database(d.Database()).Table(tableName)
+		builder := d.builder().Table(tableName)
 		for _, c := range cc {
 			builder = c(builder)
 		}
+		builder = d.softDeleteScope(builder, tableName)
 		sqlContent, err := builder.Type(SQLOperationSelect).Build(sqlFunc)
 		if err != nil {
 			return nil, err
 		}
-		return d.nQuery(sqlContent.SQL, data)
+		return d.cachedQuery(builder, sqlContent.SQL, data, func() ([]map[string]interface{}, error) {
+			return d.nQuery(sqlContent.SQL, data)
+		})
 	}
 	return nil, errors.New("not support db type: " + d.dbtype)
 }
@@ -811,17 +1102,20 @@ func (d *DBCli) List(tableName any, data map[string]interface{}, cc ...FuncWithB
 func (d *DBCli) ListWithPage(tableName any, data map[string]interface{}, pageIndex int, pageSize int, cc ...FuncWithBuilder) ([]map[string]interface{}, int, error) {
 	if sqlFunc, ok := GetSqlTransformer(d.dbtype); ok {
 
-		builder := Builder().Database(d.Database()).Table(tableName)
+		builder := d.builder().Table(tableName)
 		for _, c := range cc {
 			builder = c(builder)
 		}
+		builder = d.softDeleteScope(builder, tableName)
 		countSqlContent, err := builder.Type(SQLOperationCount).Build(sqlFunc)
 		if err != nil {
 			return nil, 0, err
 		}
 		// 1. 获取总数
 		var totalCount int
-		r, err := d.nQueryOne(countSqlContent.SQL, data)
+		r, err := d.cachedQueryOne(builder, countSqlContent.SQL, data, func() (map[string]interface{}, error) {
+			return d.nQueryOne(countSqlContent.SQL, data)
+		})
 		if err != nil {
 			return nil, 0, err
 		}
@@ -839,7 +1133,9 @@ func (d *DBCli) ListWithPage(tableName any, data map[string]interface{}, pageInd
 			return nil, 0, err
 		}
 
-		results, err := d.nQuery(dataSqlContent.SQL, data)
+		results, err := d.cachedQuery(builder, dataSqlContent.SQL, data, func() ([]map[string]interface{}, error) {
+			return d.nQuery(dataSqlContent.SQL, data)
+		})
 		if err != nil {
 			return nil, 0, err
 		}
@@ -851,31 +1147,44 @@ func (d *DBCli) ListWithPage(tableName any, data map[string]interface{}, pageInd
 }
 
 func (d *DBCli) Delete(tableName any, data map[string]interface{}, cc ...FuncWithBuilder) (int64, error) {
+	if affected, ok, err := d.softDelete(tableName, data, cc...); ok {
+		return affected, err
+	}
 	if sqlFunc, ok := GetSqlTransformer(d.dbtype); ok {
-		builder := Builder().Database(d.Database()).Table(tableName)
+		builder := d.builder().Table(tableName)
 		for _, c := range cc {
 			builder = c(builder)
 		}
+		if err := d.guardBuilder(builder, "DELETE", tableName); err != nil {
+			return 0, err
+		}
 		sqlContent, err := builder.Type(SQLOperationDelete).Build(sqlFunc)
 		if err != nil {
 			return 0, err
 		}
-		return d.nExcute(sqlContent.SQL, data)
+		affected, err := d.nExcute(sqlContent.SQL, data)
+		if err == nil {
+			d.invalidateBuilder(builder)
+		}
+		return affected, err
 	}
 	return 0, errors.New("not support db type: " + d.dbtype)
 }
 
 func (d *DBCli) First(tableName any, data map[string]interface{}, cc ...FuncWithBuilder) (map[string]interface{}, error) {
 	if sqlFunc, ok := GetSqlTransformer(d.dbtype); ok {
-		builder := Builder().Database(d.Database()).Table(tableName).Limit(1)
+		builder := d.builder().Table(tableName).Limit(1)
 		for _, c := range cc {
 			builder = c(builder)
 		}
+		builder = d.softDeleteScope(builder, tableName)
 		sqlContent, err := builder.Type(SQLOperationSelect).Build(sqlFunc)
 		if err != nil {
 			return nil, err
 		}
-		return d.nQueryOne(sqlContent.SQL, data)
+		return d.cachedQueryOne(builder, sqlContent.SQL, data, func() (map[string]interface{}, error) {
+			return d.nQueryOne(sqlContent.SQL, data)
+		})
 	}
 
 	return nil, errors.New("not support db type: " + d.dbtype)
@@ -883,15 +1192,18 @@ func (d *DBCli) First(tableName any, data map[string]interface{}, cc ...FuncWith
 
 func (d *DBCli) Count(tableName any, data map[string]interface{}, cc ...FuncWithBuilder) (int64, error) {
 	if sqlFunc, ok := GetSqlTransformer(d.dbtype); ok {
-		builder := Builder().Database(d.Database()).Table(tableName).Select("COUNT(1) AS count").Limit(1)
+		builder := d.builder().Table(tableName).Select("COUNT(1) AS count").Limit(1)
 		for _, c := range cc {
 			builder = c(builder)
 		}
+		builder = d.softDeleteScope(builder, tableName)
 		sqlContent, err := builder.Type(SQLOperationSelect).Build(sqlFunc)
 		if err != nil {
 			return 0, err
 		}
-		result, err := d.nQueryOne(sqlContent.SQL, data)
+		result, err := d.cachedQueryOne(builder, sqlContent.SQL, data, func() (map[string]interface{}, error) {
+			return d.nQueryOne(sqlContent.SQL, data)
+		})
 		if err != nil {
 			return 0, err
 		}
@@ -916,11 +1228,6 @@ func (d *DBCli) ReadSQLFile(r io.Reader, callback FuncSQLStatementCallback, opti
 	return errors.New("not support db type: " + d.dbtype)
 }
 
-var (
-	// 创建一个默认过期时间为2分钟的缓存，每5分钟清理一次过期项
-	tableColumnsCache = cache.New(2*time.Minute, 5*time.Minute)
-)
-
 func (d *DBCli) FieldExists(tableName string, fieldName string) (bool, error) {
 	// Get all columns for the specified table
 	columns, err := d.GetTableColumns(tableName)
@@ -942,17 +1249,17 @@ func (d *DBCli) FieldExists(tableName string, fieldName string) (bool, error) {
 }
 
 func (d *DBCli) Select(dest interface{}, query string, args ...interface{}) error {
-	err := d.cli.Select(dest, query, args...)
+	err := d.oper().Select(dest, query, args...)
 	if err != nil {
-		return fmt.Errorf("[Select]: %s | => %s", query, err)
+		return fmt.Errorf("[Select]: %s | => %w", query, err)
 	}
 	return nil
 }
 
 func (d *DBCli) Get(dest interface{}, query string, args ...interface{}) error {
-	err := d.cli.Get(dest, query, args...)
+	err := d.oper().Get(dest, query, args...)
 	if err != nil {
-		return fmt.Errorf("[Get]: %s | => %s", query, err)
+		return fmt.Errorf("[Get]: %s | => %w", query, err)
 	}
 	return nil
 }
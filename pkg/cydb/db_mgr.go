@@ -1,6 +1,9 @@
 package cydb
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql/driver"
 	"embed"
 	"errors"
 	"io/fs"
@@ -15,7 +18,11 @@ import (
 )
 
 type DBMgr struct {
-	dbclis sync.Map
+	dbclis    sync.Map
+	monitor   healthMonitor
+	refresher credentialRefresher
+	// reconnectMu 健康检查与凭据刷新不同时重建同一连接
+	reconnectMu sync.Mutex
 }
 
 func (s *DBMgr) GetCli(key string) *DBCli {
@@ -57,6 +64,8 @@ func (s *DBMgr) GetOrCreateCli(params map[string]interface{}) (*DBCli, error) {
 }
 
 func (s *DBMgr) CloseAll() {
+	s.StopHealthCheck()
+	s.StopCredentialRefresh()
 	s.dbclis.Range(func(k, v interface{}) bool {
 		cli, ok := v.(*DBCli)
 		if !ok {
@@ -85,26 +94,116 @@ func (s *DBMgr) InitByConfig(conf *Config) error {
 	return nil
 }
 
+// DBOpener 由方言可选实现，自行创建连接池，用于需要对每个新连接执行会话设置的场景
+// 未实现时使用 sqlx.Open
+type DBOpener interface {
+	OpenDB(driverName, dsn string, v *DBConnection) (*sqlx.DB, error)
+}
+
+// TryConnect 建立连接，密码按 DBConnection.ResolvePassword 解析；保存的配置保留凭据引用，重连时重新解析
 func TryConnect(v *DBConnection) (*DBCli, error) {
-	if sqlFunc, ok := GetSqlDialect(v.Type); ok {
-		driverName, conn := sqlFunc.GetConnectStr(v)
-		sqlxDB, err := sqlx.Open(driverName, conn)
-		if err != nil {
-			return nil, err
-		}
-		maxIdleConns := 5
-		maxOpenConns := 10
-		maxLifetime := 600
-		sqlxDB.SetMaxIdleConns(maxIdleConns)
-		sqlxDB.SetMaxOpenConns(maxOpenConns)
-		sqlxDB.SetConnMaxLifetime(time.Duration(maxLifetime) * time.Second)
-		err = sqlxDB.Ping()
-		if err != nil {
-			return nil, err
+	pw, err := v.ResolvePassword(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	sqlxDB, err := openDB(v, pw)
+	if err != nil {
+		return nil, err
+	}
+	cfg := *v
+	cli := &DBCli{pool: newDBPool(sqlxDB), key: v.Key, dbtype: v.Type, database: v.DBName, un: v.Un, pw: v.Pw, conn: &cfg, stats: &cliStats{}, audit: newAuditConf()}
+	cli.pool.pwSum = sha256.Sum256([]byte(pw))
+	cli.SetGuard(v.Guard)
+	cli.SetTenantPolicy(v.Tenant)
+	cli.translate = v.Translate
+	cli.sc = newSchemaCache(v.Key, v.SchemaCacheTTL)
+	// search_path 形式的多个 schema 取第一个作为默认 schema
+	cli.schema, _, _ = strings.Cut(v.Schema, ",")
+	cli.schema = strings.TrimSpace(cli.schema)
+	if len(v.Sequences) > 0 {
+		cli.seqs = make(map[string]string, len(v.Sequences))
+		for t, seq := range v.Sequences {
+			cli.seqs[strings.ToUpper(bareTableName(t))] = seq
 		}
-		return &DBCli{cli: sqlxDB, key: v.Key, dbtype: v.Type, database: v.DBName, un: v.Un, pw: v.Pw}, err
 	}
-	return nil, errors.New("db type not found")
+	return cli, nil
+}
+
+// openDB 使用明文密码 pw 打开连接池并确认可以连接
+func openDB(v *DBConnection, pw string) (*sqlx.DB, error) {
+	sqlFunc, ok := GetSqlDialect(v.Type)
+	if !ok {
+		return nil, errors.New("db type not found")
+	}
+	resolved := *v
+	resolved.Pw = pw
+	driverName, conn := sqlFunc.GetConnectStr(&resolved)
+	var sqlxDB *sqlx.DB
+	var err error
+	if opener, ok := sqlFunc.(DBOpener); ok {
+		sqlxDB, err = opener.OpenDB(driverName, conn, &resolved)
+	} else {
+		sqlxDB, err = sqlx.Open(driverName, conn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	maxIdleConns := 5
+	maxOpenConns := 10
+	maxLifetime := 600
+	sqlxDB.SetMaxIdleConns(maxIdleConns)
+	sqlxDB.SetMaxOpenConns(maxOpenConns)
+	sqlxDB.SetConnMaxLifetime(time.Duration(maxLifetime) * time.Second)
+	if err := sqlxDB.Ping(); err != nil {
+		_ = sqlxDB.Close()
+		return nil, err
+	}
+	return sqlxDB, nil
+}
+
+// OpenDriver 与 TryConnect 相同地解析密码并经方言的 DBOpener 建立连接，返回该连接池的驱动及连接串
+// 供需要包装驱动连接的场景使用，例如 cydbtest 录制 SQL；DBOpener 在驱动上设置的会话参数随驱动保留
+func OpenDriver(v *DBConnection) (driver.Driver, string, error) {
+	pw, err := v.ResolvePassword(context.Background())
+	if err != nil {
+		return nil, "", err
+	}
+	db, err := openDB(v, pw)
+	if err != nil {
+		return nil, "", err
+	}
+	drv := db.Driver()
+	_ = db.Close()
+	sqlFunc, _ := GetSqlDialect(v.Type)
+	resolved := *v
+	resolved.Pw = pw
+	_, dsn := sqlFunc.GetConnectStr(&resolved)
+	return drv, dsn, nil
+}
+
+// reopen 按建立连接时的配置重新打开连接池并原地替换，DBCli 及其副本继续可用
+func (d *DBCli) reopen(ctx context.Context) error {
+	if d.conn == nil || d.pool == nil {
+		return errors.New("db connection can not be reopened: " + d.key)
+	}
+	pw, err := d.conn.ResolvePassword(ctx)
+	if err != nil {
+		return err
+	}
+	return d.reopenWith(pw)
+}
+
+// reopenWith 使用已解析的明文密码 pw 重新打开连接池并原地替换
+func (d *DBCli) reopenWith(pw string) error {
+	db, err := openDB(d.conn, pw)
+	if err != nil {
+		return err
+	}
+	if err := d.pool.swap(db); err != nil {
+		return err
+	}
+	d.pool.pwSum = sha256.Sum256([]byte(pw))
+	return nil
 }
 
 type MigrateSQLParam struct {
@@ -0,0 +1,167 @@
+package cydb
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// FuncTranslator 由方言可选实现，将 MySQL 函数调用改写为目标库的等价写法
+// name 为原函数名，args 为已转换的参数 SQL；返回 false 表示保持原样
+type FuncTranslator interface {
+	TranslateFunc(name string, args []string, distinct bool) (string, bool)
+}
+
+// FuncRewrite 单个函数的改写规则
+type FuncRewrite func(args []string, distinct bool) (string, bool)
+
+// FuncTable 函数名（大写）到改写规则的映射，方言可直接用它实现 FuncTranslator
+type FuncTable map[string]FuncRewrite
+
+// TranslateFunc implements FuncTranslator.
+func (t FuncTable) TranslateFunc(name string, args []string, distinct bool) (string, bool) {
+	if fn, ok := t[strings.ToUpper(name)]; ok {
+		return fn(args, distinct)
+	}
+	return "", false
+}
+
+// FuncRename 仅替换函数名，参数与 DISTINCT 保持不变
+func FuncRename(name string) FuncRewrite {
+	return func(args []string, distinct bool) (string, bool) {
+		if distinct && len(args) > 0 {
+			return fmt.Sprintf("%s(DISTINCT %s)", name, strings.Join(args, ", ")), true
+		}
+		return fmt.Sprintf("%s(%s)", name, strings.Join(args, ", ")), true
+	}
+}
+
+// FuncConst 无参函数替换为固定表达式，如 CURDATE() -> CURRENT_DATE
+func FuncConst(sql string) FuncRewrite {
+	return func(args []string, distinct bool) (string, bool) {
+		if len(args) != 0 {
+			return "", false
+		}
+		return sql, true
+	}
+}
+
+// FuncIf IF(cond, a, b) -> CASE WHEN cond THEN a ELSE b END
+func FuncIf(args []string, distinct bool) (string, bool) {
+	if len(args) != 3 {
+		return "", false
+	}
+	return fmt.Sprintf("CASE WHEN %s THEN %s ELSE %s END", args[0], args[1], args[2]), true
+}
+
+// FuncConcatOp CONCAT(a, b, ...) -> (a || b || ...)
+func FuncConcatOp(args []string, distinct bool) (string, bool) {
+	if len(args) == 0 {
+		return "", false
+	}
+	return "(" + strings.Join(args, " || ") + ")", true
+}
+
+// FuncConcatWsOp CONCAT_WS(sep, a, b, ...) -> (a || sep || b ...)
+func FuncConcatWsOp(args []string, distinct bool) (string, bool) {
+	if len(args) < 2 {
+		return "", false
+	}
+	return "(" + strings.Join(args[1:], " || "+args[0]+" || ") + ")", true
+}
+
+// UnquoteLiteral 去掉单引号字符串字面量的引号，非字面量返回 false
+func UnquoteLiteral(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		return "", false
+	}
+	return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), true
+}
+
+// QuoteLiteral 生成单引号字符串字面量
+func QuoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// MySQL DATE_FORMAT 格式符到 TO_CHAR 格式（PostgreSQL/Oracle 通用部分）
+var ToCharDateFormat = map[byte]string{
+	'Y': "YYYY", 'y': "YY", 'm': "MM", 'd': "DD", 'H': "HH24", 'h': "HH12", 'I': "HH12",
+	'i': "MI", 's': "SS", 'S': "SS", 'p': "AM", 'j': "DDD", 'b': "Mon", 'a': "Dy",
+	'T': "HH24:MI:SS",
+}
+
+// MySQL DATE_FORMAT 格式符到 strftime 格式
+var StrftimeDateFormat = map[byte]string{
+	'Y': "%Y", 'm': "%m", 'd': "%d", 'H': "%H", 'i': "%M", 's': "%S", 'S': "%S",
+	'j': "%j", 'T': "%H:%M:%S",
+}
+
+// ConvertDateFormat 按 spec 转换 MySQL 日期格式串，text 处理格式符之间的普通文本
+// 遇到 spec 中没有的格式符返回 false
+func ConvertDateFormat(format string, spec map[byte]string, text func(string) string) (string, bool) {
+	var sb, lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			sb.WriteString(text(lit.String()))
+			lit.Reset()
+		}
+	}
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i == len(format)-1 {
+			lit.WriteByte(format[i])
+			continue
+		}
+		i++
+		if format[i] == '%' {
+			lit.WriteByte('%')
+			continue
+		}
+		v, ok := spec[format[i]]
+		if !ok {
+			return "", false
+		}
+		flush()
+		sb.WriteString(v)
+	}
+	flush()
+	return sb.String(), true
+}
+
+// ToCharText TO_CHAR 格式中的普通文本，含字母时用双引号包裹
+func ToCharText(s string) string {
+	if strings.IndexFunc(s, unicode.IsLetter) >= 0 {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// StrftimeText strftime 格式中的普通文本，转义 %
+func StrftimeText(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+// FuncDateFormat DATE_FORMAT(x, fmt) 转换为 render(x, 目标格式字面量)，格式必须是字符串常量
+func FuncDateFormat(spec map[byte]string, text func(string) string, render func(expr, format string) string) FuncRewrite {
+	return func(args []string, distinct bool) (string, bool) {
+		if len(args) != 2 {
+			return "", false
+		}
+		format, ok := UnquoteLiteral(args[1])
+		if !ok {
+			return "", false
+		}
+		converted, ok := ConvertDateFormat(format, spec, text)
+		if !ok {
+			return "", false
+		}
+		return render(args[0], QuoteLiteral(converted)), true
+	}
+}
+
+// SplitGroupConcat 拆分 GROUP_CONCAT 参数为表达式列表与分隔符
+func SplitGroupConcat(args []string) ([]string, string) {
+	if len(args) < 2 {
+		return args, QuoteLiteral(",")
+	}
+	return args[:len(args)-1], args[len(args)-1]
+}
@@ -0,0 +1,260 @@
+package cydb
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/parser/ast"
+)
+
+// ErrDangerousStatement 语句被危险语句检查拒绝，可用 errors.Is 判断
+var ErrDangerousStatement = errors.New("dangerous statement")
+
+// GuardPolicy 危险语句检查策略，零值表示全部检查
+// 开启后拒绝不带 WHERE 的 UPDATE/DELETE（含 Update/Delete 等方法未指定条件）、迁移之外的 DDL 以及一次执行多条语句
+type GuardPolicy struct {
+	// AllowNoWhere 允许不带 WHERE 的 UPDATE/DELETE
+	AllowNoWhere bool
+	// AllowDDL 允许在迁移之外执行 CREATE/ALTER/DROP/TRUNCATE 等语句
+	AllowDDL bool
+	// AllowMultiStatement 允许一次执行多条语句
+	AllowMultiStatement bool
+}
+
+type guardConf struct {
+	policy GuardPolicy
+	// fullTable 由 AllowFullTable 开启，ddl 在执行迁移时开启
+	fullTable bool
+	ddl       bool
+}
+
+// SetGuard 为当前连接开启危险语句检查，p 为 nil 时关闭
+func (d *DBCli) SetGuard(p *GuardPolicy) {
+	if p == nil {
+		d.guard = nil
+		return
+	}
+	d.guard = &guardConf{policy: *p}
+}
+
+// AllowFullTable 返回允许不带条件更新、删除整张表的 DBCli 副本
+func (d *DBCli) AllowFullTable() *DBCli {
+	cp := *d
+	if cp.guard != nil {
+		g := *cp.guard
+		g.fullTable = true
+		cp.guard = &g
+	}
+	return &cp
+}
+
+// migrationScope 迁移中允许执行 DDL
+func (d *DBCli) migrationScope() *DBCli {
+	cp := *d
+	if cp.guard != nil {
+		g := *cp.guard
+		g.ddl = true
+		cp.guard = &g
+	}
+	return &cp
+}
+
+func guardError(format string, args ...any) error {
+	return &DatabaseError{Code: ErrCodeDangerous, Message: fmt.Sprintf(format, args...), Cause: ErrDangerousStatement}
+}
+
+func whereNotEmpty(w Where) bool {
+	if g, ok := w.(*whereGroup); ok {
+		if g == nil {
+			return false
+		}
+		for _, c := range g.conditions {
+			if whereNotEmpty(c) {
+				return true
+			}
+		}
+		return false
+	}
+	return w != nil
+}
+
+// guardBuilder 检查 UPDATE/DELETE 构建器是否带有条件，需在追加租户、软删除等条件之前调用
+func (d *DBCli) guardBuilder(builder SQLBuilder, op string, tableName any) error {
+	if d.guard == nil || d.guard.policy.AllowNoWhere || d.guard.fullTable {
+		return nil
+	}
+	if qb, ok := builder.(*sqlBuilder); ok && (qb.whereClause == nil || !whereNotEmpty(qb.whereClause)) {
+		return guardError("%s %s without WHERE, use AllowFullTable to confirm", op, auditTableName(tableName))
+	}
+	return nil
+}
+
+var ddlKeywords = map[string]struct{}{
+	"CREATE": {}, "ALTER": {}, "DROP": {}, "TRUNCATE": {}, "RENAME": {}, "GRANT": {}, "REVOKE": {},
+}
+
+var (
+	firstWordPattern = regexp.MustCompile(`^[\s(]*([A-Za-z]+)`)
+	wherePattern     = regexp.MustCompile(`(?i)\bWHERE\b`)
+)
+
+// splitStatements 按分号拆分语句，字符串与注释中的分号不拆分，返回的语句已去掉注释
+func splitStatements(sql string) []string {
+	var r []string
+	var sb strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(sb.String()); s != "" {
+			r = append(r, s)
+		}
+		sb.Reset()
+	}
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			j := i + 1
+			for j < len(sql) {
+				if sql[j] == c {
+					// 连续两个引号为转义
+					if j+1 < len(sql) && sql[j+1] == c {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			end := min(j+1, len(sql))
+			sb.WriteString(sql[i:end])
+			i = end - 1
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			j := strings.IndexByte(sql[i:], '\n')
+			if j < 0 {
+				i = len(sql)
+			} else {
+				i += j
+			}
+			sb.WriteByte(' ')
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			j := strings.Index(sql[i+2:], "*/")
+			if j < 0 {
+				i = len(sql)
+			} else {
+				i += j + 3
+			}
+			sb.WriteByte(' ')
+		case c == ';':
+			flush()
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	flush()
+	return r
+}
+
+// statementKeyword 返回语句的关键字（大写）及其位置
+// WITH 开头时跳过各个公用表表达式，返回主语句的关键字，例如 WITH x AS (...) DELETE FROM t 返回 DELETE
+func statementKeyword(s string) (string, int) {
+	m := firstWordPattern.FindStringSubmatchIndex(s)
+	if m == nil {
+		return "", -1
+	}
+	kw := strings.ToUpper(s[m[2]:m[3]])
+	if kw != "WITH" {
+		return kw, m[2]
+	}
+	// closed 表示当前公用表表达式的括号已结束，之后第一个不属于 CTE 定义的单词即为主语句关键字
+	depth, closed := 0, false
+	for i := m[3]; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = quotedEnd(s, i) - 1
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			closed = depth == 0
+		case c == ',' && depth == 0:
+			closed = false
+		case depth == 0 && closed && isWordChar(c):
+			j := i
+			for j < len(s) && isWordChar(s[j]) {
+				j++
+			}
+			switch w := strings.ToUpper(s[i:j]); w {
+			case "AS", "NOT", "MATERIALIZED":
+				i = j - 1
+			default:
+				return w, i
+			}
+		}
+	}
+	return kw, m[2]
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// hasWhere 优先用解析器判断，无法解析的方言语法去掉字符串后查找 WHERE
+func hasWhere(stmt string) bool {
+	var params []string
+	if nodes, _, err := parser.New().Parse(preprocessSQLParams(stmt, &params), "", ""); err == nil && len(nodes) == 1 {
+		switch n := nodes[0].(type) {
+		case *ast.UpdateStmt:
+			return n.Where != nil
+		case *ast.DeleteStmt:
+			return n.Where != nil
+		}
+	}
+	return wherePattern.MatchString(stringLiteralPattern.ReplaceAllString(stmt, "''"))
+}
+
+var stringLiteralPattern = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+// CheckStatement 按策略检查原生 SQL
+func CheckStatement(sql string, p GuardPolicy) error {
+	return checkStatement(sql, &guardConf{policy: p})
+}
+
+func checkStatement(sql string, g *guardConf) error {
+	stmts := splitStatements(sql)
+	if len(stmts) > 1 && !g.policy.AllowMultiStatement {
+		return guardError("multiple statements are not allowed: %s", sql)
+	}
+	for _, s := range stmts {
+		kw, pos := statementKeyword(s)
+		if kw == "" {
+			continue
+		}
+		if _, ok := ddlKeywords[kw]; ok && !g.policy.AllowDDL && !g.ddl {
+			return guardError("%s is only allowed in migrations: %s", kw, s)
+		}
+		// 带 WITH 时只检查主语句，公用表表达式中的 WHERE 不算
+		if (kw == "UPDATE" || kw == "DELETE") && !g.policy.AllowNoWhere && !g.fullTable && !hasWhere(s[pos:]) {
+			return guardError("%s without WHERE, use AllowFullTable to confirm: %s", kw, s)
+		}
+	}
+	return nil
+}
+
+// rawSQL 检查通过 Excute、Query 等方法执行的原生 SQL
+func (d *DBCli) rawSQL(sql string) error {
+	if err := d.tenantRawSQL(sql); err != nil {
+		return err
+	}
+	return d.guardSQL(sql)
+}
+
+// guardSQL 危险语句检查
+func (d *DBCli) guardSQL(sql string) error {
+	if d.guard == nil {
+		return nil
+	}
+	return checkStatement(sql, d.guard)
+}
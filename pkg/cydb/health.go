@@ -0,0 +1,258 @@
+package cydb
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnState 连接健康状态
+type ConnState string
+
+const (
+	ConnStateUp   ConnState = "up"
+	ConnStateDown ConnState = "down"
+)
+
+// ConnEvent 连接断开或恢复时的通知
+type ConnEvent struct {
+	Key   string
+	State ConnState
+	// Err 断开时为 ping 或重连失败的错误
+	Err  error
+	Time time.Time
+}
+
+// ConnStats 单个连接的连接池与查询统计
+type ConnStats struct {
+	Key   string
+	State ConnState
+	Pool  sql.DBStats
+	// Queries 查询与执行的总次数，Errors 为其中失败的次数
+	Queries      int64
+	Errors       int64
+	TotalLatency time.Duration
+	MaxLatency   time.Duration
+	LastCheck    time.Time
+	LastError    string
+}
+
+// AvgLatency 平均耗时
+func (s ConnStats) AvgLatency() time.Duration {
+	if s.Queries == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Queries)
+}
+
+// cliStats DBCli 的查询计数，事务等派生的 DBCli 共享同一份
+type cliStats struct {
+	queries    atomic.Int64
+	errors     atomic.Int64
+	latency    atomic.Int64
+	maxLatency atomic.Int64
+}
+
+func (s *cliStats) observe(d time.Duration, err error) {
+	s.queries.Add(1)
+	if err != nil {
+		s.errors.Add(1)
+	}
+	s.latency.Add(int64(d))
+	for {
+		m := s.maxLatency.Load()
+		if int64(d) <= m || s.maxLatency.CompareAndSwap(m, int64(d)) {
+			return
+		}
+	}
+}
+
+// observe 记录一次查询，start 为开始时间
+func (d *DBCli) observe(start time.Time, err error) {
+	if d.stats != nil {
+		d.stats.observe(time.Since(start), err)
+	}
+}
+
+// Stats 返回当前连接的连接池与查询统计
+func (d *DBCli) Stats() ConnStats {
+	st := ConnStats{Key: d.key, State: ConnStateUp}
+	if db := d.GetDB(); db != nil {
+		st.Pool = db.Stats()
+	}
+	if d.stats != nil {
+		st.Queries = d.stats.queries.Load()
+		st.Errors = d.stats.errors.Load()
+		st.TotalLatency = time.Duration(d.stats.latency.Load())
+		st.MaxLatency = time.Duration(d.stats.maxLatency.Load())
+	}
+	return st
+}
+
+// Ping 检查连接是否可用
+func (d *DBCli) Ping(ctx context.Context) error {
+	if db := d.GetDB(); db != nil {
+		return db.PingContext(ctx)
+	}
+	return nil
+}
+
+type connHealth struct {
+	state     ConnState
+	lastCheck time.Time
+	lastErr   error
+}
+
+type healthMonitor struct {
+	mu        sync.Mutex
+	conns     map[string]*connHealth
+	listeners []func(ConnEvent)
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func (s *DBMgr) health(key string) *connHealth {
+	if s.monitor.conns == nil {
+		s.monitor.conns = map[string]*connHealth{}
+	}
+	h, ok := s.monitor.conns[key]
+	if !ok {
+		h = &connHealth{state: ConnStateUp}
+		s.monitor.conns[key] = h
+	}
+	return h
+}
+
+// OnConnEvent 注册连接断开与恢复的回调，回调在健康检查的协程中同步执行
+func (s *DBMgr) OnConnEvent(fn func(ConnEvent)) {
+	s.monitor.mu.Lock()
+	defer s.monitor.mu.Unlock()
+	s.monitor.listeners = append(s.monitor.listeners, fn)
+}
+
+// StartHealthCheck 每隔 interval ping 一次所有连接，断开的连接按原配置重新连接
+// 重复调用时只保留第一次启动的检查
+func (s *DBMgr) StartHealthCheck(interval time.Duration) {
+	s.monitor.mu.Lock()
+	defer s.monitor.mu.Unlock()
+	if s.monitor.stop != nil {
+		return
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	s.monitor.stop, s.monitor.done = stop, done
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				s.CheckHealth(ctx)
+				cancel()
+			}
+		}
+	}()
+}
+
+// StopHealthCheck 停止健康检查并等待正在进行的检查结束
+func (s *DBMgr) StopHealthCheck() {
+	s.monitor.mu.Lock()
+	stop, done := s.monitor.stop, s.monitor.done
+	s.monitor.stop, s.monitor.done = nil, nil
+	s.monitor.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// CheckHealth 立即检查一次所有连接
+// ping 失败的连接先标记为 down，再用建立连接时的配置打开新的连接池原地替换，成功后标记为 up
+// DBMgr 中的 DBCli 不变，调用方持有的 DBCli 继续可用；同一个 DBCli 注册在多个 key 下时只检查、重连一次
+// 已经 Close 的 DBCli 跳过，不会被重新连接
+func (s *DBMgr) CheckHealth(ctx context.Context) {
+	s.reconnectMu.Lock()
+	defer s.reconnectMu.Unlock()
+	clis, keys := s.uniqueClis()
+	for _, cli := range clis {
+		// 调用方已关闭的连接不再检查，避免在其背后重新打开连接池
+		if cli.pool != nil && cli.pool.isClosed() {
+			continue
+		}
+		ks := keys[cli]
+		err := cli.Ping(ctx)
+		if err != nil && cli.conn != nil {
+			s.updateHealth(ks, err)
+			DBLog().Warn("db ping failed, reconnecting", "key", ks[0], "err", err)
+			err = cli.reopen(ctx)
+		}
+		s.updateHealth(ks, err)
+	}
+}
+
+func (s *DBMgr) updateHealth(keys []string, err error) {
+	state := ConnStateUp
+	if err != nil {
+		state = ConnStateDown
+	}
+	now := time.Now()
+	var events []ConnEvent
+	s.monitor.mu.Lock()
+	for _, k := range keys {
+		h := s.health(k)
+		if h.state != state {
+			events = append(events, ConnEvent{Key: k, State: state, Err: err, Time: now})
+		}
+		h.state, h.lastCheck, h.lastErr = state, now, err
+	}
+	listeners := slices.Clone(s.monitor.listeners)
+	s.monitor.mu.Unlock()
+	for _, e := range events {
+		if e.State == ConnStateDown {
+			DBLog().Error("db connection down", "key", e.Key, "err", e.Err)
+		} else {
+			DBLog().Info("db connection recovered", "key", e.Key)
+		}
+		for _, fn := range listeners {
+			fn(e)
+		}
+	}
+}
+
+// Stats 返回所有连接的统计，按 key 排序
+func (s *DBMgr) Stats() []ConnStats {
+	var r []ConnStats
+	s.dbclis.Range(func(k, v interface{}) bool {
+		cli, ok := v.(*DBCli)
+		if !ok {
+			return true
+		}
+		st := cli.Stats()
+		st.Key = k.(string)
+		s.monitor.mu.Lock()
+		h := s.health(st.Key)
+		st.State, st.LastCheck = h.state, h.lastCheck
+		if h.lastErr != nil {
+			st.LastError = h.lastErr.Error()
+		}
+		s.monitor.mu.Unlock()
+		r = append(r, st)
+		return true
+	})
+	slices.SortFunc(r, func(a, b ConnStats) int {
+		if a.Key < b.Key {
+			return -1
+		}
+		if a.Key > b.Key {
+			return 1
+		}
+		return 0
+	})
+	return r
+}
@@ -38,6 +38,7 @@ const (
 	ErrCodeTimeout      = "TIMEOUT"
 	ErrCodeInvalidParam = "INVALID_PARAMETER"
 	ErrCodeUnsupported  = "UNSUPPORTED_OPERATION"
+	ErrCodeDangerous    = "DANGEROUS_STATEMENT"
 )
 
 // NewDatabaseError creates a new DatabaseError
@@ -223,6 +224,20 @@ type BuildSql interface {
 	GetPkFieldsString(dt DatabaseTransformer, skipAS bool) (string, error)
 	GetValuesString(dt DatabaseTransformer, all bool) (string, []string, error)
 	GetAssignString(dt DatabaseTransformer, all bool) (string, []string, error)
+	// GetAssignItems 逐列返回写入的列与值，update 为 true 时为冲突时更新的列
+	GetAssignItems(dt DatabaseTransformer, update bool) ([]AssignItem, error)
+	// GetConflictGuard 返回 Upsert、Replace 命中已有行时该行必须满足 column = value，ok 为 false 时不限制
+	// 方言无法表达该条件时应返回错误，而不是覆盖不满足条件的行
+	GetConflictGuard(dt DatabaseTransformer) (column string, value string, ok bool, err error)
+}
+
+// AssignItem 写入语句中的一列，Column 为转义后的列名，Value 为值表达式，Fields 为 Value 中的命名参数
+type AssignItem struct {
+	Column string
+	Value  string
+	Fields []string
+	// PK 该列是否为主键
+	PK bool
 }
 
 type DatabaseTransformer interface {
@@ -300,6 +315,10 @@ type SQLBuilder interface {
 	LimitPlaceholder(limit string) SQLBuilder
 	Offset(offset int) SQLBuilder
 	OffsetPlaceholder(offset string) SQLBuilder
+	// Tenant 指定租户，租户隔离的表会自动追加租户条件，AllTenants 表示跳过隔离
+	Tenant(tenant any) SQLBuilder
+	// TenantPolicy 指定多租户隔离策略，DBCli 的方法使用 DBCli.SetTenantPolicy 的设置
+	TenantPolicy(p *TenantPolicy) SQLBuilder
 
 	// 统一的构建方法，通过选项控制不同的构建方式
 	Build(dt DatabaseTransformer) (*BuildResult, error)
@@ -352,6 +371,10 @@ type DBColumn struct {
 	ColumnKey   string
 	OrgDataType string
 	Nullable    bool
+	// AutoIncrement 由数据库生成取值的列，如自增、identity 或由触发器填充的序列主键
+	AutoIncrement bool
+	// Sequence 生成该列取值的序列名，没有时为空
+	Sequence string
 }
 
 type FieldData struct {
@@ -0,0 +1,226 @@
+package cydb
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// OP_JSON_CONTAINS JSON 文档包含，PostgreSQL 为 @>，MySQL 为 JSON_CONTAINS
+	OP_JSON_CONTAINS OP = "@>"
+	// OP_ARRAY_CONTAINS 数组包含全部给定元素
+	OP_ARRAY_CONTAINS OP = "@> ARRAY"
+	// OP_ANY 给定值是数组元素之一
+	OP_ANY OP = "= ANY"
+)
+
+// JSONTransformer 由方言可选实现，生成 JSON 路径取值与数组包含判断的 SQL
+// 未实现时使用 MySQLJSON；column、doc、values 均为已转换的 SQL
+type JSONTransformer interface {
+	// JSONPath 按路径取值，path 中的纯数字为数组下标；asText 为 true 时取文本，否则取 JSON
+	JSONPath(column string, path []string, asText bool) (string, error)
+	// JSONContains column 包含 JSON 文档 doc
+	JSONContains(column, doc string) (string, error)
+	// ArrayContains column 包含 values 中的全部元素
+	ArrayContains(column string, values []string) (string, error)
+	// ArrayAny value 是 column 的元素之一
+	ArrayAny(column, value string) (string, error)
+}
+
+// MySQLJSON MySQL JSON 函数写法，数组为 JSON 数组
+type MySQLJSON struct{}
+
+var _ JSONTransformer = MySQLJSON{}
+
+// JSONPath implements JSONTransformer.
+func (MySQLJSON) JSONPath(column string, path []string, asText bool) (string, error) {
+	sql := fmt.Sprintf("JSON_EXTRACT(%s, %s)", column, mysqlLiteral(JSONPathString(path)))
+	if asText {
+		sql = "JSON_UNQUOTE(" + sql + ")"
+	}
+	return sql, nil
+}
+
+// JSONContains implements JSONTransformer.
+func (MySQLJSON) JSONContains(column, doc string) (string, error) {
+	return fmt.Sprintf("JSON_CONTAINS(%s, %s)", column, doc), nil
+}
+
+// ArrayContains implements JSONTransformer.
+func (MySQLJSON) ArrayContains(column string, values []string) (string, error) {
+	return fmt.Sprintf("JSON_CONTAINS(%s, JSON_ARRAY(%s))", column, strings.Join(values, ", ")), nil
+}
+
+// ArrayAny implements JSONTransformer.
+func (MySQLJSON) ArrayAny(column, value string) (string, error) {
+	return fmt.Sprintf("JSON_CONTAINS(%s, JSON_ARRAY(%s))", column, value), nil
+}
+
+var jsonPathKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// mysqlLiteral MySQL 字符串字面量会处理反斜杠转义，反斜杠需要写两次
+func mysqlLiteral(s string) string {
+	return QuoteLiteral(strings.ReplaceAll(s, `\`, `\\`))
+}
+
+var jsonPathKeyEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// JSONPathString 生成 $.a[0].b 形式的路径，包含特殊字符的键加双引号，键中的 " 与 \ 按 JSON 字符串转义
+// 返回值为路径本身，写入 SQL 字面量时需按目标数据库的字面量规则转义
+func JSONPathString(path []string) string {
+	var sb strings.Builder
+	sb.WriteString("$")
+	for _, p := range path {
+		switch {
+		case IsJSONIndex(p):
+			sb.WriteString("[" + p + "]")
+		case jsonPathKeyPattern.MatchString(p):
+			sb.WriteString("." + p)
+		default:
+			sb.WriteString(".\"" + jsonPathKeyEscaper.Replace(p) + "\"")
+		}
+	}
+	return sb.String()
+}
+
+// IsJSONIndex 路径元素是否为数组下标
+func IsJSONIndex(p string) bool {
+	n, err := strconv.Atoi(p)
+	return err == nil && n >= 0 && strconv.Itoa(n) == p
+}
+
+func jsonTransformer(dt DatabaseTransformer) JSONTransformer {
+	if jt, ok := dt.(JSONTransformer); ok {
+		return jt
+	}
+	return MySQLJSON{}
+}
+
+// JSONPathExpr JSON 列按路径取值
+type JSONPathExpr struct {
+	Field Expression
+	Path  []string
+	// Text 为 true 时取文本（->>），否则取 JSON（->）
+	Text  bool
+	Alias string
+}
+
+// JSON_GET 按路径取 JSON 值，PostgreSQL 为 field->'a'->'b'，MySQL 为 JSON_EXTRACT(field, '$.a.b')
+func JSON_GET(field string, path ...string) Expression {
+	return newJSONPath(field, path, false)
+}
+
+// JSON_TEXT 按路径取文本值，PostgreSQL 为 field->'a'->>'b'，MySQL 为 JSON_UNQUOTE(JSON_EXTRACT(...))
+func JSON_TEXT(field string, path ...string) Expression {
+	return newJSONPath(field, path, true)
+}
+
+func newJSONPath(field string, path []string, text bool) Expression {
+	f, err := parseExpression(field)
+	if err != nil {
+		panic(err)
+	}
+	return &JSONPathExpr{Field: f, Path: path, Text: text}
+}
+
+// ToSQL implements Expression.
+func (je *JSONPathExpr) ToSQL(dt DatabaseTransformer) (string, error) {
+	if len(je.Path) == 0 {
+		return "", errors.New("json path is empty")
+	}
+	col, err := je.Field.ToSQL(dt)
+	if err != nil {
+		return "", err
+	}
+	sql, err := jsonTransformer(dt).JSONPath(col, je.Path, je.Text)
+	if err != nil {
+		return "", err
+	}
+	if je.Alias != "" {
+		sql = fmt.Sprintf("%s AS %s", sql, dt.EscapeColumnName(je.Alias))
+	}
+	return sql, nil
+}
+
+// GetFields implements Expression.
+func (je *JSONPathExpr) GetFields() []string {
+	return je.Field.GetFields()
+}
+
+// SetAlias implements Expression.
+func (je *JSONPathExpr) SetAlias(alias string) Expression {
+	je.Alias = alias
+	return je
+}
+
+// COMPARE 以表达式为左值的比较条件，如 COMPARE(JSON_TEXT("attrs", "color"), OP_EQ, WithParameter("color"))
+func COMPARE(left Expression, op OP, f ...WhereOptionFunc) Where {
+	return createWhere(left, op, f...)
+}
+
+// JSON_CONTAINS field 包含给定的 JSON 文档，值应为 JSON 字符串，默认绑定同名参数
+func JSON_CONTAINS(field string, f ...WhereOptionFunc) Where {
+	return createWhere(field, OP_JSON_CONTAINS, f...)
+}
+
+// ARRAY_CONTAINS field 包含 values 中的全部元素，PostgreSQL 为数组列，MySQL 为 JSON 数组列
+// values 的元素可以是字面值或 &ParameterValue{}
+func ARRAY_CONTAINS(field string, values []any, f ...WhereOptionFunc) Where {
+	return createWhere(field, OP_ARRAY_CONTAINS, append(f, WithNativeValue(values))...)
+}
+
+// ANY 给定值是 field 的元素之一，PostgreSQL 为 value = ANY(field)，默认绑定同名参数
+func ANY(field string, f ...WhereOptionFunc) Where {
+	return createWhere(field, OP_ANY, f...)
+}
+
+// jsonCondition 处理 JSON 与数组相关的操作符
+func (w *whereItem) jsonCondition(dt DatabaseTransformer) (*Condition, error) {
+	leftSQL, leftFields, err := w.processLeft(dt)
+	if err != nil {
+		return nil, err
+	}
+	jt := jsonTransformer(dt)
+	if w.Op == OP_ARRAY_CONTAINS {
+		values, ok := w.Right.([]any)
+		if !ok || len(values) == 0 {
+			return nil, errors.New("ARRAY_CONTAINS requires a non-empty slice of values")
+		}
+		items := make([]string, 0, len(values))
+		fields := leftFields
+		for _, v := range values {
+			expr, ok := v.(Expression)
+			if !ok {
+				expr = &LiteralValue{Value: v}
+			}
+			s, err := expr.ToSQL(dt)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, s)
+			fields = append(fields, expr.GetFields()...)
+		}
+		cond, err := jt.ArrayContains(leftSQL, items)
+		if err != nil {
+			return nil, err
+		}
+		return &Condition{Condition: cond, Fields: fields}, nil
+	}
+	rightSQL, rightFields, err := w.processRight(dt, leftFields)
+	if err != nil {
+		return nil, err
+	}
+	var cond string
+	if w.Op == OP_ANY {
+		cond, err = jt.ArrayAny(leftSQL, rightSQL)
+	} else {
+		cond, err = jt.JSONContains(leftSQL, rightSQL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Condition{Condition: cond, Fields: append(leftFields, rightFields...)}, nil
+}
@@ -15,6 +15,7 @@ import (
 )
 
 func (d *DBCli) migrateFromFolder(pm *MigrateSQLParam, migrationsPath string) error {
+	d = d.migrationScope()
 	migrateTableName := "_migrations"
 	if pm.serviceOwner != "" {
 		serviceOwner := strings.ToLower(pm.serviceOwner)
@@ -95,6 +96,10 @@ func (d *DBCli) migrateFromFolder(pm *MigrateSQLParam, migrationsPath string) er
 		}
 		return errors.New("some files not found in " + migrateTableName)
 	}
+	if len(pathNeedMerge) > 0 {
+		// 迁移中可能有无法识别表名的 DDL，结束后清理全部表结构缓存
+		defer d.InvalidateSchema()
+	}
 	funcExcuteSql := func(block *SQLStatement) error {
 		if block == nil || len(strings.TrimSpace(block.Content)) == 0 {
 			return nil
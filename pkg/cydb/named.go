@@ -0,0 +1,259 @@
+package cydb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// InListLimiter 由方言可选实现，返回 IN 列表允许的最大元素个数，超出时拆分为多个 IN 用 OR 连接
+type InListLimiter interface {
+	MaxInListSize() int
+}
+
+var (
+	inListPrefix = regexp.MustCompile("(?i)([\\w.\"`\\[\\]]+)\\s+(NOT\\s+)?IN\\s*\\(\\s*$")
+	inListSuffix = regexp.MustCompile(`^\s*\)`)
+)
+
+// isExpandable 切片参数需要展开，[]byte 与实现 driver.Valuer 的类型按单个值绑定
+func isExpandable(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+	if _, ok := v.(driver.Valuer); ok {
+		return false
+	}
+	t := reflect.TypeOf(v)
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return false
+	}
+	return t.Elem().Kind() != reflect.Uint8
+}
+
+func sliceItems(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	r := make([]interface{}, rv.Len())
+	for i := range r {
+		r[i] = rv.Index(i).Interface()
+	}
+	return r
+}
+
+// writeHolders 为每个元素写入占位符，元素本身是切片时写为 (?, ?)，用于 (a, b) IN ((?, ?), ...)
+func writeHolders(sb *strings.Builder, items []interface{}) []interface{} {
+	var out []interface{}
+	for i, item := range items {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		if isExpandable(item) {
+			sub := sliceItems(item)
+			sb.WriteString("(" + strings.TrimSuffix(strings.Repeat("?, ", len(sub)), ", ") + ")")
+			out = append(out, sub...)
+			continue
+		}
+		sb.WriteByte('?')
+		out = append(out, item)
+	}
+	return out
+}
+
+// ExpandNamedQuery 将 :name 命名参数转换为 ? 占位符，data 可以是 map 或带 db 标签的结构体
+// 切片参数展开为对应个数的占位符，元素为切片时展开为 (?, ?) 元组；maxInList > 0 时超长的 IN 列表拆分为 (a IN (...) OR a IN (...))
+// 空切片只能用于 a [NOT] IN (:name)，a IN 替换为恒假的 1=0，a NOT IN 替换为恒真的 1=1，其他位置返回错误
+// 字符串、引号标识符和注释中的 ? 不作为占位符
+// expanded 为 false 表示没有切片参数
+func ExpandNamedQuery(query string, data interface{}, maxInList int) (string, []interface{}, bool, error) {
+	q, args, err := sqlx.Named(query, data)
+	if err != nil {
+		return "", nil, false, err
+	}
+	if !slices.ContainsFunc(args, isExpandable) {
+		return q, args, false, nil
+	}
+	var sb strings.Builder
+	out := make([]interface{}, 0, len(args))
+	idx := 0
+	for i := 0; i < len(q); i++ {
+		c := q[i]
+		if end := quotedEnd(q, i); end > 0 {
+			// 字符串、引号标识符和注释原样输出
+			sb.WriteString(q[i:end])
+			i = end - 1
+			continue
+		}
+		if c != '?' {
+			sb.WriteByte(c)
+			continue
+		}
+		if idx >= len(args) {
+			return "", nil, false, fmt.Errorf("named query has more placeholders than arguments: %s", query)
+		}
+		arg := args[idx]
+		idx++
+		if !isExpandable(arg) {
+			sb.WriteByte('?')
+			out = append(out, arg)
+			continue
+		}
+		items := sliceItems(arg)
+		if len(items) == 0 {
+			// NULL 会使 NOT IN 恒为 NULL，整个 IN 条件改写为常量条件
+			prefix := sb.String()
+			m := inListPrefix.FindStringSubmatchIndex(prefix)
+			end := inListSuffix.FindStringIndex(q[i+1:])
+			if m == nil || end == nil {
+				return "", nil, false, fmt.Errorf("empty slice is only supported in IN list: %s", query)
+			}
+			sb.Reset()
+			sb.WriteString(prefix[:m[0]])
+			if m[4] >= 0 {
+				sb.WriteString("1=1")
+			} else {
+				sb.WriteString("1=0")
+			}
+			i += end[1]
+			continue
+		}
+		if maxInList > 0 && len(items) > maxInList {
+			prefix := sb.String()
+			m := inListPrefix.FindStringSubmatchIndex(prefix)
+			end := inListSuffix.FindStringIndex(q[i+1:])
+			if m != nil && end != nil {
+				expr := prefix[m[2]:m[3]]
+				op, join := " IN (", " OR "
+				if m[4] >= 0 {
+					op, join = " NOT IN (", " AND "
+				}
+				sb.Reset()
+				sb.WriteString(prefix[:m[0]])
+				sb.WriteString("(")
+				for k, chunk := range slices.Collect(slices.Chunk(items, maxInList)) {
+					if k > 0 {
+						sb.WriteString(join)
+					}
+					sb.WriteString(expr + op)
+					out = append(out, writeHolders(&sb, chunk)...)
+					sb.WriteString(")")
+				}
+				sb.WriteString(")")
+				i += end[1]
+				continue
+			}
+		}
+		out = append(out, writeHolders(&sb, items)...)
+	}
+	return sb.String(), out, true, nil
+}
+
+// quotedEnd 位置 i 为字符串、引号标识符或注释的开头时返回其结束位置（不含），否则返回 -1
+// 连续两个引号的转义按两段相邻的字符串处理，结果相同
+func quotedEnd(q string, i int) int {
+	c := q[i]
+	switch {
+	case c == '\'' || c == '"' || c == '`':
+		if j := strings.IndexByte(q[i+1:], c); j >= 0 {
+			return i + j + 2
+		}
+		return len(q)
+	case c == '-' && i+1 < len(q) && q[i+1] == '-':
+		if j := strings.IndexByte(q[i:], '\n'); j >= 0 {
+			return i + j
+		}
+		return len(q)
+	case c == '/' && i+1 < len(q) && q[i+1] == '*':
+		if j := strings.Index(q[i+2:], "*/"); j >= 0 {
+			return i + j + 4
+		}
+		return len(q)
+	}
+	return -1
+}
+
+func (d *DBCli) maxInListSize() int {
+	if sqlFunc, ok := GetSqlDialect(d.dbtype); ok {
+		if l, ok := sqlFunc.(InListLimiter); ok {
+			return l.MaxInListSize()
+		}
+	}
+	return 0
+}
+
+// expandNamed 展开切片参数并转换为当前驱动的占位符，没有切片参数时 ok 为 false，由 sqlx 按原方式执行
+func (d *DBCli) expandNamed(query string, data interface{}) (string, []interface{}, bool, error) {
+	if data == nil {
+		return query, nil, false, nil
+	}
+	q, args, ok, err := ExpandNamedQuery(query, data, d.maxInListSize())
+	if err != nil || !ok {
+		return query, nil, false, err
+	}
+	return d.Rebind(q), args, true, nil
+}
+
+func (d *DBCli) namedQuery(query string, data interface{}) (*sqlx.Rows, error) {
+	q, args, ok, err := d.expandNamed(query, data)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return d.oper().NamedQuery(query, data)
+	}
+	DBLog().Debug("expanded named sql", "sql", q, "args", args)
+	return d.oper().Queryx(q, args...)
+}
+
+func (d *DBCli) namedExec(query string, data interface{}) (sql.Result, error) {
+	q, args, ok, err := d.expandNamed(query, data)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return d.oper().NamedExec(query, data)
+	}
+	DBLog().Debug("expanded named sql", "sql", q, "args", args)
+	return d.oper().Exec(q, args...)
+}
+
+// positionalToNamed 将 ? 占位符改写为 :argN 命名参数，引号内的冒号转义为 ::，用于需要经过 preProcess 的位置参数 SQL
+func positionalToNamed(query string, args []interface{}) (string, map[string]interface{}, error) {
+	var sb strings.Builder
+	data := make(map[string]interface{}, len(args))
+	var quote byte
+	n := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == ':' {
+				// 命名参数绑定时 :: 还原为 :
+				sb.WriteByte(':')
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			if n >= len(args) {
+				return "", nil, fmt.Errorf("not enough arguments for placeholders: %s", query)
+			}
+			n++
+			name := fmt.Sprintf("arg%d", n)
+			data[name] = args[n-1]
+			sb.WriteString(":" + name)
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	if n != len(args) {
+		return "", nil, fmt.Errorf("expected %d arguments, got %d: %s", n, len(args), query)
+	}
+	return sb.String(), data, nil
+}
@@ -0,0 +1,369 @@
+package cydb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fj1981/infrakit/pkg/cylog"
+	"github.com/fj1981/infrakit/pkg/cyutil"
+)
+
+// DefaultOutboxTable 发件箱默认表名
+const DefaultOutboxTable = "cy_outbox"
+
+// 发件箱记录状态
+const (
+	OutboxPending = 0
+	OutboxSent    = 1
+	// OutboxDead 超过最大投递次数，不再重试
+	OutboxDead = 2
+)
+
+// OutboxPublisher 发件箱的投递目标，cydist 的 RedisPublisher、LocalPublisher、Broadcaster 均满足该接口
+type OutboxPublisher interface {
+	PublishSimple(channel string, payload interface{}) error
+}
+
+// OutboxLocker 分布式锁，保证多个实例中同一时刻只有一个 relay 在投递
+// 未获取到锁时返回 ok=false，cydist.DBOutboxLocker 基于 DistLockManager 实现
+type OutboxLocker interface {
+	TryLock(ctx context.Context, key string) (unlock func(), ok bool)
+}
+
+// OutboxEvent 发件箱中的一条事件
+type OutboxEvent struct {
+	ID        string
+	Topic     string
+	Payload   json.RawMessage
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// 各方言的建表语句，时间统一存 unix 毫秒
+var outboxDDL = map[string][]string{
+	"mysql": {
+		"CREATE TABLE %[1]s (id VARCHAR(64) NOT NULL PRIMARY KEY, topic VARCHAR(255) NOT NULL, payload LONGTEXT, status INT NOT NULL DEFAULT 0, attempts INT NOT NULL DEFAULT 0, next_attempt_at BIGINT NOT NULL, last_error VARCHAR(1000), enqueued_at BIGINT NOT NULL, sent_at BIGINT)",
+		"CREATE INDEX idx_%[1]s_pending ON %[1]s (status, next_attempt_at)",
+	},
+	"postgresql": {
+		"CREATE TABLE %[1]s (id VARCHAR(64) NOT NULL PRIMARY KEY, topic VARCHAR(255) NOT NULL, payload TEXT, status INT NOT NULL DEFAULT 0, attempts INT NOT NULL DEFAULT 0, next_attempt_at BIGINT NOT NULL, last_error VARCHAR(1000), enqueued_at BIGINT NOT NULL, sent_at BIGINT)",
+		"CREATE INDEX idx_%[1]s_pending ON %[1]s (status, next_attempt_at)",
+	},
+	"oracle": {
+		"CREATE TABLE %[1]s (id VARCHAR2(64) NOT NULL PRIMARY KEY, topic VARCHAR2(255) NOT NULL, payload CLOB, status NUMBER(3) DEFAULT 0 NOT NULL, attempts NUMBER(10) DEFAULT 0 NOT NULL, next_attempt_at NUMBER(19) NOT NULL, last_error VARCHAR2(1000), enqueued_at NUMBER(19) NOT NULL, sent_at NUMBER(19))",
+		"CREATE INDEX idx_%[1]s_pending ON %[1]s (status, next_attempt_at)",
+	},
+	"sqlite": {
+		"CREATE TABLE %[1]s (id VARCHAR(64) NOT NULL PRIMARY KEY, topic VARCHAR(255) NOT NULL, payload TEXT, status INTEGER NOT NULL DEFAULT 0, attempts INTEGER NOT NULL DEFAULT 0, next_attempt_at INTEGER NOT NULL, last_error VARCHAR(1000), enqueued_at INTEGER NOT NULL, sent_at INTEGER)",
+		"CREATE INDEX idx_%[1]s_pending ON %[1]s (status, next_attempt_at)",
+	},
+}
+
+// EnsureOutbox 发件箱表不存在时按方言建表，table 为空时使用 DefaultOutboxTable
+// 部分数据库的 DDL 会隐式提交事务，需在事务外调用
+func (d *DBCli) EnsureOutbox(table string) error {
+	if table == "" {
+		table = DefaultOutboxTable
+	}
+	return d.ensureTable(table, outboxDDL)
+}
+
+// ensureTable 表不存在时执行 ddl 中当前方言的建表语句，语句中的 %[1]s 为表名
+func (d *DBCli) ensureTable(table string, ddl map[string][]string) error {
+	if ok, err := d.IsTableExist(table); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+	stmts, ok := ddl[d.dbtype]
+	if !ok {
+		return errors.New("not support db type: " + d.dbtype)
+	}
+	for _, stmt := range stmts {
+		if _, err := d.excute(fmt.Sprintf(stmt, table)); err != nil {
+			return fmt.Errorf("create table %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// Enqueue 向默认发件箱写入一条事件，在 WithTransaction 中调用时与业务写入一同提交或回滚
+func (d *DBCli) Enqueue(topic string, payload interface{}) (string, error) {
+	return d.EnqueueTo(DefaultOutboxTable, topic, payload)
+}
+
+// EnqueueTo 向指定发件箱表写入一条事件，返回事件 ID；payload 按 JSON 序列化保存
+func (d *DBCli) EnqueueTo(table, topic string, payload interface{}) (string, error) {
+	if topic == "" {
+		return "", errors.New("outbox topic cannot be empty")
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("marshal outbox payload: %w", err)
+	}
+	id := cyutil.NanoID()
+	now := time.Now().UnixMilli()
+	_, err = d.Insert(table, map[string]interface{}{
+		"id":              id,
+		"topic":           topic,
+		"payload":         string(data),
+		"status":          OutboxPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+		"enqueued_at":     now,
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// OutboxOptions relay 配置
+type OutboxOptions struct {
+	// Table 发件箱表名，默认 DefaultOutboxTable
+	Table string
+	// Interval 轮询间隔
+	Interval time.Duration
+	// BatchSize 每轮最多投递的事件数
+	BatchSize int
+	// Retry 投递失败后的退避策略，MaxAttempts 为最大投递次数，超过后标记为 OutboxDead
+	Retry *RetryOptions
+	// Locker 为空时不加锁，多实例部署时需要设置
+	Locker  OutboxLocker
+	LockKey string
+	// OnError 投递失败回调
+	OnError func(event *OutboxEvent, err error)
+}
+
+type OutboxOption func(*OutboxOptions)
+
+func DefaultOutboxOptions() *OutboxOptions {
+	return &OutboxOptions{
+		Table:     DefaultOutboxTable,
+		Interval:  time.Second,
+		BatchSize: 100,
+		Retry: &RetryOptions{
+			MaxAttempts:    10,
+			InitialBackoff: time.Second,
+			MaxBackoff:     5 * time.Minute,
+			Multiplier:     2,
+			Jitter:         0.2,
+		},
+	}
+}
+
+func WithOutboxTable(table string) OutboxOption {
+	return func(o *OutboxOptions) { o.Table = table }
+}
+
+func WithOutboxInterval(interval time.Duration) OutboxOption {
+	return func(o *OutboxOptions) { o.Interval = interval }
+}
+
+func WithOutboxBatchSize(n int) OutboxOption {
+	return func(o *OutboxOptions) { o.BatchSize = n }
+}
+
+// WithOutboxRetry 调整投递失败后的重试次数与退避
+func WithOutboxRetry(opts ...RetryOption) OutboxOption {
+	return func(o *OutboxOptions) {
+		for _, opt := range opts {
+			opt(o.Retry)
+		}
+	}
+}
+
+// WithOutboxLocker 设置分布式锁，key 为空时使用 "outbox:relay:<表名>"
+func WithOutboxLocker(locker OutboxLocker, key ...string) OutboxOption {
+	return func(o *OutboxOptions) {
+		o.Locker = locker
+		if len(key) > 0 {
+			o.LockKey = key[0]
+		}
+	}
+}
+
+func WithOutboxErrorHandler(fn func(event *OutboxEvent, err error)) OutboxOption {
+	return func(o *OutboxOptions) { o.OnError = fn }
+}
+
+// OutboxRelay 轮询发件箱表并投递到 OutboxPublisher
+// 投递成功后才标记为已发送，进程崩溃时事件可能重复投递，消费方需要幂等
+type OutboxRelay struct {
+	cli  *DBCli
+	pub  OutboxPublisher
+	opts *OutboxOptions
+
+	mu     sync.Mutex
+	stop   chan struct{}
+	done   chan struct{}
+	notify chan struct{}
+}
+
+// NewOutboxRelay 创建 relay，发件箱表不存在时自动创建
+func NewOutboxRelay(cli *DBCli, pub OutboxPublisher, opts ...OutboxOption) (*OutboxRelay, error) {
+	if cli == nil || pub == nil {
+		return nil, errors.New("outbox relay requires db client and publisher")
+	}
+	o := DefaultOutboxOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Table == "" {
+		o.Table = DefaultOutboxTable
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.Interval <= 0 {
+		o.Interval = time.Second
+	}
+	if o.LockKey == "" {
+		o.LockKey = "outbox:relay:" + o.Table
+	}
+	if err := cli.EnsureOutbox(o.Table); err != nil {
+		return nil, err
+	}
+	return &OutboxRelay{cli: cli, pub: pub, opts: o, notify: make(chan struct{}, 1)}, nil
+}
+
+// Start 启动后台轮询，重复调用无效
+func (r *OutboxRelay) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop != nil {
+		return
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	r.stop, r.done = stop, done
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(r.opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			case <-r.notify:
+			}
+			if _, err := r.RelayOnce(context.Background()); err != nil {
+				cylog.Warnf("outbox relay %s: %v", r.opts.Table, err)
+			}
+		}
+	}()
+}
+
+// Stop 停止后台轮询并等待正在进行的投递结束
+func (r *OutboxRelay) Stop() {
+	r.mu.Lock()
+	stop, done := r.stop, r.done
+	r.stop, r.done = nil, nil
+	r.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// Notify 唤醒后台轮询立即投递一轮，通常在事务提交后调用以降低延迟
+func (r *OutboxRelay) Notify() {
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+// RelayOnce 投递一轮到期的待发送事件，返回成功投递的数量
+// 设置了 Locker 且锁被其他实例持有时直接返回 0
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	if r.opts.Locker != nil {
+		unlock, ok := r.opts.Locker.TryLock(ctx, r.opts.LockKey)
+		if !ok {
+			return 0, nil
+		}
+		defer unlock()
+	}
+	events, err := r.pending()
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, ev := range events {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		if err := r.pub.PublishSimple(ev.Topic, ev.Payload); err != nil {
+			if r.opts.OnError != nil {
+				r.opts.OnError(ev, err)
+			}
+			if ferr := r.markFailed(ev, err); ferr != nil {
+				return sent, ferr
+			}
+			continue
+		}
+		if err := r.markSent(ev); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func (r *OutboxRelay) pending() ([]*OutboxEvent, error) {
+	sql := fmt.Sprintf("SELECT id, topic, payload, attempts, enqueued_at FROM %s WHERE status = :status AND next_attempt_at <= :now ORDER BY enqueued_at, id LIMIT %d", r.opts.Table, r.opts.BatchSize)
+	rows, err := r.cli.NQuery(sql, map[string]interface{}{"status": OutboxPending, "now": time.Now().UnixMilli()})
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*OutboxEvent, 0, len(rows))
+	for _, row := range rows {
+		payload := cyutil.GetStr(row, "payload", true)
+		if !json.Valid([]byte(payload)) {
+			payload = "null"
+		}
+		events = append(events, &OutboxEvent{
+			ID:        cyutil.GetStr(row, "id", true),
+			Topic:     cyutil.GetStr(row, "topic", true),
+			Payload:   json.RawMessage(payload),
+			Attempts:  cyutil.GetInt(row, "attempts", true),
+			CreatedAt: time.UnixMilli(int64(cyutil.GetInt(row, "enqueued_at", true))),
+		})
+	}
+	return events, nil
+}
+
+func (r *OutboxRelay) markSent(ev *OutboxEvent) error {
+	_, err := r.cli.NExcute(fmt.Sprintf("UPDATE %s SET status = :status, attempts = :attempts, sent_at = :now WHERE id = :id", r.opts.Table), map[string]interface{}{
+		"status":   OutboxSent,
+		"attempts": ev.Attempts + 1,
+		"now":      time.Now().UnixMilli(),
+		"id":       ev.ID,
+	})
+	return err
+}
+
+func (r *OutboxRelay) markFailed(ev *OutboxEvent, cause error) error {
+	attempts := ev.Attempts + 1
+	status := OutboxPending
+	if attempts >= max(r.opts.Retry.MaxAttempts, 1) {
+		status = OutboxDead
+	}
+	msg := cause.Error()
+	if len(msg) > 1000 {
+		msg = msg[:1000]
+	}
+	_, err := r.cli.NExcute(fmt.Sprintf("UPDATE %s SET status = :status, attempts = :attempts, next_attempt_at = :next, last_error = :err WHERE id = :id", r.opts.Table), map[string]interface{}{
+		"status":   status,
+		"attempts": attempts,
+		"next":     time.Now().Add(r.opts.Retry.backoff(attempts)).UnixMilli(),
+		"err":      strings.ToValidUTF8(msg, ""),
+		"id":       ev.ID,
+	})
+	return err
+}
@@ -0,0 +1,270 @@
+package cydb
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// QueryCache 查询结果缓存，tags 为查询涉及的表，写入这些表时通过 Invalidate 清理
+// 进程内可使用 NewLocalQueryCache，分布式场景可使用 cydist.NewDBQueryCache
+// Get 返回的行会交给调用方修改，实现不能返回内部保存的同一份数据
+type QueryCache interface {
+	Get(ctx context.Context, key string) ([]map[string]interface{}, bool)
+	Set(ctx context.Context, key string, tags []string, rows []map[string]interface{}, ttl time.Duration) error
+	Invalidate(ctx context.Context, tags ...string) error
+}
+
+type queryCacheConf struct {
+	cache QueryCache
+	ttl   time.Duration
+	ns    string
+}
+
+func (c *queryCacheConf) tags(tables []string) []string {
+	r := make([]string, 0, len(tables))
+	for _, t := range tables {
+		r = append(r, c.ns+":"+t)
+	}
+	return r
+}
+
+// SetQueryCache 为当前 DBCli 开启查询缓存，List/First/Count/ListWithPage 的结果会按 SQL 与参数缓存
+// 通过 Insert/Update/Delete/Upsert/Replace 等方法写入时自动清理相关表的缓存；c 为 nil 时关闭缓存
+func (d *DBCli) SetQueryCache(c QueryCache, ttl time.Duration) {
+	if c == nil {
+		d.qc = nil
+		return
+	}
+	d.qc = &queryCacheConf{cache: c, ttl: ttl, ns: d.key}
+}
+
+// NoCache 返回一个不使用查询缓存读取的 DBCli 副本，写入仍会清理缓存
+func (d *DBCli) NoCache() *DBCli {
+	cp := *d
+	cp.noCacheRead = true
+	return &cp
+}
+
+// InvalidateQueryCache 清理指定表相关的查询缓存，用于通过 Excute 等原生 SQL 写入之后
+func (d *DBCli) InvalidateQueryCache(tables ...string) error {
+	r := make([]string, 0, len(tables))
+	for _, t := range tables {
+		r = append(r, normalTableTag(t))
+	}
+	return d.invalidateTables(r)
+}
+
+func (d *DBCli) invalidateTables(tables []string) error {
+	if d.qc == nil || len(tables) == 0 {
+		return nil
+	}
+	if d.InTransaction() {
+		// 事务中的写入在提交后才对其他连接可见，提交时再统一清理
+		if d.txTables == nil {
+			d.txTables = map[string]struct{}{}
+		}
+		for _, t := range tables {
+			d.txTables[t] = struct{}{}
+		}
+	}
+	return d.qc.cache.Invalidate(context.Background(), d.qc.tags(tables)...)
+}
+
+func (d *DBCli) flushTxTables() {
+	if d.qc == nil || len(d.txTables) == 0 {
+		return
+	}
+	tables := make([]string, 0, len(d.txTables))
+	for t := range d.txTables {
+		tables = append(tables, t)
+	}
+	d.txTables = nil
+	if err := d.qc.cache.Invalidate(context.Background(), d.qc.tags(tables)...); err != nil {
+		DBLog().Warn("invalidate query cache failed", "tables", tables, "err", err)
+	}
+}
+
+func (d *DBCli) invalidateBuilder(builder SQLBuilder) {
+	if d.qc == nil {
+		return
+	}
+	if err := d.invalidateTables(BuilderTables(builder)); err != nil {
+		DBLog().Warn("invalidate query cache failed", "err", err)
+	}
+}
+
+func (d *DBCli) cacheable() bool {
+	return d.qc != nil && !d.noCacheRead && !d.InTransaction() && d.vm == nil
+}
+
+func queryCacheKey(ns string, sql string, data interface{}) string {
+	b, err := json.Marshal(data)
+	if err != nil {
+		b = []byte(fmt.Sprintf("%v", data))
+	}
+	h := sha1.New()
+	h.Write([]byte(sql))
+	h.Write([]byte{0})
+	h.Write(b)
+	return "qc:" + ns + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedQuery 读取缓存，未命中时执行 load 并写入缓存
+func (d *DBCli) cachedQuery(builder SQLBuilder, sql string, data interface{}, load func() ([]map[string]interface{}, error)) ([]map[string]interface{}, error) {
+	if !d.cacheable() {
+		return load()
+	}
+	ctx := context.Background()
+	key := queryCacheKey(d.qc.ns, sql, data)
+	if rows, ok := d.qc.cache.Get(ctx, key); ok {
+		return rows, nil
+	}
+	rows, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.qc.cache.Set(ctx, key, d.qc.tags(BuilderTables(builder)), rows, d.qc.ttl); err != nil {
+		DBLog().Warn("set query cache failed", "sql", sql, "err", err)
+	}
+	return rows, nil
+}
+
+func (d *DBCli) cachedQueryOne(builder SQLBuilder, sql string, data interface{}, load func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	rows, err := d.cachedQuery(builder, sql, data, func() ([]map[string]interface{}, error) {
+		row, err := load()
+		if err != nil || row == nil {
+			return []map[string]interface{}{}, err
+		}
+		return []map[string]interface{}{row}, nil
+	})
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return rows[0], nil
+}
+
+func normalTableTag(name string) string {
+	return strings.ToLower(strings.Trim(name, "`\"[]"))
+}
+
+// BuilderTables 返回构建器涉及的表名（小写、去重），包括 FROM、JOIN 以及子查询中的表
+func BuilderTables(builder SQLBuilder) []string {
+	seen := map[string]struct{}{}
+	var r []string
+	var walk func(b SQLBuilder)
+	var walkSrc func(ts TableSource)
+	walkSrc = func(ts TableSource) {
+		switch v := ts.(type) {
+		case *Table:
+			name := normalTableTag(v.Name)
+			if name == "" {
+				return
+			}
+			if _, ok := seen[name]; !ok {
+				seen[name] = struct{}{}
+				r = append(r, name)
+			}
+		case *SubQuery:
+			walk(v.Builder)
+		}
+	}
+	walk = func(b SQLBuilder) {
+		qb, ok := b.(*sqlBuilder)
+		if !ok || qb == nil {
+			return
+		}
+		walkSrc(qb.table)
+		walkSrc(qb.from)
+		for _, t := range qb.delTables {
+			walkSrc(t)
+		}
+		for _, j := range qb.joins {
+			walkSrc(j.table)
+		}
+		if qb.subQueryData != nil {
+			walk(qb.subQueryData)
+		}
+	}
+	walk(builder)
+	return r
+}
+
+// cloneRows 复制结果集，调用方修改返回的行不影响缓存中的数据
+func cloneRows(rows []map[string]interface{}) []map[string]interface{} {
+	r := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		r[i] = maps.Clone(row)
+		for k, v := range r[i] {
+			if b, ok := v.([]byte); ok {
+				r[i][k] = bytes.Clone(b)
+			}
+		}
+	}
+	return r
+}
+
+type localQueryCache struct {
+	ch   *cache.Cache
+	lock sync.Mutex
+	tags map[string]map[string]struct{}
+}
+
+// NewLocalQueryCache 创建进程内查询缓存，defaultTTL 为未指定 ttl 时的过期时间
+func NewLocalQueryCache(defaultTTL time.Duration) QueryCache {
+	return &localQueryCache{
+		ch:   cache.New(defaultTTL, 2*defaultTTL),
+		tags: map[string]map[string]struct{}{},
+	}
+}
+
+func (c *localQueryCache) Get(_ context.Context, key string) ([]map[string]interface{}, bool) {
+	v, ok := c.ch.Get(key)
+	if !ok {
+		return nil, false
+	}
+	rows, ok := v.([]map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return cloneRows(rows), true
+}
+
+func (c *localQueryCache) Set(_ context.Context, key string, tags []string, rows []map[string]interface{}, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = cache.DefaultExpiration
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, tag := range tags {
+		keys, ok := c.tags[tag]
+		if !ok {
+			keys = map[string]struct{}{}
+			c.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	c.ch.Set(key, cloneRows(rows), ttl)
+	return nil
+}
+
+func (c *localQueryCache) Invalidate(_ context.Context, tags ...string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			c.ch.Delete(key)
+		}
+		delete(c.tags, tag)
+	}
+	return nil
+}
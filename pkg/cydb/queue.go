@@ -0,0 +1,541 @@
+package cydb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fj1981/infrakit/pkg/cylog"
+	"github.com/fj1981/infrakit/pkg/cyutil"
+)
+
+// SkipLocker 由方言可选实现，为领取任务的查询追加 FOR UPDATE SKIP LOCKED
+// limitInSQL 为 false 时语句本身不限制行数，由调用方读取 limit 行后停止
+// 未实现的方言（如 SQLite）在进程内串行领取与确认
+type SkipLocker interface {
+	SkipLockedSQL(query string, limit int) (sql string, limitInSQL bool)
+}
+
+// TaskHandler 任务处理接口，与 cydist.TaskHandler 方法一致，cydist.HandlerFunc 可直接使用
+type TaskHandler interface {
+	ProcessTask(ctx context.Context, payload []byte) error
+}
+
+// TaskHandlerFunc 函数形式的 TaskHandler
+type TaskHandlerFunc func(ctx context.Context, payload []byte) error
+
+func (f TaskHandlerFunc) ProcessTask(ctx context.Context, payload []byte) error {
+	return f(ctx, payload)
+}
+
+// DefaultJobTable 任务队列默认表名
+const DefaultJobTable = "cy_jobs"
+
+// 任务状态
+const (
+	JobReady   = 0
+	JobRunning = 1
+	JobDone    = 2
+	// JobDead 超过最大执行次数，进入死信
+	JobDead = 3
+)
+
+// ErrJobLeaseLost 任务的租约已过期并被其他消费者领取，Ack/Nack 不再生效
+var ErrJobLeaseLost = errors.New("job lease lost")
+
+var jobDDL = map[string][]string{
+	"mysql": {
+		"CREATE TABLE %[1]s (id VARCHAR(64) NOT NULL PRIMARY KEY, queue VARCHAR(128) NOT NULL, job_type VARCHAR(255) NOT NULL, payload LONGTEXT, priority INT NOT NULL DEFAULT 0, status INT NOT NULL DEFAULT 0, attempts INT NOT NULL DEFAULT 0, max_attempts INT NOT NULL, run_at BIGINT NOT NULL, locked_until BIGINT, locked_by VARCHAR(64), last_error VARCHAR(1000), enqueued_at BIGINT NOT NULL, finished_at BIGINT)",
+		"CREATE INDEX idx_%[1]s_claim ON %[1]s (queue, status, run_at)",
+	},
+	"postgresql": {
+		"CREATE TABLE %[1]s (id VARCHAR(64) NOT NULL PRIMARY KEY, queue VARCHAR(128) NOT NULL, job_type VARCHAR(255) NOT NULL, payload TEXT, priority INT NOT NULL DEFAULT 0, status INT NOT NULL DEFAULT 0, attempts INT NOT NULL DEFAULT 0, max_attempts INT NOT NULL, run_at BIGINT NOT NULL, locked_until BIGINT, locked_by VARCHAR(64), last_error VARCHAR(1000), enqueued_at BIGINT NOT NULL, finished_at BIGINT)",
+		"CREATE INDEX idx_%[1]s_claim ON %[1]s (queue, status, run_at)",
+	},
+	"oracle": {
+		"CREATE TABLE %[1]s (id VARCHAR2(64) NOT NULL PRIMARY KEY, queue VARCHAR2(128) NOT NULL, job_type VARCHAR2(255) NOT NULL, payload CLOB, priority NUMBER(10) DEFAULT 0 NOT NULL, status NUMBER(3) DEFAULT 0 NOT NULL, attempts NUMBER(10) DEFAULT 0 NOT NULL, max_attempts NUMBER(10) NOT NULL, run_at NUMBER(19) NOT NULL, locked_until NUMBER(19), locked_by VARCHAR2(64), last_error VARCHAR2(1000), enqueued_at NUMBER(19) NOT NULL, finished_at NUMBER(19))",
+		"CREATE INDEX idx_%[1]s_claim ON %[1]s (queue, status, run_at)",
+	},
+	"sqlite": {
+		"CREATE TABLE %[1]s (id VARCHAR(64) NOT NULL PRIMARY KEY, queue VARCHAR(128) NOT NULL, job_type VARCHAR(255) NOT NULL, payload TEXT, priority INTEGER NOT NULL DEFAULT 0, status INTEGER NOT NULL DEFAULT 0, attempts INTEGER NOT NULL DEFAULT 0, max_attempts INTEGER NOT NULL, run_at INTEGER NOT NULL, locked_until INTEGER, locked_by VARCHAR(64), last_error VARCHAR(1000), enqueued_at INTEGER NOT NULL, finished_at INTEGER)",
+		"CREATE INDEX idx_%[1]s_claim ON %[1]s (queue, status, run_at)",
+	},
+}
+
+// Job 一条已领取的任务
+type Job struct {
+	ID          string
+	Queue       string
+	Type        string
+	Payload     []byte
+	Priority    int
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	// LockedUntil 租约到期时间，到期前未 Ack/Nack 的任务会被重新领取
+	LockedUntil time.Time
+	LastError   string
+
+	token string
+}
+
+// JobQueueOptions 队列配置
+type JobQueueOptions struct {
+	Table string
+	// Visibility 领取后的租约时长
+	Visibility time.Duration
+	// Retry Nack 后的退避策略，MaxAttempts 为入队时未指定时的默认最大执行次数
+	Retry *RetryOptions
+}
+
+type JobQueueOption func(*JobQueueOptions)
+
+func DefaultJobQueueOptions() *JobQueueOptions {
+	return &JobQueueOptions{
+		Table:      DefaultJobTable,
+		Visibility: 30 * time.Second,
+		Retry: &RetryOptions{
+			MaxAttempts:    5,
+			InitialBackoff: time.Second,
+			MaxBackoff:     10 * time.Minute,
+			Multiplier:     2,
+			Jitter:         0.2,
+		},
+	}
+}
+
+func WithJobTable(table string) JobQueueOption {
+	return func(o *JobQueueOptions) { o.Table = table }
+}
+
+func WithJobVisibility(d time.Duration) JobQueueOption {
+	return func(o *JobQueueOptions) { o.Visibility = d }
+}
+
+// WithJobRetry 调整默认最大执行次数与 Nack 退避
+func WithJobRetry(opts ...RetryOption) JobQueueOption {
+	return func(o *JobQueueOptions) {
+		for _, opt := range opts {
+			opt(o.Retry)
+		}
+	}
+}
+
+// JobQueue 基于数据库表的持久化任务队列
+// MySQL 8、PostgreSQL、Oracle 使用 SELECT ... FOR UPDATE SKIP LOCKED 支持多消费者并发领取
+type JobQueue struct {
+	cli  *DBCli
+	name string
+	opts *JobQueueOptions
+	// writeMu 方言不支持 SKIP LOCKED 时，领取与 Ack/Nack 在进程内串行执行（单写者）
+	writeMu *sync.Mutex
+}
+
+// NewJobQueue 创建名为 name 的队列，任务表不存在时自动创建；多个队列可共用一张表
+func NewJobQueue(cli *DBCli, name string, opts ...JobQueueOption) (*JobQueue, error) {
+	if cli == nil || name == "" {
+		return nil, errors.New("job queue requires db client and name")
+	}
+	o := DefaultJobQueueOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Table == "" {
+		o.Table = DefaultJobTable
+	}
+	if o.Visibility <= 0 {
+		o.Visibility = 30 * time.Second
+	}
+	if err := cli.ensureTable(o.Table, jobDDL); err != nil {
+		return nil, err
+	}
+	return &JobQueue{cli: cli, name: name, opts: o, writeMu: &sync.Mutex{}}, nil
+}
+
+// Tx 返回绑定到事务 tx 的队列副本，入队与业务写入一同提交或回滚
+func (q *JobQueue) Tx(tx *DBCli) *JobQueue {
+	cp := *q
+	cp.cli = tx
+	return &cp
+}
+
+// EnqueueOption 单个任务的入队参数
+type EnqueueOption func(*enqueueConf)
+
+type enqueueConf struct {
+	priority    int
+	runAt       time.Time
+	maxAttempts int
+}
+
+// WithJobPriority 优先级，数值越大越先执行
+func WithJobPriority(p int) EnqueueOption {
+	return func(c *enqueueConf) { c.priority = p }
+}
+
+// WithJobRunAt 最早执行时间
+func WithJobRunAt(t time.Time) EnqueueOption {
+	return func(c *enqueueConf) { c.runAt = t }
+}
+
+// WithJobDelay 延迟执行
+func WithJobDelay(d time.Duration) EnqueueOption {
+	return func(c *enqueueConf) { c.runAt = time.Now().Add(d) }
+}
+
+// WithJobMaxAttempts 最大执行次数，超过后进入死信
+func WithJobMaxAttempts(n int) EnqueueOption {
+	return func(c *enqueueConf) { c.maxAttempts = n }
+}
+
+// Enqueue 入队一个任务，jobType 用于匹配 JobConsumer 中注册的处理器
+// payload 为 []byte 时原样保存，其他类型按 JSON 序列化
+func (q *JobQueue) Enqueue(jobType string, payload interface{}, opts ...EnqueueOption) (string, error) {
+	if jobType == "" {
+		return "", errors.New("job type cannot be empty")
+	}
+	c := &enqueueConf{runAt: time.Now(), maxAttempts: q.opts.Retry.MaxAttempts}
+	for _, opt := range opts {
+		opt(c)
+	}
+	var data []byte
+	switch v := payload.(type) {
+	case []byte:
+		data = v
+	default:
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return "", fmt.Errorf("marshal job payload: %w", err)
+		}
+		data = b
+	}
+	if _, ok := skipLockerOf(q.cli.dbtype); !ok && !q.cli.InTransaction() {
+		q.writeMu.Lock()
+		defer q.writeMu.Unlock()
+	}
+	id := cyutil.NanoID()
+	_, err := q.cli.Insert(q.opts.Table, map[string]interface{}{
+		"id":           id,
+		"queue":        q.name,
+		"job_type":     jobType,
+		"payload":      string(data),
+		"priority":     c.priority,
+		"status":       JobReady,
+		"attempts":     0,
+		"max_attempts": max(c.maxAttempts, 1),
+		"run_at":       c.runAt.UnixMilli(),
+		"enqueued_at":  time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Dequeue 领取最多 limit 个到期任务，领取后租约时长为 Visibility
+// 处于执行中但租约已过期的任务会被重新领取；已用完执行次数的过期任务直接进入死信
+func (q *JobQueue) Dequeue(limit int) ([]*Job, error) {
+	if limit <= 0 {
+		limit = 1
+	}
+	locker, ok := skipLockerOf(q.cli.dbtype)
+	if !ok {
+		q.writeMu.Lock()
+		defer q.writeMu.Unlock()
+	}
+	var jobs []*Job
+	err := q.cli.WithTransaction(func(tx *DBCli) error {
+		now := time.Now()
+		rows, err := q.claimable(tx, locker, limit, now.UnixMilli())
+		if err != nil {
+			return err
+		}
+		token := cyutil.NanoID()
+		lockedUntil := now.Add(q.opts.Visibility)
+		// 更新时再次确认任务仍可领取，并发领取同一任务时只有一方的更新生效，影响 0 行的一方跳过该任务
+		claimable := " WHERE id = :id AND ((status = :ready AND run_at <= :now) OR (status = :running AND locked_until <= :now))"
+		for _, row := range rows {
+			job := jobFromRow(row)
+			if job.Attempts >= job.MaxAttempts {
+				// 上一次执行的进程在租约内退出且次数已用完
+				if _, err := tx.nExcute(fmt.Sprintf("UPDATE %s SET status = :status, locked_by = NULL, finished_at = :now, last_error = :err", q.opts.Table)+claimable, map[string]interface{}{
+					"status": JobDead, "now": now.UnixMilli(), "err": "lease expired", "id": job.ID, "ready": JobReady, "running": JobRunning,
+				}); err != nil {
+					return err
+				}
+				continue
+			}
+			n, err := tx.nExcute(fmt.Sprintf("UPDATE %s SET status = :status, attempts = :attempts, locked_until = :until, locked_by = :token", q.opts.Table)+claimable, map[string]interface{}{
+				"status": JobRunning, "attempts": job.Attempts + 1, "until": lockedUntil.UnixMilli(), "token": token, "id": job.ID,
+				"ready": JobReady, "running": JobRunning, "now": now.UnixMilli(),
+			})
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				continue
+			}
+			job.Attempts++
+			job.LockedUntil = lockedUntil
+			job.token = token
+			jobs = append(jobs, job)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func skipLockerOf(dbtype string) (SkipLocker, bool) {
+	if sqlFunc, ok := GetSqlDialect(dbtype); ok {
+		l, ok := sqlFunc.(SkipLocker)
+		return l, ok
+	}
+	return nil, false
+}
+
+func (q *JobQueue) claimable(tx *DBCli, locker SkipLocker, limit int, now int64) ([]map[string]interface{}, error) {
+	query := fmt.Sprintf("SELECT id, queue, job_type, payload, priority, attempts, max_attempts, run_at, last_error FROM %s WHERE queue = :queue AND ((status = :ready AND run_at <= :now) OR (status = :running AND locked_until <= :now)) ORDER BY priority DESC, run_at, id", q.opts.Table)
+	limitInSQL := false
+	if locker != nil {
+		query, limitInSQL = locker.SkipLockedSQL(query, limit)
+	} else {
+		query, limitInSQL = fmt.Sprintf("%s LIMIT %d", query, limit), true
+	}
+	rows, err := tx.namedQuery(query, map[string]interface{}{"queue": q.name, "ready": JobReady, "running": JobRunning, "now": now})
+	if err != nil {
+		return nil, fmt.Errorf("claim jobs: %w", err)
+	}
+	defer rows.Close()
+	r := []map[string]interface{}{}
+	for rows.Next() && (limitInSQL || len(r) < limit) {
+		row, err := tx.scanSQLRow(rows, nil)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, row)
+	}
+	return r, rows.Err()
+}
+
+func jobFromRow(row map[string]interface{}) *Job {
+	return &Job{
+		ID:          cyutil.GetStr(row, "id", true),
+		Queue:       cyutil.GetStr(row, "queue", true),
+		Type:        cyutil.GetStr(row, "job_type", true),
+		Payload:     []byte(cyutil.GetStr(row, "payload", true)),
+		Priority:    cyutil.GetInt(row, "priority", true),
+		Attempts:    cyutil.GetInt(row, "attempts", true),
+		MaxAttempts: cyutil.GetInt(row, "max_attempts", true),
+		RunAt:       time.UnixMilli(int64(cyutil.GetInt(row, "run_at", true))),
+		LastError:   cyutil.GetStr(row, "last_error", true),
+	}
+}
+
+// Ack 标记任务完成
+func (q *JobQueue) Ack(job *Job) error {
+	return q.finish(job, "UPDATE %s SET status = :status, locked_by = NULL, finished_at = :now WHERE id = :id AND locked_by = :token AND status = :running", map[string]interface{}{
+		"status": JobDone, "now": time.Now().UnixMilli(),
+	})
+}
+
+// Nack 标记任务失败，未超过最大执行次数时按退避重新排队，否则进入死信
+func (q *JobQueue) Nack(job *Job, cause error) error {
+	msg := "nack"
+	if cause != nil {
+		msg = cause.Error()
+	}
+	if len(msg) > 1000 {
+		msg = msg[:1000]
+	}
+	now := time.Now()
+	if job.Attempts >= job.MaxAttempts {
+		return q.finish(job, "UPDATE %s SET status = :status, locked_by = NULL, finished_at = :now, last_error = :err WHERE id = :id AND locked_by = :token AND status = :running", map[string]interface{}{
+			"status": JobDead, "now": now.UnixMilli(), "err": strings.ToValidUTF8(msg, ""),
+		})
+	}
+	return q.finish(job, "UPDATE %s SET status = :status, locked_by = NULL, run_at = :runAt, last_error = :err WHERE id = :id AND locked_by = :token AND status = :running", map[string]interface{}{
+		"status": JobReady, "runAt": now.Add(q.opts.Retry.backoff(job.Attempts)).UnixMilli(), "err": strings.ToValidUTF8(msg, ""),
+	})
+}
+
+// Extend 延长任务租约，长时间运行的任务需在租约到期前调用
+func (q *JobQueue) Extend(job *Job, d time.Duration) error {
+	until := time.Now().Add(d)
+	if err := q.finish(job, "UPDATE %s SET locked_until = :until WHERE id = :id AND locked_by = :token AND status = :running", map[string]interface{}{
+		"until": until.UnixMilli(),
+	}); err != nil {
+		return err
+	}
+	job.LockedUntil = until
+	return nil
+}
+
+// finish 以领取时的 token 为条件更新任务，租约被他人接管时返回 ErrJobLeaseLost
+func (q *JobQueue) finish(job *Job, sql string, data map[string]interface{}) error {
+	data["id"] = job.ID
+	data["token"] = job.token
+	data["running"] = JobRunning
+	if _, ok := skipLockerOf(q.cli.dbtype); !ok {
+		q.writeMu.Lock()
+		defer q.writeMu.Unlock()
+	}
+	n, err := q.cli.nExcute(fmt.Sprintf(sql, q.opts.Table), data)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrJobLeaseLost
+	}
+	return nil
+}
+
+// DeadLetters 列出死信任务
+func (q *JobQueue) DeadLetters(limit int) ([]*Job, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := q.cli.NQuery(fmt.Sprintf("SELECT id, queue, job_type, payload, priority, attempts, max_attempts, run_at, last_error FROM %s WHERE queue = :queue AND status = :status ORDER BY finished_at DESC LIMIT %d", q.opts.Table, limit), map[string]interface{}{
+		"queue": q.name, "status": JobDead,
+	})
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*Job, 0, len(rows))
+	for _, row := range rows {
+		jobs = append(jobs, jobFromRow(row))
+	}
+	return jobs, nil
+}
+
+// Requeue 将死信任务重置为待执行，执行次数清零
+func (q *JobQueue) Requeue(id string) error {
+	n, err := q.cli.NExcute(fmt.Sprintf("UPDATE %s SET status = :ready, attempts = 0, run_at = :now, finished_at = NULL WHERE id = :id AND queue = :queue AND status = :dead", q.opts.Table), map[string]interface{}{
+		"ready": JobReady, "now": time.Now().UnixMilli(), "id": id, "queue": q.name, "dead": JobDead,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("dead job %s not found in queue %s", id, q.name)
+	}
+	return nil
+}
+
+// JobConsumer 轮询 JobQueue 并按任务类型分发给 TaskHandler，接口与 cydist.RedisConsumer 一致
+type JobConsumer struct {
+	queue    *JobQueue
+	handlers map[string]TaskHandler
+	workers  int
+	interval time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewJobConsumer workers 为并发处理数，interval 为队列为空时的轮询间隔
+func NewJobConsumer(queue *JobQueue, workers int, interval time.Duration) *JobConsumer {
+	if workers <= 0 {
+		workers = 1
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &JobConsumer{queue: queue, handlers: map[string]TaskHandler{}, workers: workers, interval: interval}
+}
+
+func (c *JobConsumer) RegisterHandler(messageType string, handler TaskHandler) error {
+	c.handlers[messageType] = handler
+	return nil
+}
+
+func (c *JobConsumer) RegisterHandlerFunc(messageType string, handlerFunc func(ctx context.Context, payload []byte) error) error {
+	return c.RegisterHandler(messageType, TaskHandlerFunc(handlerFunc))
+}
+
+// Start 启动消费，需在 Start 之前注册处理器
+func (c *JobConsumer) Start(ctx context.Context) error {
+	if len(c.handlers) == 0 {
+		return fmt.Errorf("no handlers registered")
+	}
+	ctx, c.cancel = context.WithCancel(ctx)
+	for i := 0; i < c.workers; i++ {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.run(ctx)
+		}()
+	}
+	return nil
+}
+
+func (c *JobConsumer) run(ctx context.Context) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		n, err := c.ProcessOnce(ctx)
+		if err != nil {
+			cylog.Warnf("job consumer %s: %v", c.queue.name, err)
+		}
+		if n > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.interval):
+		}
+	}
+}
+
+// ProcessOnce 领取并处理一个任务，返回处理的任务数
+func (c *JobConsumer) ProcessOnce(ctx context.Context) (int, error) {
+	jobs, err := c.queue.Dequeue(1)
+	if err != nil || len(jobs) == 0 {
+		return 0, err
+	}
+	for _, job := range jobs {
+		handler, ok := c.handlers[job.Type]
+		if !ok {
+			err = fmt.Errorf("no handler registered for job type: %s", job.Type)
+		} else {
+			err = c.handle(ctx, handler, job)
+		}
+		if err != nil {
+			if nerr := c.queue.Nack(job, err); nerr != nil {
+				return 0, nerr
+			}
+			continue
+		}
+		if err := c.queue.Ack(job); err != nil {
+			return 0, err
+		}
+	}
+	return len(jobs), nil
+}
+
+func (c *JobConsumer) handle(ctx context.Context, handler TaskHandler, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic occurred: %v", r)
+		}
+	}()
+	ctx, cancel := context.WithDeadline(ctx, job.LockedUntil)
+	defer cancel()
+	return handler.ProcessTask(ctx, job.Payload)
+}
+
+// Shutdown 停止消费并等待处理中的任务结束
+func (c *JobConsumer) Shutdown() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+}
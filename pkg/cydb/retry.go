@@ -0,0 +1,177 @@
+package cydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TransientErrorClassifier 由方言可选实现，用于识别可以整体重试的瞬时错误
+// 例如 MySQL 死锁、PostgreSQL 序列化失败、Oracle ORA-00060、SQLite 忙等待
+type TransientErrorClassifier interface {
+	IsTransientError(err error) bool
+}
+
+// RetryOptions 事务重试配置
+type RetryOptions struct {
+	// MaxAttempts 最大执行次数（包含第一次），小于 1 时按 1 处理
+	MaxAttempts int
+	// InitialBackoff 第一次重试前的等待时间
+	InitialBackoff time.Duration
+	// MaxBackoff 单次等待时间上限
+	MaxBackoff time.Duration
+	// Multiplier 每次重试等待时间的增长倍数
+	Multiplier float64
+	// Jitter 抖动比例（0~1），实际等待时间在 [backoff*(1-Jitter), backoff] 之间随机
+	Jitter float64
+	// Retryable 自定义可重试判断，为空时使用方言识别
+	Retryable func(err error) bool
+	// OnRetry 每次重试前回调，attempt 为即将开始的执行序号
+	OnRetry func(attempt int, err error)
+	// Context 用于取消等待，默认 context.Background()
+	Context context.Context
+}
+
+type RetryOption func(*RetryOptions)
+
+func DefaultRetryOptions() *RetryOptions {
+	return &RetryOptions{
+		MaxAttempts:    3,
+		InitialBackoff: 20 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+		Jitter:         0.5,
+		Context:        context.Background(),
+	}
+}
+
+func WithRetryAttempts(n int) RetryOption {
+	return func(o *RetryOptions) { o.MaxAttempts = n }
+}
+
+func WithRetryBackoff(initial, max time.Duration, multiplier ...float64) RetryOption {
+	return func(o *RetryOptions) {
+		o.InitialBackoff = initial
+		o.MaxBackoff = max
+		if len(multiplier) > 0 {
+			o.Multiplier = multiplier[0]
+		}
+	}
+}
+
+func WithRetryJitter(jitter float64) RetryOption {
+	return func(o *RetryOptions) { o.Jitter = jitter }
+}
+
+func WithRetryCondition(fn func(err error) bool) RetryOption {
+	return func(o *RetryOptions) { o.Retryable = fn }
+}
+
+func WithRetryCallback(fn func(attempt int, err error)) RetryOption {
+	return func(o *RetryOptions) { o.OnRetry = fn }
+}
+
+func WithRetryContext(ctx context.Context) RetryOption {
+	return func(o *RetryOptions) { o.Context = ctx }
+}
+
+// backoff 计算第 attempt 次重试前的等待时间（attempt 从 1 开始）
+func (o *RetryOptions) backoff(attempt int) time.Duration {
+	wait := float64(o.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		wait *= o.Multiplier
+		if o.MaxBackoff > 0 && wait >= float64(o.MaxBackoff) {
+			wait = float64(o.MaxBackoff)
+			break
+		}
+	}
+	if o.MaxBackoff > 0 && wait > float64(o.MaxBackoff) {
+		wait = float64(o.MaxBackoff)
+	}
+	if o.Jitter > 0 {
+		jitter := min(o.Jitter, 1)
+		wait = wait * (1 - jitter*rand.Float64())
+	}
+	return time.Duration(wait)
+}
+
+// InTransaction 判断当前 DBCli 是否处于事务中
+func (d *DBCli) InTransaction() bool {
+	_, ok := d.cli.(*sqlx.Tx)
+	return ok
+}
+
+// IsTransientError 判断错误是否为当前方言可识别的瞬时错误（死锁、序列化失败等）
+func (d *DBCli) IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if sqlFunc, ok := GetSqlDialect(d.dbtype); ok {
+		if c, ok := sqlFunc.(TransientErrorClassifier); ok {
+			return c.IsTransientError(err)
+		}
+	}
+	return false
+}
+
+// runTransaction 执行一次完整的事务，提交失败时返回提交错误
+func (d *DBCli) runTransaction(fn func(tx *DBCli) error) (err error) {
+	tx, err := d.BeginX()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic occurred: %v\nStack trace:\n%s", r, debug.Stack())
+		}
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// WithRetryTransaction 在事务中执行 fn，遇到方言识别的瞬时错误时整体回滚并重试
+// 只有在事务未提交成功时才会重试；若当前已处于事务中，则不做重试，由最外层事务负责
+func (d *DBCli) WithRetryTransaction(fn func(tx *DBCli) error, opts ...RetryOption) error {
+	o := DefaultRetryOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if d.InTransaction() {
+		return d.WithTransaction(fn)
+	}
+	retryable := o.Retryable
+	if retryable == nil {
+		retryable = d.IsTransientError
+	}
+	ctx := o.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for attempt := 1; ; attempt++ {
+		err := d.runTransaction(fn)
+		if err == nil || attempt >= o.MaxAttempts || !retryable(err) {
+			return err
+		}
+		DBLog().Warn("transaction retry", "key", d.key, "attempt", attempt+1, "err", err)
+		if o.OnRetry != nil {
+			o.OnRetry(attempt+1, err)
+		}
+		timer := time.NewTimer(o.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package cydb
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/fj1981/infrakit/pkg/cyutil"
+)
+
+// ConstraintType 约束类型
+type ConstraintType string
+
+const (
+	ConstraintPrimaryKey ConstraintType = "PRIMARY KEY"
+	ConstraintUnique     ConstraintType = "UNIQUE"
+	ConstraintForeignKey ConstraintType = "FOREIGN KEY"
+	ConstraintCheck      ConstraintType = "CHECK"
+)
+
+// SchemaColumn 列的完整属性
+type SchemaColumn struct {
+	Name string
+	// Position 列序号，从 1 开始
+	Position int
+	// DataType 数据库原始类型，如 varchar、NUMBER
+	DataType string
+	// ColumnType 带长度精度的完整类型，如 varchar(32)、NUMBER(10,2)
+	ColumnType string
+	FieldType  DBFieldType
+	Nullable   bool
+	// Default 默认值表达式，nil 表示没有默认值
+	Default   *string
+	Length    int64
+	Precision int64
+	Scale     int64
+	// AutoIncrement 自增列，包括 MySQL AUTO_INCREMENT、PostgreSQL serial/identity、Oracle identity
+	AutoIncrement bool
+	// Sequence 生成该列取值的序列名，没有时为空
+	Sequence   string
+	PrimaryKey bool
+	Comment    string
+}
+
+// SchemaIndex 索引，不包含主键索引
+type SchemaIndex struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+// SchemaConstraint 主键、唯一、检查约束，外键见 SchemaForeignKey
+type SchemaConstraint struct {
+	Name    string
+	Type    ConstraintType
+	Columns []string
+	// Check 检查约束的条件表达式
+	Check string
+}
+
+// SchemaForeignKey 外键
+type SchemaForeignKey struct {
+	Name       string
+	Columns    []string
+	RefSchema  string
+	RefTable   string
+	RefColumns []string
+	OnDelete   string
+	OnUpdate   string
+}
+
+// SchemaTrigger 触发器
+type SchemaTrigger struct {
+	Name string
+	// Timing BEFORE、AFTER、INSTEAD OF
+	Timing string
+	// Event INSERT、UPDATE、DELETE，多个事件以 OR 连接
+	Event      string
+	Definition string
+}
+
+// TableSchema 与方言无关的表结构描述
+type TableSchema struct {
+	Schema      string
+	Name        string
+	Comment     string
+	Columns     []*SchemaColumn
+	PrimaryKey  []string
+	Indexes     []*SchemaIndex
+	Constraints []*SchemaConstraint
+	ForeignKeys []*SchemaForeignKey
+	Triggers    []*SchemaTrigger
+}
+
+// Column 按名称查找列，忽略大小写
+func (t *TableSchema) Column(name string) *SchemaColumn {
+	for _, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// TableSchemaInspector 由方言可选实现，读取表的完整结构
+type TableSchemaInspector interface {
+	GetTableSchema(cli DatabaseClient, database, tableName string) (*TableSchema, error)
+}
+
+// GetTableSchema 读取表的完整结构，name 为表名或 database, table
+func (d *DBCli) GetTableSchema(name ...string) (*TableSchema, error) {
+	db, table := GetDBAndTable(d, name...)
+	if table == "" {
+		return nil, errors.New("table name is empty")
+	}
+	sqlFunc, ok := GetSqlDialect(d.dbtype)
+	if !ok {
+		return nil, errors.New("not support db type: " + d.dbtype)
+	}
+	inspector, ok := sqlFunc.(TableSchemaInspector)
+	if !ok {
+		return nil, errors.New("table schema not supported for db type: " + d.dbtype)
+	}
+	return inspector.GetTableSchema(d, db, table)
+}
+
+// MarkPrimaryKey 根据约束补全 PrimaryKey 和列的 PrimaryKey 标记，供方言实现复用
+func (t *TableSchema) MarkPrimaryKey() {
+	for _, c := range t.Constraints {
+		if c.Type == ConstraintPrimaryKey {
+			t.PrimaryKey = c.Columns
+			break
+		}
+	}
+	for _, name := range t.PrimaryKey {
+		if col := t.Column(name); col != nil {
+			col.PrimaryKey = true
+		}
+	}
+}
+
+// AppendIndexColumn 按名称追加索引列，供方言实现按行组装索引
+func (t *TableSchema) AppendIndexColumn(name, column string, unique bool) {
+	for _, idx := range t.Indexes {
+		if idx.Name == name {
+			idx.Columns = append(idx.Columns, column)
+			return
+		}
+	}
+	t.Indexes = append(t.Indexes, &SchemaIndex{Name: name, Columns: []string{column}, Unique: unique})
+}
+
+// AppendConstraintColumn 按名称追加约束列
+func (t *TableSchema) AppendConstraintColumn(name string, tp ConstraintType, column string) *SchemaConstraint {
+	for _, c := range t.Constraints {
+		if c.Name == name {
+			if column != "" {
+				c.Columns = append(c.Columns, column)
+			}
+			return c
+		}
+	}
+	c := &SchemaConstraint{Name: name, Type: tp}
+	if column != "" {
+		c.Columns = []string{column}
+	}
+	t.Constraints = append(t.Constraints, c)
+	return c
+}
+
+// AppendForeignKeyColumn 按名称追加外键列及其引用列
+func (t *TableSchema) AppendForeignKeyColumn(name, column, refColumn string) *SchemaForeignKey {
+	for _, fk := range t.ForeignKeys {
+		if fk.Name == name {
+			fk.Columns = append(fk.Columns, column)
+			fk.RefColumns = append(fk.RefColumns, refColumn)
+			return fk
+		}
+	}
+	fk := &SchemaForeignKey{Name: name, Columns: []string{column}, RefColumns: []string{refColumn}}
+	t.ForeignKeys = append(t.ForeignKeys, fk)
+	return fk
+}
+
+// NullableString 将 nil 以外的值转为字符串指针
+func NullableString(v any) *string {
+	if v == nil {
+		return nil
+	}
+	s := strings.TrimSpace(cyutil.ToStr(v))
+	return &s
+}
+
+// TableLister 由方言可选实现，列出数据库中的表
+type TableLister interface {
+	GetTableNames(cli DatabaseClient, database string) ([]string, error)
+}
+
+// GetTableNames 列出当前数据库中的表，不包含视图；指定 schema 时列出该 schema 下的表，默认为 Schema()
+func (d *DBCli) GetTableNames(schema ...string) ([]string, error) {
+	sqlFunc, ok := GetSqlDialect(d.dbtype)
+	if !ok {
+		return nil, errors.New("not support db type: " + d.dbtype)
+	}
+	lister, ok := sqlFunc.(TableLister)
+	if !ok {
+		return nil, errors.New("table listing not supported for db type: " + d.dbtype)
+	}
+	db := d.Schema()
+	if len(schema) > 0 && schema[0] != "" {
+		db = schema[0]
+	}
+	return lister.GetTableNames(d, db)
+}
+
+// QueryNames 执行返回 name 列的查询，供方言实现复用
+func QueryNames(cli DatabaseClient, query string, args ...any) ([]string, error) {
+	rows, err := InternalQuery(cli, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(rows))
+	for _, r := range rows {
+		names = append(names, cyutil.GetStr(r, "name", true))
+	}
+	return names, nil
+}
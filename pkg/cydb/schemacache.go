@@ -0,0 +1,196 @@
+package cydb
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// DefaultSchemaCacheTTL 表结构缓存默认有效期
+const DefaultSchemaCacheTTL = 2 * time.Minute
+
+// SchemaInvalidateTopic 表结构变更广播的消息类型前缀，实际类型为前缀加连接 Key
+const SchemaInvalidateTopic = "cydb.schema.invalidate"
+
+// SchemaBroadcaster 跨进程广播表结构变更，cydist.Broadcaster 满足该接口
+type SchemaBroadcaster interface {
+	PublishSimple(channel string, payload interface{}) error
+	RegisterHandlerFunc(messageType string, handlerFunc func(ctx context.Context, payload []byte) error) error
+}
+
+// schemaCache 连接级的表结构缓存，同一连接的事务、副本共享
+type schemaCache struct {
+	mu    sync.RWMutex
+	c     *cache.Cache
+	ttl   time.Duration
+	ns    string
+	bcast SchemaBroadcaster
+}
+
+type schemaInvalidation struct {
+	Tables []string `json:"tables,omitempty"`
+}
+
+// newSchemaCache ttl 为 0 时使用默认有效期，小于 0 时不缓存
+func newSchemaCache(ns string, ttl time.Duration) *schemaCache {
+	if ttl == 0 {
+		ttl = DefaultSchemaCacheTTL
+	}
+	return &schemaCache{c: cache.New(ttl, 2*ttl), ttl: ttl, ns: ns}
+}
+
+func (s *schemaCache) get(key string) ([]*DBColumn, bool) {
+	if s == nil || s.currentTTL() < 0 {
+		return nil, false
+	}
+	if v, ok := s.c.Get(key); ok {
+		return v.([]*DBColumn), true
+	}
+	return nil, false
+}
+
+func (s *schemaCache) set(key string, cols []*DBColumn) {
+	if s == nil {
+		return
+	}
+	if ttl := s.currentTTL(); ttl > 0 {
+		s.c.Set(key, cols, ttl)
+	}
+}
+
+func (s *schemaCache) currentTTL() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ttl
+}
+
+// invalidate 清理本进程的缓存，tables 为空时全部清理，表名不区分大小写
+func (s *schemaCache) invalidate(tables []string) {
+	if s == nil {
+		return
+	}
+	if len(tables) == 0 {
+		s.c.Flush()
+		return
+	}
+	for key := range s.c.Items() {
+		name := bareTableName(key[strings.LastIndexByte(key, ':')+1:])
+		for _, t := range tables {
+			if strings.EqualFold(name, bareTableName(t)) {
+				s.c.Delete(key)
+				break
+			}
+		}
+	}
+}
+
+func (s *schemaCache) broadcaster() SchemaBroadcaster {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.bcast
+}
+
+func (d *DBCli) schemaCacheKey(tableName string) string {
+	return d.database + ":" + tableName
+}
+
+// SetSchemaCacheTTL 调整当前连接的表结构缓存有效期并清空缓存，ttl 小于 0 时关闭缓存
+func (d *DBCli) SetSchemaCacheTTL(ttl time.Duration) {
+	if d.sc == nil {
+		return
+	}
+	if ttl == 0 {
+		ttl = DefaultSchemaCacheTTL
+	}
+	d.sc.mu.Lock()
+	d.sc.ttl = ttl
+	d.sc.mu.Unlock()
+	d.sc.c.Flush()
+}
+
+// SetSchemaBroadcaster 通过 b 在进程间同步表结构变更，本连接的 InvalidateSchema 及 DDL 会通知其他进程
+// 其他进程需以相同的连接 Key 调用本方法；b 为 cydist.Broadcaster 时需自行 Start
+func (d *DBCli) SetSchemaBroadcaster(b SchemaBroadcaster) error {
+	if d.sc == nil {
+		return nil
+	}
+	sc := d.sc
+	if b != nil {
+		err := b.RegisterHandlerFunc(SchemaInvalidateTopic+":"+sc.ns, func(ctx context.Context, payload []byte) error {
+			var msg schemaInvalidation
+			if err := json.Unmarshal(payload, &msg); err != nil {
+				return err
+			}
+			sc.invalidate(msg.Tables)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	sc.mu.Lock()
+	sc.bcast = b
+	sc.mu.Unlock()
+	return nil
+}
+
+// InvalidateSchema 清理表结构缓存，不指定表时清理全部；设置了广播时同时通知其他进程
+// 通过 DBCli 执行的 DDL 与迁移会自动调用，仅在其他途径修改表结构后需要手动调用
+func (d *DBCli) InvalidateSchema(tables ...string) {
+	if d.sc == nil {
+		return
+	}
+	d.sc.invalidate(tables)
+	if b := d.sc.broadcaster(); b != nil {
+		if err := b.PublishSimple(SchemaInvalidateTopic+":"+d.sc.ns, schemaInvalidation{Tables: tables}); err != nil {
+			DBLog().Warn("broadcast schema invalidation failed", "tables", tables, "err", err)
+		}
+	}
+}
+
+var (
+	ddlPattern      = regexp.MustCompile(`(?i)\b(CREATE|ALTER|DROP|RENAME)\b`)
+	ddlTablePattern = regexp.MustCompile(`(?i)^\s*(?:ALTER|DROP|CREATE)\s+TABLE\s+(?:IF\s+(?:NOT\s+)?EXISTS\s+)?([^\s(;]+)`)
+)
+
+// afterExec 执行成功后检查是否为修改表结构的语句，是则清理相应缓存
+// 能识别出表名的 CREATE/ALTER/DROP TABLE 只清理该表，其他 DDL 清理全部
+func (d *DBCli) afterExec(sql string) {
+	if d.sc == nil || !ddlPattern.MatchString(sql) {
+		return
+	}
+	var tables []string
+	for _, stmt := range splitStatements(sql) {
+		m := firstWordPattern.FindStringSubmatch(stmt)
+		if m == nil {
+			continue
+		}
+		switch strings.ToUpper(m[1]) {
+		case "CREATE", "ALTER", "DROP", "RENAME":
+		default:
+			continue
+		}
+		t := ddlTablePattern.FindStringSubmatch(stmt)
+		if t == nil {
+			d.InvalidateSchema()
+			return
+		}
+		tables = append(tables, t[1])
+	}
+	if len(tables) > 0 {
+		d.InvalidateSchema(tables...)
+	}
+}
+
+// bareTableName 去掉库名前缀与引号
+func bareTableName(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.Trim(name, "`\"[]")
+}
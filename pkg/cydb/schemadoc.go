@@ -0,0 +1,364 @@
+package cydb
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DocFormat 表结构文档格式
+type DocFormat string
+
+const (
+	// DocFormatMarkdown Markdown 数据字典，末尾附带 Mermaid ER 图
+	DocFormatMarkdown DocFormat = "markdown"
+	DocFormatHTML     DocFormat = "html"
+	DocFormatMermaid  DocFormat = "mermaid"
+	DocFormatPlantUML DocFormat = "plantuml"
+)
+
+// SchemaDocOptions 文档生成配置
+type SchemaDocOptions struct {
+	Title string
+	// Tables 指定表，为空时使用数据库中的所有表
+	Tables []string
+	// Include 表名通配符（path.Match 语法），为空时不过滤
+	Include []string
+	// Exclude 排除的表名通配符
+	Exclude []string
+}
+
+type SchemaDocOption func(*SchemaDocOptions)
+
+func WithDocTitle(title string) SchemaDocOption {
+	return func(o *SchemaDocOptions) { o.Title = title }
+}
+
+func WithDocTables(tables ...string) SchemaDocOption {
+	return func(o *SchemaDocOptions) { o.Tables = append(o.Tables, tables...) }
+}
+
+func WithDocInclude(patterns ...string) SchemaDocOption {
+	return func(o *SchemaDocOptions) { o.Include = append(o.Include, patterns...) }
+}
+
+func WithDocExclude(patterns ...string) SchemaDocOption {
+	return func(o *SchemaDocOptions) { o.Exclude = append(o.Exclude, patterns...) }
+}
+
+func matchAny(patterns []string, name string) bool {
+	name = strings.ToLower(name)
+	return slices.ContainsFunc(patterns, func(p string) bool {
+		ok, _ := path.Match(strings.ToLower(p), name)
+		return ok
+	})
+}
+
+func (o *SchemaDocOptions) match(name string) bool {
+	if len(o.Include) > 0 && !matchAny(o.Include, name) {
+		return false
+	}
+	return !matchAny(o.Exclude, name)
+}
+
+// LoadTableSchemas 按过滤条件读取多张表的结构
+func (d *DBCli) LoadTableSchemas(opts ...SchemaDocOption) ([]*TableSchema, error) {
+	o := &SchemaDocOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	tables := o.Tables
+	if len(tables) == 0 {
+		var err error
+		if tables, err = d.GetTableNames(); err != nil {
+			return nil, err
+		}
+	}
+	var schemas []*TableSchema
+	for _, t := range tables {
+		if !o.match(t) {
+			continue
+		}
+		ts, err := d.GetTableSchema(t)
+		if err != nil {
+			return nil, fmt.Errorf("load schema of %s: %w", t, err)
+		}
+		schemas = append(schemas, ts)
+	}
+	return schemas, nil
+}
+
+// GenerateSchemaDoc 生成表结构文档
+func (d *DBCli) GenerateSchemaDoc(w io.Writer, format DocFormat, opts ...SchemaDocOption) error {
+	schemas, err := d.LoadTableSchemas(opts...)
+	if err != nil {
+		return err
+	}
+	o := &SchemaDocOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	title := o.Title
+	if title == "" {
+		title = d.Database()
+	}
+	return WriteSchemaDoc(w, format, title, schemas)
+}
+
+// WriteSchemaDoc 将表结构渲染为指定格式
+func WriteSchemaDoc(w io.Writer, format DocFormat, title string, schemas []*TableSchema) error {
+	switch format {
+	case DocFormatMarkdown:
+		return writeMarkdownDoc(w, title, schemas)
+	case DocFormatHTML:
+		return htmlDocTemplate.Execute(w, map[string]any{"Title": title, "Tables": schemas})
+	case DocFormatMermaid:
+		_, err := io.WriteString(w, MermaidER(schemas))
+		return err
+	case DocFormatPlantUML:
+		_, err := io.WriteString(w, PlantUMLER(schemas))
+		return err
+	}
+	return fmt.Errorf("unsupported doc format: %s", format)
+}
+
+// columnKeys 列的键标记，PK、FK、UK
+func (t *TableSchema) columnKeys(col *SchemaColumn) []string {
+	var keys []string
+	if col.PrimaryKey {
+		keys = append(keys, "PK")
+	}
+	for _, fk := range t.ForeignKeys {
+		if slices.ContainsFunc(fk.Columns, func(c string) bool { return strings.EqualFold(c, col.Name) }) {
+			keys = append(keys, "FK")
+			break
+		}
+	}
+	for _, c := range t.Constraints {
+		if c.Type == ConstraintUnique && len(c.Columns) == 1 && strings.EqualFold(c.Columns[0], col.Name) {
+			keys = append(keys, "UK")
+			break
+		}
+	}
+	return keys
+}
+
+func columnDefault(col *SchemaColumn) string {
+	if col.Default == nil {
+		return ""
+	}
+	return *col.Default
+}
+
+func columnTypeText(col *SchemaColumn) string {
+	if col.ColumnType != "" {
+		return col.ColumnType
+	}
+	return col.DataType
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "YES"
+	}
+	return "NO"
+}
+
+func mdCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+func writeMarkdownDoc(w io.Writer, title string, schemas []*TableSchema) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", title)
+	for _, t := range schemas {
+		fmt.Fprintf(&sb, "## %s\n\n", t.Name)
+		if t.Comment != "" {
+			fmt.Fprintf(&sb, "%s\n\n", mdCell(t.Comment))
+		}
+		sb.WriteString("| # | Column | Type | Nullable | Default | Key | Comment |\n")
+		sb.WriteString("|---|---|---|---|---|---|---|\n")
+		for _, c := range t.Columns {
+			fmt.Fprintf(&sb, "| %d | %s | %s | %s | %s | %s | %s |\n", c.Position, mdCell(c.Name), mdCell(columnTypeText(c)),
+				yesNo(c.Nullable), mdCell(columnDefault(c)), strings.Join(t.columnKeys(c), ", "), mdCell(c.Comment))
+		}
+		sb.WriteString("\n")
+		if len(t.Indexes) > 0 {
+			sb.WriteString("**Indexes**\n\n| Name | Columns | Unique |\n|---|---|---|\n")
+			for _, idx := range t.Indexes {
+				fmt.Fprintf(&sb, "| %s | %s | %s |\n", mdCell(idx.Name), mdCell(strings.Join(idx.Columns, ", ")), yesNo(idx.Unique))
+			}
+			sb.WriteString("\n")
+		}
+		if len(t.ForeignKeys) > 0 {
+			sb.WriteString("**Foreign Keys**\n\n| Name | Columns | References | On Delete | On Update |\n|---|---|---|---|---|\n")
+			for _, fk := range t.ForeignKeys {
+				fmt.Fprintf(&sb, "| %s | %s | %s(%s) | %s | %s |\n", mdCell(fk.Name), mdCell(strings.Join(fk.Columns, ", ")),
+					mdCell(fk.RefTable), mdCell(strings.Join(fk.RefColumns, ", ")), fk.OnDelete, fk.OnUpdate)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	sb.WriteString("## ER Diagram\n\n```mermaid\n")
+	sb.WriteString(MermaidER(schemas))
+	sb.WriteString("```\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+var erIdentPattern = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// erIdent ER 图中的标识符只允许字母数字下划线
+func erIdent(s string) string {
+	s = erIdentPattern.ReplaceAllString(s, "_")
+	if s == "" {
+		return "_"
+	}
+	return s
+}
+
+// fkNullable 外键列均可为空时，关系的父端为可选
+func (t *TableSchema) fkNullable(fk *SchemaForeignKey) bool {
+	for _, name := range fk.Columns {
+		if col := t.Column(name); col == nil || !col.Nullable {
+			return false
+		}
+	}
+	return true
+}
+
+// MermaidER 生成 Mermaid erDiagram
+func MermaidER(schemas []*TableSchema) string {
+	var sb strings.Builder
+	sb.WriteString("erDiagram\n")
+	for _, t := range schemas {
+		fmt.Fprintf(&sb, "    %s {\n", erIdent(t.Name))
+		for _, c := range t.Columns {
+			fmt.Fprintf(&sb, "        %s %s", erIdent(c.DataType), erIdent(c.Name))
+			if keys := t.columnKeys(c); len(keys) > 0 {
+				fmt.Fprintf(&sb, " %s", strings.Join(keys, ", "))
+			}
+			if c.Comment != "" {
+				fmt.Fprintf(&sb, " %q", strings.ReplaceAll(c.Comment, `"`, "'"))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("    }\n")
+	}
+	for _, t := range schemas {
+		for _, fk := range t.ForeignKeys {
+			parent := "||"
+			if t.fkNullable(fk) {
+				parent = "|o"
+			}
+			fmt.Fprintf(&sb, "    %s %s--o{ %s : %q\n", erIdent(fk.RefTable), parent, erIdent(t.Name), strings.Join(fk.Columns, ", "))
+		}
+	}
+	return sb.String()
+}
+
+// PlantUMLER 生成 PlantUML 实体关系图
+func PlantUMLER(schemas []*TableSchema) string {
+	var sb strings.Builder
+	sb.WriteString("@startuml\nhide circle\nskinparam linetype ortho\n\n")
+	for _, t := range schemas {
+		fmt.Fprintf(&sb, "entity %q as %s {\n", t.Name, erIdent(t.Name))
+		var rest []*SchemaColumn
+		for _, c := range t.Columns {
+			if !c.PrimaryKey {
+				rest = append(rest, c)
+				continue
+			}
+			fmt.Fprintf(&sb, "  * %s : %s <<PK>>\n", c.Name, columnTypeText(c))
+		}
+		sb.WriteString("  --\n")
+		for _, c := range rest {
+			mark := ""
+			if !c.Nullable {
+				mark = "* "
+			}
+			fmt.Fprintf(&sb, "  %s%s : %s", mark, c.Name, columnTypeText(c))
+			for _, k := range t.columnKeys(c) {
+				fmt.Fprintf(&sb, " <<%s>>", k)
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("}\n\n")
+	}
+	for _, t := range schemas {
+		for _, fk := range t.ForeignKeys {
+			parent := "||"
+			if t.fkNullable(fk) {
+				parent = "|o"
+			}
+			fmt.Fprintf(&sb, "%s %s..o{ %s : %s\n", erIdent(fk.RefTable), parent, erIdent(t.Name), strings.Join(fk.Columns, ", "))
+		}
+	}
+	sb.WriteString("@enduml\n")
+	return sb.String()
+}
+
+var htmlDocTemplate = template.Must(template.New("schemadoc").Funcs(template.FuncMap{
+	"keys":    func(t *TableSchema, c *SchemaColumn) string { return strings.Join(t.columnKeys(c), ", ") },
+	"dflt":    columnDefault,
+	"coltype": columnTypeText,
+	"yesno":   yesNo,
+	"join":    strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #f4f4f4; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<ul>
+{{- range .Tables}}
+<li><a href="#{{.Name}}">{{.Name}}</a></li>
+{{- end}}
+</ul>
+{{- range $t := .Tables}}
+<h2 id="{{$t.Name}}">{{$t.Name}}</h2>
+{{- if $t.Comment}}
+<p>{{$t.Comment}}</p>
+{{- end}}
+<table>
+<tr><th>#</th><th>Column</th><th>Type</th><th>Nullable</th><th>Default</th><th>Key</th><th>Comment</th></tr>
+{{- range $t.Columns}}
+<tr><td>{{.Position}}</td><td>{{.Name}}</td><td>{{coltype .}}</td><td>{{yesno .Nullable}}</td><td>{{dflt .}}</td><td>{{keys $t .}}</td><td>{{.Comment}}</td></tr>
+{{- end}}
+</table>
+{{- if $t.Indexes}}
+<h3>Indexes</h3>
+<table>
+<tr><th>Name</th><th>Columns</th><th>Unique</th></tr>
+{{- range $t.Indexes}}
+<tr><td>{{.Name}}</td><td>{{join .Columns ", "}}</td><td>{{yesno .Unique}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if $t.ForeignKeys}}
+<h3>Foreign Keys</h3>
+<table>
+<tr><th>Name</th><th>Columns</th><th>References</th><th>On Delete</th><th>On Update</th></tr>
+{{- range $t.ForeignKeys}}
+<tr><td>{{.Name}}</td><td>{{join .Columns ", "}}</td><td>{{.RefTable}}({{join .RefColumns ", "}})</td><td>{{.OnDelete}}</td><td>{{.OnUpdate}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
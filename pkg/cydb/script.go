@@ -0,0 +1,179 @@
+package cydb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ScriptTxMode 脚本执行的事务模式
+type ScriptTxMode int
+
+const (
+	// ScriptTxNone 不使用事务，逐条自动提交
+	ScriptTxNone ScriptTxMode = iota
+	// ScriptTxStatement 每条语句单独一个事务
+	ScriptTxStatement
+	// ScriptTxScript 整个脚本一个事务，出错时全部回滚并停止
+	ScriptTxScript
+)
+
+// ScriptOptions 脚本执行配置
+type ScriptOptions struct {
+	TxMode ScriptTxMode
+	// ContinueOnError 出错后继续执行后续语句，ScriptTxScript 模式下无效
+	ContinueOnError bool
+	// ResumeAfter 跳过 Index <= ResumeAfter 的语句，用于从上次成功的位置继续执行
+	ResumeAfter int
+	// Progress 每条语句执行后回调，err 为该语句的执行错误
+	Progress func(stmt *SQLStatement, err error)
+	// ReadOptions 解析脚本的选项
+	ReadOptions []func(*ReadSQLFileOptions)
+}
+
+type ScriptOption func(*ScriptOptions)
+
+func WithScriptTxMode(mode ScriptTxMode) ScriptOption {
+	return func(o *ScriptOptions) { o.TxMode = mode }
+}
+
+func WithScriptContinueOnError(enable bool) ScriptOption {
+	return func(o *ScriptOptions) { o.ContinueOnError = enable }
+}
+
+func WithScriptResume(lastSuccess int) ScriptOption {
+	return func(o *ScriptOptions) { o.ResumeAfter = lastSuccess }
+}
+
+func WithScriptProgress(fn func(stmt *SQLStatement, err error)) ScriptOption {
+	return func(o *ScriptOptions) { o.Progress = fn }
+}
+
+func WithScriptReadOptions(options ...func(*ReadSQLFileOptions)) ScriptOption {
+	return func(o *ScriptOptions) { o.ReadOptions = append(o.ReadOptions, options...) }
+}
+
+// ScriptStatementError 脚本中某条语句的执行错误
+type ScriptStatementError struct {
+	Statement *SQLStatement
+	Err       error
+}
+
+func (e *ScriptStatementError) Error() string {
+	return fmt.Sprintf("statement %d (line %d-%d) failed: %v", e.Statement.Index, e.Statement.StartLine, e.Statement.EndLine, e.Err)
+}
+
+func (e *ScriptStatementError) Unwrap() error {
+	return e.Err
+}
+
+// ScriptResult 脚本执行结果
+type ScriptResult struct {
+	// Executed 成功执行的语句数
+	Executed int
+	// Skipped 因断点续跑跳过的语句数
+	Skipped int
+	// LastSuccess 从头开始连续执行成功的最后一条语句的 Index，遇到失败的语句后不再前移
+	// 作为 WithScriptResume 的参数时从 FirstFailed 重新执行，ContinueOnError 时其后已成功的语句也会再次执行，需保证可重复执行
+	LastSuccess int
+	// FirstFailed 第一条失败语句的 Index，没有失败时为 0
+	FirstFailed int
+	// Errors ContinueOnError 时收集的错误
+	Errors []*ScriptStatementError
+}
+
+// errScriptStop 用于在回调中中断解析
+var errScriptStop = errors.New("script stopped")
+
+// RunScript 执行 SQL 脚本，语句按当前数据库方言解析，不做方言转换
+// 返回的 ScriptResult 在出错时同样有效，可用于断点续跑
+// 开启危险语句检查时按迁移处理：允许 DDL，其余规则不变
+func (d *DBCli) RunScript(r io.Reader, opts ...ScriptOption) (*ScriptResult, error) {
+	d = d.migrationScope()
+	o := &ScriptOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	res := &ScriptResult{LastSuccess: o.ResumeAfter}
+	if o.TxMode == ScriptTxScript {
+		var executed int
+		var last int
+		err := d.WithTransaction(func(tx *DBCli) error {
+			sub := &ScriptResult{LastSuccess: o.ResumeAfter}
+			err := tx.runScript(r, o, sub, false)
+			executed, last, res.Skipped, res.FirstFailed = sub.Executed, sub.LastSuccess, sub.Skipped, sub.FirstFailed
+			return err
+		})
+		if err != nil {
+			// 整个脚本已回滚
+			return res, err
+		}
+		res.Executed, res.LastSuccess = executed, last
+		return res, nil
+	}
+	return res, d.runScript(r, o, res, o.ContinueOnError)
+}
+
+func (d *DBCli) runScript(r io.Reader, o *ScriptOptions, res *ScriptResult, continueOnError bool) error {
+	var stopErr error
+	err := d.ReadSQLFile(r, func(stmt *SQLStatement) error {
+		if stmt == nil || strings.TrimSpace(stmt.Content) == "" {
+			return nil
+		}
+		if stmt.Index <= o.ResumeAfter {
+			res.Skipped++
+			return nil
+		}
+		err := d.runStatement(stmt, o.TxMode)
+		if o.Progress != nil {
+			o.Progress(stmt, err)
+		}
+		if err == nil {
+			res.Executed++
+			if res.FirstFailed == 0 {
+				res.LastSuccess = stmt.Index
+			}
+			return nil
+		}
+		if res.FirstFailed == 0 {
+			res.FirstFailed = stmt.Index
+		}
+		se := &ScriptStatementError{Statement: stmt, Err: err}
+		if continueOnError {
+			res.Errors = append(res.Errors, se)
+			return nil
+		}
+		stopErr = se
+		return errScriptStop
+	}, o.ReadOptions...)
+	if stopErr != nil {
+		// 解析器会包装回调返回的错误，这里返回原始的语句错误
+		return stopErr
+	}
+	if err != nil {
+		return err
+	}
+	if len(res.Errors) > 0 {
+		errs := make([]error, len(res.Errors))
+		for i, e := range res.Errors {
+			errs[i] = e
+		}
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+func (d *DBCli) runStatement(stmt *SQLStatement, mode ScriptTxMode) error {
+	if err := d.guardSQL(stmt.Content); err != nil {
+		return err
+	}
+	if mode != ScriptTxStatement {
+		_, err := d.excute(stmt.Content)
+		return err
+	}
+	return d.WithTransaction(func(tx *DBCli) error {
+		_, err := tx.excute(stmt.Content)
+		return err
+	})
+}
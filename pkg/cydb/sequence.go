@@ -0,0 +1,185 @@
+package cydb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/duke-git/lancet/v2/maputil"
+	"github.com/fj1981/infrakit/pkg/cyutil"
+)
+
+// SequenceGenerator 由方言可选实现，从序列取下一个值
+type SequenceGenerator interface {
+	NextSequenceValue(cli DatabaseClient, sequence string) (int64, error)
+}
+
+// InsertReturner 由方言可选实现，返回追加在 INSERT 语句后、将 column 的取值写入输出参数 param 的子句
+// 未实现时 InsertReturningID 使用驱动的 LastInsertId
+type InsertReturner interface {
+	InsertReturningClause(column, param string) string
+}
+
+const returningIDParam = "cy_returning_id"
+
+// SetSequence 指定表的单列主键由 sequence 生成，Insert、BatchInsert、Upsert、Replace、BulkInsert 未提供主键时取 NEXTVAL 填充，sequence 为空时取消
+// 与 DBConnection.Sequences 相同，对当前 DBCli 及其后创建的副本生效
+func (d *DBCli) SetSequence(tableName, sequence string) {
+	seqs := make(map[string]string, len(d.seqs)+1)
+	for k, v := range d.seqs {
+		seqs[k] = v
+	}
+	key := strings.ToUpper(bareTableName(tableName))
+	if sequence == "" {
+		delete(seqs, key)
+	} else {
+		seqs[key] = sequence
+	}
+	d.seqs = seqs
+}
+
+// pkColumn 返回表的单列主键，复合主键或无主键时返回 nil
+func (d *DBCli) pkColumn(tableName string) (*DBColumn, error) {
+	cols, err := d.GetTableColumns(tableName)
+	if err != nil {
+		return nil, err
+	}
+	var pk *DBColumn
+	for _, col := range cols {
+		if col.ColumnKey != "PRI" {
+			continue
+		}
+		if pk != nil {
+			return nil, nil
+		}
+		pk = col
+	}
+	return pk, nil
+}
+
+// dataKey 返回 data 中与 col 同名（忽略大小写）的键
+func dataKey(data map[string]interface{}, col string) (string, bool) {
+	if _, ok := data[col]; ok {
+		return col, true
+	}
+	for k := range data {
+		if strings.EqualFold(k, col) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// sequenceKey 表配置了序列时返回序列名与单列主键，未配置时序列名为空
+func (d *DBCli) sequenceKey(tableName string) (string, *DBColumn, error) {
+	seq := d.seqs[strings.ToUpper(bareTableName(tableName))]
+	if seq == "" {
+		return "", nil, nil
+	}
+	pk, err := d.pkColumn(tableName)
+	if err != nil {
+		return "", nil, err
+	}
+	if pk == nil {
+		return "", nil, fmt.Errorf("table %s: sequence requires a single-column primary key", tableName)
+	}
+	return seq, pk, nil
+}
+
+// fillSequenceKey data 未提供单列主键且为表配置了序列时，取序列下一个值写入 data 的副本并返回，不修改调用方传入的数据
+// 由数据库生成取值的列（identity、触发器）无需配置序列
+func (d *DBCli) fillSequenceKey(tableName string, data map[string]interface{}) (map[string]interface{}, error) {
+	seq, pk, err := d.sequenceKey(tableName)
+	if err != nil || seq == "" {
+		return data, err
+	}
+	var gen SequenceGenerator
+	if sqlFunc, ok := GetSqlDialect(d.dbtype); ok {
+		gen, _ = sqlFunc.(SequenceGenerator)
+	}
+	if gen == nil {
+		return nil, errors.New("sequence not supported for db type: " + d.dbtype)
+	}
+	k, ok := dataKey(data, pk.Name)
+	if ok && data[k] != nil {
+		return data, nil
+	}
+	id, err := gen.NextSequenceValue(d, seq)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		k = pk.Name
+	}
+	data = cloneData(data)
+	data[k] = id
+	return data, nil
+}
+
+// InsertReturningID 插入一条记录并返回单列整数主键的值
+// 主键已提供时返回该值；配置了序列时先取 NEXTVAL；由数据库生成时通过 RETURNING INTO 或 LastInsertId 取回
+func (d *DBCli) InsertReturningID(tableName string, data map[string]interface{}, cc ...FuncWithBuilder) (int64, error) {
+	sqlFunc, ok := GetSqlTransformer(d.dbtype)
+	if !ok {
+		return 0, errors.New("not support db type: " + d.dbtype)
+	}
+	pk, err := d.pkColumn(tableName)
+	if err != nil {
+		return 0, err
+	}
+	if pk == nil {
+		return 0, fmt.Errorf("table %s: InsertReturningID requires a single-column primary key", tableName)
+	}
+	data = d.auditData(tableName, data, true)
+	data, err = d.fillSequenceKey(tableName, data)
+	if err != nil {
+		return 0, err
+	}
+	key, hasKey := dataKey(data, pk.Name)
+	hasKey = hasKey && data[key] != nil
+
+	fields := d.filterFields(tableName, maputil.Keys(data))
+	builder := d.builder().Table(tableName).Fields(fields)
+	for _, c := range cc {
+		builder = c(builder)
+	}
+	sqlContent, err := builder.Type(SQLOperationInsert).Build(sqlFunc)
+	if err != nil {
+		return 0, err
+	}
+	var id int64
+	query := sqlContent.SQL
+	args := data
+	var returner InsertReturner
+	if dialect, ok := GetSqlDialect(d.dbtype); ok && !hasKey {
+		returner, _ = dialect.(InsertReturner)
+	}
+	if returner != nil {
+		query += returner.InsertReturningClause(pk.Name, returningIDParam)
+		args = make(map[string]interface{}, len(data)+1)
+		for k, v := range data {
+			args[k] = v
+		}
+		args[returningIDParam] = sql.Out{Dest: &id}
+	}
+	if err := d.guardSQL(query); err != nil {
+		return 0, err
+	}
+	DBLog().Debug("excute sql", "sql", query, "data", data)
+	start := time.Now()
+	r, err := d.namedExec(query, args)
+	d.observe(start, err)
+	if err != nil {
+		return 0, fmt.Errorf("[InsertReturningID]: %s | => %w | %v", query, err, data)
+	}
+	d.invalidateBuilder(builder)
+	switch {
+	case hasKey:
+		return cyutil.ToInt64(data[key]), nil
+	case returner == nil:
+		return r.LastInsertId()
+	}
+	return id, nil
+}
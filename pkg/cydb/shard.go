@@ -0,0 +1,495 @@
+package cydb
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fj1981/infrakit/pkg/cyutil"
+)
+
+// ShardRule 根据分片键计算分片序号
+type ShardRule func(key any) (int, error)
+
+// errShardCount 分片数不合法时规则返回的错误
+func errShardCount(n int) error {
+	return fmt.Errorf("shard count must be positive, got %d", n)
+}
+
+// HashShard 按分片键的 crc32 取模，n 不大于 0 时规则总是返回错误
+func HashShard(n int) ShardRule {
+	return func(key any) (int, error) {
+		if n <= 0 {
+			return 0, errShardCount(n)
+		}
+		if key == nil {
+			return 0, errors.New("shard key is nil")
+		}
+		return int(crc32.ChecksumIEEE([]byte(cyutil.ToString(key))) % uint32(n)), nil
+	}
+}
+
+// ModShard 按整数分片键取模，n 不大于 0 时规则总是返回错误
+func ModShard(n int) ShardRule {
+	return func(key any) (int, error) {
+		if n <= 0 {
+			return 0, errShardCount(n)
+		}
+		v, err := strconv.ParseInt(cyutil.ToString(key), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("shard key %v is not an integer: %w", key, err)
+		}
+		r := int(v % int64(n))
+		if r < 0 {
+			r += n
+		}
+		return r, nil
+	}
+}
+
+// RangeShard 按范围分片，bounds 为各分片的上界（不含），key < bounds[i] 时落在第 i 个分片
+func RangeShard(bounds ...int64) ShardRule {
+	return func(key any) (int, error) {
+		v, err := strconv.ParseInt(cyutil.ToString(key), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("shard key %v is not an integer: %w", key, err)
+		}
+		for i, b := range bounds {
+			if v < b {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("shard key %v out of range", key)
+	}
+}
+
+// DateShard 按日期分片，分片序号为日期按 layout 格式化后的数字，例如 "200601" 得到 202401
+// 分片键可以是 time.Time 或者 time.DateTime/time.DateOnly 格式的字符串
+func DateShard(layout string) ShardRule {
+	return func(key any) (int, error) {
+		var t time.Time
+		switch v := key.(type) {
+		case time.Time:
+			t = v
+		case string:
+			var err error
+			if t, err = time.ParseInLocation(time.DateTime, v, time.Local); err != nil {
+				if t, err = time.ParseInLocation(time.DateOnly, v, time.Local); err != nil {
+					return 0, fmt.Errorf("shard key %v is not a date: %w", key, err)
+				}
+			}
+		default:
+			return 0, fmt.Errorf("shard key %v is not a date", key)
+		}
+		return strconv.Atoi(t.Format(layout))
+	}
+}
+
+// ShardTable 逻辑表的分片配置
+type ShardTable struct {
+	// Table 逻辑表名
+	Table string
+	// Key 分片键字段
+	Key string
+	// Rule 分片规则
+	Rule ShardRule
+	// Shards 全部分片序号，用于扇出查询；为空时使用 0..Count-1
+	Shards []int
+	Count  int
+	// NameFormat 物理表名格式，参数为逻辑表名和分片序号，默认 "%s_%02d"
+	NameFormat string
+	// DBKeys 分片所在的 DBMgr 连接，Shards 中的分片按顺序均匀分配到各连接
+	DBKeys []string
+	// DBRoute 自定义分片到连接的映射，优先于 DBKeys；规则会产生 Shards 以外的分片序号时（如 DateShard）必须设置
+	DBRoute func(shard int) string
+}
+
+func (t *ShardTable) tableName(shard int) string {
+	return fmt.Sprintf(t.NameFormat, t.Table, shard)
+}
+
+// dbKey 未设置 DBRoute 时只有 Shards 中的分片可以映射到 DBKeys
+func (t *ShardTable) dbKey(shard int) (string, error) {
+	if t.DBRoute != nil {
+		return t.DBRoute(shard), nil
+	}
+	pos := slices.Index(t.Shards, shard)
+	if pos < 0 {
+		return "", fmt.Errorf("shard %d of table %s is not in Shards, set DBRoute to route it", shard, t.Table)
+	}
+	return t.DBKeys[pos*len(t.DBKeys)/len(t.Shards)], nil
+}
+
+// ShardRouter 分片路由，按分片规则选择 DBCli 并改写 SQLBuilder 中的表名
+type ShardRouter struct {
+	mgr    *DBMgr
+	lock   sync.RWMutex
+	tables map[string]*ShardTable
+}
+
+func NewShardRouter(mgr *DBMgr) *ShardRouter {
+	return &ShardRouter{mgr: mgr, tables: map[string]*ShardTable{}}
+}
+
+func (r *ShardRouter) Register(t *ShardTable) error {
+	if t.Table == "" || t.Rule == nil {
+		return errors.New("shard table and rule are required")
+	}
+	if len(t.Shards) == 0 {
+		for i := 0; i < t.Count; i++ {
+			t.Shards = append(t.Shards, i)
+		}
+	}
+	if len(t.Shards) == 0 {
+		return errors.New("shard table " + t.Table + " has no shards")
+	}
+	if t.DBRoute == nil && len(t.DBKeys) == 0 {
+		return errors.New("shard table " + t.Table + " has no db keys")
+	}
+	if t.NameFormat == "" {
+		t.NameFormat = "%s_%02d"
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.tables[normalTableTag(t.Table)] = t
+	return nil
+}
+
+func (r *ShardRouter) table(name string) (*ShardTable, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	t, ok := r.tables[normalTableTag(name)]
+	if !ok {
+		return nil, errors.New("shard table not registered: " + name)
+	}
+	return t, nil
+}
+
+func (r *ShardRouter) cli(t *ShardTable, shard int) (*DBCli, error) {
+	key, err := t.dbKey(shard)
+	if err != nil {
+		return nil, err
+	}
+	cli := r.mgr.GetCli(key)
+	if cli == nil {
+		return nil, errors.New("shard db not found: " + key)
+	}
+	return cli, nil
+}
+
+// Route 根据分片键返回对应的 DBCli 和物理表名
+func (r *ShardRouter) Route(table string, key any) (*DBCli, string, error) {
+	t, err := r.table(table)
+	if err != nil {
+		return nil, "", err
+	}
+	shard, err := t.Rule(key)
+	if err != nil {
+		return nil, "", err
+	}
+	cli, err := r.cli(t, shard)
+	if err != nil {
+		return nil, "", err
+	}
+	return cli, t.tableName(shard), nil
+}
+
+// RouteData 从数据中取分片键并路由
+func (r *ShardRouter) RouteData(table string, data map[string]interface{}) (*DBCli, string, error) {
+	t, err := r.table(table)
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := cyutil.GetValue(data, []string{t.Key}, true)
+	if err != nil || key == nil {
+		return nil, "", fmt.Errorf("shard key %s not found for table %s", t.Key, table)
+	}
+	return r.Route(table, key)
+}
+
+// Rewrite 将构建器中的逻辑表名（包括子查询中的表）改写为分片键对应的物理表名，返回改写后的构建器和该分片的 DBCli
+// 传入的构建器不会被修改；构建器中出现的多个分片表使用同一个分片序号
+func (r *ShardRouter) Rewrite(builder SQLBuilder, key any) (SQLBuilder, *DBCli, error) {
+	var cli *DBCli
+	var routeErr error
+	rename := func(ts TableSource) TableSource {
+		tb, ok := ts.(*Table)
+		if !ok || tb == nil {
+			return ts
+		}
+		t, err := r.table(tb.Name)
+		if err != nil {
+			return ts
+		}
+		shard, err := t.Rule(key)
+		if err == nil && cli == nil {
+			cli, err = r.cli(t, shard)
+		}
+		if err != nil {
+			// 保留第一个错误，之后的表即使路由成功也不再改写
+			if routeErr == nil {
+				routeErr = err
+			}
+			return ts
+		}
+		if routeErr != nil {
+			return ts
+		}
+		return &Table{Schema: tb.Schema, Name: t.tableName(shard), Alias: tb.Alias}
+	}
+	qb, ok := builder.(*sqlBuilder)
+	if !ok {
+		return nil, nil, errors.New("unsupported builder")
+	}
+	var rewrite func(b *sqlBuilder) *sqlBuilder
+	rewrite = func(b *sqlBuilder) *sqlBuilder {
+		// withSubBuilders 已复制 joins，改写副本不影响原构建器
+		cp := b.withSubBuilders(rewrite)
+		cp.table = rename(cp.table)
+		cp.from = rename(cp.from)
+		for i := range cp.joins {
+			cp.joins[i].table = rename(cp.joins[i].table)
+		}
+		if len(cp.delTables) > 0 {
+			cp.delTables = slices.Clone(cp.delTables)
+			for i := range cp.delTables {
+				cp.delTables[i] = rename(cp.delTables[i])
+			}
+		}
+		return cp
+	}
+	rb := rewrite(qb)
+	if routeErr != nil {
+		return nil, nil, routeErr
+	}
+	if cli == nil {
+		return nil, nil, errors.New("no shard table found in builder")
+	}
+	return rb, cli, nil
+}
+
+// Insert 按数据中的分片键写入对应分片
+func (r *ShardRouter) Insert(table string, data map[string]interface{}, cc ...FuncWithBuilder) (int64, error) {
+	cli, name, err := r.RouteData(table, data)
+	if err != nil {
+		return 0, err
+	}
+	return cli.Insert(name, data, cc...)
+}
+
+// shardQuery 扇出查询的分片
+type shardQuery struct {
+	cli   *DBCli
+	table string
+}
+
+func (r *ShardRouter) shardQueries(table string) ([]shardQuery, error) {
+	t, err := r.table(table)
+	if err != nil {
+		return nil, err
+	}
+	r2 := make([]shardQuery, 0, len(t.Shards))
+	for _, shard := range t.Shards {
+		cli, err := r.cli(t, shard)
+		if err != nil {
+			return nil, err
+		}
+		r2 = append(r2, shardQuery{cli: cli, table: t.tableName(shard)})
+	}
+	return r2, nil
+}
+
+func fanOut[T any](queries []shardQuery, fn func(q shardQuery) (T, error)) ([]T, error) {
+	results := make([]T, len(queries))
+	errs := make([]error, len(queries))
+	var wg sync.WaitGroup
+	for i, q := range queries {
+		wg.Add(1)
+		go func(i int, q shardQuery) {
+			defer wg.Done()
+			results[i], errs[i] = fn(q)
+		}(i, q)
+	}
+	wg.Wait()
+	return results, errors.Join(errs...)
+}
+
+// ListAll 在所有分片上执行查询并合并结果
+// 每个分片不带 offset 查询前 offset+limit 条（未指定 limit 时查询全部），合并后按 ORDER BY 排序再截取 offset/limit
+func (r *ShardRouter) ListAll(table string, data map[string]interface{}, cc ...FuncWithBuilder) ([]map[string]interface{}, error) {
+	queries, err := r.shardQueries(table)
+	if err != nil {
+		return nil, err
+	}
+	probe := Builder().Table(table).(*sqlBuilder)
+	for _, c := range cc {
+		c(probe)
+	}
+	limit, err := shardPageValue(probe.limitValue, data, -1)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := shardPageValue(probe.offsetValue, data, 0)
+	if err != nil {
+		return nil, err
+	}
+	orderBy := probe.orderBy
+	for _, ob := range orderBy {
+		for _, col := range ob.Column {
+			if orderColumn(col) == "" {
+				return nil, fmt.Errorf("cannot merge shard results ordered by %T, order by a column or alias", col)
+			}
+		}
+	}
+	// 分片上不能跳过 offset 条，否则合并后会丢掉其他分片中排在前面的数据
+	shardCC := append(slices.Clone(cc), func(b SQLBuilder) SQLBuilder {
+		b.(*sqlBuilder).offsetValue = ""
+		if limit >= 0 {
+			return b.Limit(offset + limit)
+		}
+		return b
+	})
+	parts, err := fanOut(queries, func(q shardQuery) ([]map[string]interface{}, error) {
+		return q.cli.List(q.table, data, shardCC...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var rows []map[string]interface{}
+	for _, p := range parts {
+		rows = append(rows, p...)
+	}
+	if len(orderBy) > 0 {
+		sortRows(rows, orderBy)
+	}
+	if offset > 0 {
+		if offset >= len(rows) {
+			return []map[string]interface{}{}, nil
+		}
+		rows = rows[offset:]
+	}
+	if limit >= 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+// shardPageValue 解析 limit/offset，:name 占位符从 data 中取值，未设置时返回 def，无法解析时返回错误
+func shardPageValue(v string, data map[string]interface{}, def int) (int, error) {
+	if v == "" {
+		return def, nil
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		return n, nil
+	}
+	if name, ok := strings.CutPrefix(v, ":"); ok {
+		if x, found := data[name]; found {
+			if n, err := strconv.Atoi(cyutil.ToString(x)); err == nil {
+				return n, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("cannot resolve limit/offset %q for shard merge", v)
+}
+
+// CountAll 统计所有分片的记录数
+func (r *ShardRouter) CountAll(table string, data map[string]interface{}, cc ...FuncWithBuilder) (int64, error) {
+	queries, err := r.shardQueries(table)
+	if err != nil {
+		return 0, err
+	}
+	counts, err := fanOut(queries, func(q shardQuery) (int64, error) {
+		return q.cli.Count(q.table, data, cc...)
+	})
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, c := range counts {
+		total += c
+	}
+	return total, nil
+}
+
+// orderColumn 返回排序表达式在结果行中的列名，去掉表名限定与引号，不是列或别名时返回空
+func orderColumn(e Expression) string {
+	v, ok := e.(*SimpleExpr)
+	if !ok {
+		return ""
+	}
+	if v.Alias != "" {
+		return v.Alias
+	}
+	name := v.Field
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.Trim(name, "`\"[]")
+}
+
+func rowValue(row map[string]interface{}, col string) interface{} {
+	if v, ok := row[col]; ok {
+		return v
+	}
+	for k, v := range row {
+		if strings.EqualFold(k, col) {
+			return v
+		}
+	}
+	return nil
+}
+
+func compareValue(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+	as, bs := cyutil.ToString(a), cyutil.ToString(b)
+	af, err1 := strconv.ParseFloat(as, 64)
+	bf, err2 := strconv.ParseFloat(bs, 64)
+	if err1 == nil && err2 == nil {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(as, bs)
+}
+
+func sortRows(rows []map[string]interface{}, orderBy []OrderBy) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, ob := range orderBy {
+			desc := strings.EqualFold(ob.Direction, "DESC")
+			for _, col := range ob.Column {
+				name := orderColumn(col)
+				if name == "" {
+					continue
+				}
+				c := compareValue(rowValue(rows[i], name), rowValue(rows[j], name))
+				if c == 0 {
+					continue
+				}
+				if desc {
+					return c > 0
+				}
+				return c < 0
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,91 @@
+package sqlmysql
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/fj1981/infrakit/pkg/cyutil"
+	"github.com/go-sql-driver/mysql"
+)
+
+// MaxBindParams MySQL 预编译语句最多 65535 个占位符
+func (s *mysqlSql) MaxBindParams() int {
+	return 65535
+}
+
+var mysqlLoadEscaper = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r", "\x00", "\\0")
+
+func loadDataValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "\\N"
+	case []byte:
+		return mysqlLoadEscaper.Replace(string(val))
+	case string:
+		return mysqlLoadEscaper.Replace(val)
+	case time.Time:
+		return val.Format("2006-01-02 15:04:05.999999")
+	case bool:
+		if val {
+			return "1"
+		}
+		return "0"
+	default:
+		return mysqlLoadEscaper.Replace(cyutil.ToString(val))
+	}
+}
+
+// BulkLoad 使用 LOAD DATA LOCAL INFILE 从 Reader 导入，服务端未开启 local_infile 时返回 ErrBulkNativeUnavailable
+func (s *mysqlSql) BulkLoad(cli *DBCli, table string, columns []string, rows [][]any, opt *BulkLoadOptions) (int64, error) {
+	pr, pw := io.Pipe()
+	name := "cydb_bulk_" + cyutil.NanoID()
+	mysql.RegisterReaderHandler(name, func() io.Reader { return pr })
+	defer mysql.DeregisterReaderHandler(name)
+
+	go func() {
+		w := bufio.NewWriter(pw)
+		var sent int64
+		for _, chunk := range opt.BulkChunks(rows, 0, 0) {
+			for _, row := range chunk {
+				for i, v := range row {
+					if i > 0 {
+						w.WriteByte('\t')
+					}
+					w.WriteString(loadDataValue(v))
+				}
+				w.WriteByte('\n')
+			}
+			if err := w.Flush(); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			sent += int64(len(chunk))
+			opt.Report(sent, int64(len(rows)))
+		}
+		pw.Close()
+	}()
+
+	cols := make([]string, len(columns))
+	for i, c := range columns {
+		cols[i] = "`" + strings.ReplaceAll(c, "`", "``") + "`"
+	}
+	query := fmt.Sprintf("LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE %s CHARACTER SET utf8mb4 "+
+		"FIELDS TERMINATED BY '\\t' ESCAPED BY '\\\\' LINES TERMINATED BY '\\n' (%s)",
+		name, s.EscapeTableName(table), strings.Join(cols, ", "))
+	affected, err := cli.Excute(query)
+	// 提前失败时关闭读端，避免写入协程阻塞
+	pr.Close()
+	if err != nil {
+		var myErr *mysql.MySQLError
+		if errors.As(err, &myErr) && (myErr.Number == 1148 || myErr.Number == 3948) {
+			return 0, errors.Join(ErrBulkNativeUnavailable, err)
+		}
+		return 0, err
+	}
+	return affected, nil
+}
@@ -0,0 +1,16 @@
+package sqlmysql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadDataValue(t *testing.T) {
+	assert.Equal(t, "\\N", loadDataValue(nil))
+	assert.Equal(t, "a\\tb\\nc\\\\d", loadDataValue("a\tb\nc\\d"))
+	assert.Equal(t, "1", loadDataValue(true))
+	assert.Equal(t, "42", loadDataValue(42))
+	assert.Equal(t, "2024-01-02 03:04:05", loadDataValue(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+}
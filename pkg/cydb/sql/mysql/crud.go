@@ -56,6 +56,12 @@ func (s *mysqlSql) SupportsBatch() bool {
 // BuildReplaceSQL implements DatabaseTransformer for MySQL
 // MySQL 支持原生 REPLACE INTO 语法
 func (s *mysqlSql) BuildReplaceSQL(tableName string, bs BuildSql) (sql string, paramOrder []string, err error) {
+	if _, _, ok, err := bs.GetConflictGuard(s); err != nil || ok {
+		if err == nil {
+			err = TenantReplaceError(tableName)
+		}
+		return "", nil, err
+	}
 	// 构建 MySQL REPLACE INTO 语句
 	var sb strings.Builder
 	sb.WriteString("REPLACE INTO ")
@@ -67,14 +73,13 @@ func (s *mysqlSql) BuildReplaceSQL(tableName string, bs BuildSql) (sql string, p
 		return "", nil, err
 	}
 	sb.WriteString(columnNames)
-	sb.WriteString(") VALUES (")
+	sb.WriteString(") VALUES ")
 
 	columnValues, fields, err := bs.GetValuesString(s, true)
 	if err != nil {
 		return "", nil, err
 	}
 	sb.WriteString(columnValues)
-	sb.WriteString(")")
 
 	return sb.String(), fields, nil
 }
@@ -106,6 +111,30 @@ func (s *mysqlSql) BuildUpsertSQL(tableName string, bs BuildSql) (sql string, pa
 	sb.WriteString(columnValues)
 
 	// 构建 ON DUPLICATE KEY UPDATE 部分
+	column, value, guarded, err := bs.GetConflictGuard(s)
+	if err != nil {
+		return "", nil, err
+	}
+	if guarded {
+		// ON DUPLICATE KEY UPDATE 不支持 WHERE，已有行不满足条件时各列保持原值
+		items, err := bs.GetAssignItems(s, true)
+		if err != nil {
+			return "", nil, err
+		}
+		var set []string
+		for _, item := range items {
+			if item.Column == column {
+				continue
+			}
+			set = append(set, fmt.Sprintf("%s = IF(%s = %s, %s, %s)", item.Column, column, value, item.Value, item.Column))
+			paramOrder = append(paramOrder, item.Fields...)
+		}
+		if len(set) > 0 {
+			sb.WriteString(" ON DUPLICATE KEY UPDATE ")
+			sb.WriteString(strings.Join(set, ", "))
+		}
+		return sb.String(), paramOrder, nil
+	}
 	updateAssignStr, updateFields, err := bs.GetAssignString(s, false)
 	if err != nil {
 		return "", nil, err
@@ -91,7 +91,7 @@ func (s *mysqlSql) GetDDLSqlFunc(funcName DDLSqlFuncName) (DDLSqlFunc, error) {
 func (s *mysqlSql) GetCreateTableSql(cli DatabaseClient, database, tableName string) (string, error) {
 	fullTableName := fmt.Sprintf("`%s`.`%s`", database, tableName)
 	sql := fmt.Sprintf("SHOW CREATE TABLE %s", fullTableName)
-	v, err := cli.Query(sql)
+	v, err := InternalQuery(cli, sql)
 	if err != nil {
 		return "", err
 	}
@@ -112,7 +112,7 @@ func (s *mysqlSql) GetCreateTableSql(cli DatabaseClient, database, tableName str
 
 func (s *mysqlSql) GetCreateTriggerSql(cli DatabaseClient, database, tableName string) ([]string, error) {
 	triggerSQL := fmt.Sprintf("SHOW TRIGGERS FROM `%s` WHERE `Table` = '%s'", database, tableName)
-	vTriggers, err := cli.Query(triggerSQL)
+	vTriggers, err := InternalQuery(cli, triggerSQL)
 	if err != nil {
 		return nil, err
 	}
@@ -120,7 +120,7 @@ func (s *mysqlSql) GetCreateTriggerSql(cli DatabaseClient, database, tableName s
 	for _, trigger := range vTriggers {
 		triggerName := cyutil.ToStr(trigger["Trigger"])
 		createTriggerSQL := fmt.Sprintf("SHOW CREATE TRIGGER `%s`.`%s`", database, triggerName)
-		vt, err := cli.Query(createTriggerSQL)
+		vt, err := InternalQuery(cli, createTriggerSQL)
 		if err != nil {
 			return nil, err
 		}
@@ -137,7 +137,7 @@ func (s *mysqlSql) GetCreateTriggerSql(cli DatabaseClient, database, tableName s
 
 func (s *mysqlSql) GetCreateViewSql(cli DatabaseClient, database, viewName string) (string, error) {
 	createViewSQL := fmt.Sprintf("SHOW CREATE VIEW `%s`;", viewName)
-	result, err := cli.Query(createViewSQL)
+	result, err := InternalQuery(cli, createViewSQL)
 	if err != nil {
 		return "", err
 	}
@@ -160,7 +160,7 @@ func (s *mysqlSql) GetCreateViewSql(cli DatabaseClient, database, viewName strin
 
 func (s *mysqlSql) GetCreateProcedureSql(cli DatabaseClient, database, procName string) (string, error) {
 	createProcSQL := fmt.Sprintf("SHOW CREATE PROCEDURE `%s`;", procName)
-	result, err := cli.Query(createProcSQL)
+	result, err := InternalQuery(cli, createProcSQL)
 	if err != nil {
 		return "", err
 	}
@@ -182,7 +182,7 @@ func (s *mysqlSql) GetCreateProcedureSql(cli DatabaseClient, database, procName
 func (s *mysqlSql) GetCreateFunctionSql(cli DatabaseClient, database, funcName string) (string, error) {
 	// 获取函数创建SQL
 	createFuncSQL := fmt.Sprintf("SHOW CREATE FUNCTION `%s`;", funcName)
-	result, err := cli.Query(createFuncSQL)
+	result, err := InternalQuery(cli, createFuncSQL)
 	if err != nil {
 		return "", err
 	}
@@ -206,7 +206,7 @@ func (s *mysqlSql) GetCreateFunctionSql(cli DatabaseClient, database, funcName s
 func (s *mysqlSql) GetCreateEventSql(cli DatabaseClient, database, eventName string) (string, error) {
 	// 获取事件创建SQL
 	createEventSQL := fmt.Sprintf("SHOW CREATE EVENT `%s`;", eventName)
-	result, err := cli.Query(createEventSQL)
+	result, err := InternalQuery(cli, createEventSQL)
 	if err != nil {
 		return "", err
 	}
@@ -22,7 +22,7 @@ func init() {
 
 func (s *mysqlSql) GetTableColumns(cli DatabaseClient, database, tableName string) ([]*DBColumn, error) {
 	query := fmt.Sprintf("SELECT COLUMN_NAME, DATA_TYPE,COLUMN_KEY FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = '%s' AND TABLE_NAME = '%s'", database, tableName)
-	rows, err := cli.Query(query)
+	rows, err := InternalQuery(cli, query)
 	if err != nil {
 		return nil, err
 	}
@@ -34,27 +34,31 @@ func (s *mysqlSql) GetTableColumns(cli DatabaseClient, database, tableName strin
 		dataType = cyutil.ToStr(row["DATA_TYPE"])
 		columnKey = cyutil.ToStr(row["COLUMN_KEY"])
 
-		// Map MySQL data types to Go reflect.Kind.
-		switch strings.ToLower(dataType) {
-		case "tinyint", "smallint", "mediumint", "int", "bigint":
-			columns = append(columns, &DBColumn{Name: colName, DBFieldType: DBFieldTypeInt, ColumnKey: columnKey, OrgDataType: dataType})
-		case "float", "double", "decimal":
-			columns = append(columns, &DBColumn{Name: colName, DBFieldType: DBFieldTypeFloat, ColumnKey: columnKey, OrgDataType: dataType})
-		case "date", "datetime", "timestamp":
-			columns = append(columns, &DBColumn{Name: colName, DBFieldType: DBFieldTypeTime, ColumnKey: columnKey, OrgDataType: dataType})
-		case "blob", "binary", "varbinary", "longblob", "mediumblob", "tinyblob":
-			columns = append(columns, &DBColumn{Name: colName, DBFieldType: DBFieldTypeBinary, ColumnKey: columnKey, OrgDataType: dataType})
-		case "json":
-			columns = append(columns, &DBColumn{Name: colName, DBFieldType: DBFieldTypeJson, ColumnKey: columnKey, OrgDataType: dataType})
-		case "bit":
-			columns = append(columns, &DBColumn{Name: colName, DBFieldType: DBFieldTypeBit, ColumnKey: columnKey, OrgDataType: dataType})
-		default:
-			columns = append(columns, &DBColumn{Name: colName, DBFieldType: DBFieldTypeString, ColumnKey: columnKey, OrgDataType: dataType})
-		}
+		columns = append(columns, &DBColumn{Name: colName, DBFieldType: fieldType(dataType), ColumnKey: columnKey, OrgDataType: dataType})
 	}
 	return columns, nil
 }
 
+// fieldType 将 MySQL 数据类型映射为 DBFieldType
+func fieldType(dataType string) DBFieldType {
+	switch strings.ToLower(dataType) {
+	case "tinyint", "smallint", "mediumint", "int", "bigint":
+		return DBFieldTypeInt
+	case "float", "double", "decimal":
+		return DBFieldTypeFloat
+	case "date", "datetime", "timestamp":
+		return DBFieldTypeTime
+	case "blob", "binary", "varbinary", "longblob", "mediumblob", "tinyblob":
+		return DBFieldTypeBinary
+	case "json":
+		return DBFieldTypeJson
+	case "bit":
+		return DBFieldTypeBit
+	default:
+		return DBFieldTypeString
+	}
+}
+
 func (s *mysqlSql) IsTableExist(cli DatabaseClient, tableName string) (bool, error) {
 	sql := "SELECT COUNT(1) as count FROM information_schema.tables WHERE table_name = :tableName AND table_schema = :database;"
 	params := map[string]interface{}{"tableName": tableName, "database": cli.Database()}
@@ -0,0 +1,16 @@
+package sqlmysql
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// IsTransientError 识别 MySQL 死锁(1213)与锁等待超时(1205)
+func (s *mysqlSql) IsTransientError(err error) bool {
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		return myErr.Number == 1213 || myErr.Number == 1205
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+package sqlmysql
+
+import (
+	"fmt"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+)
+
+var _ SkipLocker = (*mysqlSql)(nil)
+
+// SkipLockedSQL implements cydb.SkipLocker.
+func (s *mysqlSql) SkipLockedSQL(query string, limit int) (string, bool) {
+	return fmt.Sprintf("%s LIMIT %d FOR UPDATE SKIP LOCKED", query, limit), true
+}
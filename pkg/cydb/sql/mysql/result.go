@@ -0,0 +1,16 @@
+package sqlmysql
+
+import (
+	"database/sql"
+	"strings"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+)
+
+var _ ResultTypeMapper = (*mysqlSql)(nil)
+
+// ResultFieldType implements cydb.ResultTypeMapper.
+// 驱动返回 INT、UNSIGNED BIGINT、DECIMAL、JSON 等大写类型名
+func (s *mysqlSql) ResultFieldType(ct *sql.ColumnType) DBFieldType {
+	return fieldType(strings.TrimPrefix(strings.ToLower(ct.DatabaseTypeName()), "unsigned "))
+}
@@ -0,0 +1,116 @@
+package sqlmysql
+
+import (
+	"strings"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/fj1981/infrakit/pkg/cyutil"
+)
+
+var (
+	_ TableSchemaInspector = (*mysqlSql)(nil)
+	_ TableLister          = (*mysqlSql)(nil)
+)
+
+func (s *mysqlSql) GetTableSchema(cli DatabaseClient, database, tableName string) (*TableSchema, error) {
+	ts := &TableSchema{Schema: database, Name: tableName}
+	row, err := InternalQueryOne(cli, "SELECT TABLE_COMMENT AS comment FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?", database, tableName)
+	if err != nil {
+		return nil, err
+	}
+	ts.Comment = cyutil.GetStr(row, "comment", true)
+
+	rows, err := InternalQuery(cli, `SELECT COLUMN_NAME AS name, ORDINAL_POSITION AS pos, DATA_TYPE AS data_type, COLUMN_TYPE AS column_type,
+		IS_NULLABLE AS nullable, COLUMN_DEFAULT AS dflt, CHARACTER_MAXIMUM_LENGTH AS len, NUMERIC_PRECISION AS prec,
+		NUMERIC_SCALE AS scale, EXTRA AS extra, COLUMN_COMMENT AS comment
+		FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION`, database, tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		dataType := cyutil.GetStr(r, "data_type", true)
+		ts.Columns = append(ts.Columns, &SchemaColumn{
+			Name:          cyutil.GetStr(r, "name", true),
+			Position:      cyutil.GetInt(r, "pos", true),
+			DataType:      dataType,
+			ColumnType:    cyutil.GetStr(r, "column_type", true),
+			FieldType:     fieldType(dataType),
+			Nullable:      cyutil.GetStr(r, "nullable", true) == "YES",
+			Default:       NullableString(r["dflt"]),
+			Length:        cyutil.GetInt64(r, "len", true),
+			Precision:     cyutil.GetInt64(r, "prec", true),
+			Scale:         cyutil.GetInt64(r, "scale", true),
+			AutoIncrement: strings.Contains(strings.ToLower(cyutil.GetStr(r, "extra", true)), "auto_increment"),
+			Comment:       cyutil.GetStr(r, "comment", true),
+		})
+	}
+
+	rows, err = InternalQuery(cli, `SELECT tc.CONSTRAINT_NAME AS name, tc.CONSTRAINT_TYPE AS type, kcu.COLUMN_NAME AS col,
+		kcu.REFERENCED_TABLE_SCHEMA AS ref_schema, kcu.REFERENCED_TABLE_NAME AS ref_table, kcu.REFERENCED_COLUMN_NAME AS ref_col,
+		rc.UPDATE_RULE AS on_update, rc.DELETE_RULE AS on_delete
+		FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc
+		LEFT JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE kcu
+			ON kcu.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA AND kcu.TABLE_NAME = tc.TABLE_NAME AND kcu.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+		LEFT JOIN INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc
+			ON rc.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA AND rc.TABLE_NAME = tc.TABLE_NAME AND rc.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+		WHERE tc.TABLE_SCHEMA = ? AND tc.TABLE_NAME = ?
+		ORDER BY tc.CONSTRAINT_NAME, kcu.ORDINAL_POSITION`, database, tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		name := cyutil.GetStr(r, "name", true)
+		col := cyutil.GetStr(r, "col", true)
+		tp := ConstraintType(cyutil.GetStr(r, "type", true))
+		if tp == ConstraintForeignKey {
+			fk := ts.AppendForeignKeyColumn(name, col, cyutil.GetStr(r, "ref_col", true))
+			fk.RefSchema = cyutil.GetStr(r, "ref_schema", true)
+			fk.RefTable = cyutil.GetStr(r, "ref_table", true)
+			fk.OnUpdate = cyutil.GetStr(r, "on_update", true)
+			fk.OnDelete = cyutil.GetStr(r, "on_delete", true)
+			continue
+		}
+		ts.AppendConstraintColumn(name, tp, col)
+	}
+	// CHECK_CONSTRAINTS 自 MySQL 8.0.16 起提供，低版本忽略
+	if rows, err := InternalQuery(cli, `SELECT cc.CONSTRAINT_NAME AS name, cc.CHECK_CLAUSE AS clause
+		FROM INFORMATION_SCHEMA.CHECK_CONSTRAINTS cc
+		JOIN INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc
+			ON tc.CONSTRAINT_SCHEMA = cc.CONSTRAINT_SCHEMA AND tc.CONSTRAINT_NAME = cc.CONSTRAINT_NAME
+		WHERE tc.TABLE_SCHEMA = ? AND tc.TABLE_NAME = ?`, database, tableName); err == nil {
+		for _, r := range rows {
+			c := ts.AppendConstraintColumn(cyutil.GetStr(r, "name", true), ConstraintCheck, "")
+			c.Check = cyutil.GetStr(r, "clause", true)
+		}
+	}
+	ts.MarkPrimaryKey()
+
+	rows, err = InternalQuery(cli, `SELECT INDEX_NAME AS name, COLUMN_NAME AS col, NON_UNIQUE AS non_unique
+		FROM INFORMATION_SCHEMA.STATISTICS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND INDEX_NAME <> 'PRIMARY'
+		ORDER BY INDEX_NAME, SEQ_IN_INDEX`, database, tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		ts.AppendIndexColumn(cyutil.GetStr(r, "name", true), cyutil.GetStr(r, "col", true), cyutil.GetInt(r, "non_unique", true) == 0)
+	}
+
+	rows, err = InternalQuery(cli, `SELECT TRIGGER_NAME AS name, ACTION_TIMING AS timing, EVENT_MANIPULATION AS event, ACTION_STATEMENT AS body
+		FROM INFORMATION_SCHEMA.TRIGGERS WHERE EVENT_OBJECT_SCHEMA = ? AND EVENT_OBJECT_TABLE = ?`, database, tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		ts.Triggers = append(ts.Triggers, &SchemaTrigger{
+			Name:       cyutil.GetStr(r, "name", true),
+			Timing:     cyutil.GetStr(r, "timing", true),
+			Event:      cyutil.GetStr(r, "event", true),
+			Definition: cyutil.GetStr(r, "body", true),
+		})
+	}
+	return ts, nil
+}
+
+func (s *mysqlSql) GetTableNames(cli DatabaseClient, database string) ([]string, error) {
+	return QueryNames(cli, "SELECT TABLE_NAME AS name FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME", database)
+}
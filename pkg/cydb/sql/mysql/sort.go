@@ -28,7 +28,7 @@ func (s *mysqlSql) SortTables(cli DatabaseClient, tableNames []string) ([]*SqlCo
 	for _, tableName := range tableNames {
 		fullTableName := fmt.Sprintf("`%s`.`%s`", cli.Database(), tableName)
 		sql := fmt.Sprintf("SHOW CREATE TABLE %s", fullTableName)
-		v, err := cli.Query(sql)
+		v, err := InternalQuery(cli, sql)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,70 @@
+package sqlmysql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantScope(t *testing.T) {
+	policy := &TenantPolicy{Tables: []string{"orders", "items"}}
+	dt, ok := GetSqlTransformer("mysql")
+	require.True(t, ok)
+
+	_, err := Builder().TenantPolicy(policy).Table("orders").Where(EQ("id")).Build(dt)
+	assert.True(t, errors.Is(err, ErrTenantRequired))
+
+	b := Builder().TenantPolicy(policy).Table(ALIAS("orders", "o")).Tenant(7).
+		LeftJoin(ALIAS("items", "i"), ON("o.id", "i.order_id")).
+		Where(EQ("o.id"))
+	r, err := b.Build(dt)
+	require.NoError(t, err)
+	t.Log(r.SQL)
+	assert.Contains(t, r.SQL, "i.tenant_id = 7")
+	assert.Contains(t, r.SQL, "o.tenant_id = 7")
+	r2, err := b.Build(dt)
+	require.NoError(t, err)
+	assert.Equal(t, r.SQL, r2.SQL, "build must not accumulate tenant conditions")
+
+	sub := Builder().Table("items").Select("order_id")
+	r, err = Builder().TenantPolicy(policy).Table("orders").Tenant("t-1").Where(IN("id", []any{SUBQUERY(sub)})).Build(dt)
+	require.NoError(t, err)
+	t.Log(r.SQL)
+	assert.Equal(t, 2, strings.Count(r.SQL, "tenant_id = 't-1'"))
+	// 子查询构建器不被修改，可以在其他租户下复用
+	r, err = Builder().TenantPolicy(policy).Table("orders").Tenant("t-2").Where(IN("id", []any{SUBQUERY(sub)})).Build(dt)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(r.SQL, "tenant_id = 't-2'"))
+	// 子查询未设置策略时继承外层策略
+	_, err = sub.Build(dt)
+	assert.NoError(t, err)
+	_, err = sub.TenantPolicy(policy).Build(dt)
+	assert.True(t, errors.Is(err, ErrTenantRequired))
+
+	upsert := Builder().TenantPolicy(policy).Table("orders").Tenant(7).Fields([]string{"id", "name", "tenant_id"}).PrimaryKeys("id")
+	r, err = upsert.Type(SQLOperationUpsert).Build(dt)
+	require.NoError(t, err)
+	t.Log(r.SQL)
+	assert.Contains(t, r.SQL, "ON DUPLICATE KEY UPDATE id = IF(tenant_id = 7, :id, id), name = IF(tenant_id = 7, :name, name)")
+	assert.NotContains(t, r.SQL, ":tenant_id")
+	_, err = upsert.Type(SQLOperationReplace).Build(dt)
+	assert.True(t, errors.Is(err, ErrTenantReplace))
+
+	r, err = Builder().TenantPolicy(policy).Table("orders").Tenant(7).Fields([]string{"id", "tenant_id"}).Type(SQLOperationInsert).Build(dt)
+	require.NoError(t, err)
+	t.Log(r.SQL)
+	assert.Contains(t, r.SQL, "7)")
+	assert.NotContains(t, r.SQL, ":tenant_id")
+
+	_, err = Builder().TenantPolicy(policy).Table("orders").Tenant("x' OR '1'='1").Build(dt)
+	assert.Error(t, err)
+
+	_, err = Builder().TenantPolicy(policy).Table("orders").Tenant(AllTenants).Build(dt)
+	assert.NoError(t, err)
+	_, err = Builder().TenantPolicy(policy).Table("users").Build(dt)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,69 @@
+package sqloracle
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+)
+
+// MaxBindParams Oracle 单条语句最多 65535 个绑定参数
+func (s *oracleSql) MaxBindParams() int {
+	return 65535
+}
+
+// BulkLoad 使用数组绑定，每批执行一次 INSERT
+func (s *oracleSql) BulkLoad(cli *DBCli, table string, columns []string, rows [][]any, opt *BulkLoadOptions) (int64, error) {
+	cols := make([]string, len(columns))
+	holders := make([]string, len(columns))
+	for i, c := range columns {
+		cols[i] = s.EscapeColumnName(c)
+		holders[i] = fmt.Sprintf(":%d", i+1)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", s.EscapeTableName(table), strings.Join(cols, ", "), strings.Join(holders, ", "))
+	var loaded int64
+	for _, chunk := range opt.BulkChunks(rows, 0, 0) {
+		args := make([]any, len(columns))
+		for i := range columns {
+			args[i] = columnArray(chunk, i)
+		}
+		if _, err := InternalExcute(cli, query, args...); err != nil {
+			return loaded, err
+		}
+		loaded += int64(len(chunk))
+		opt.Report(loaded, int64(len(rows)))
+	}
+	return loaded, nil
+}
+
+// columnArray 构造列数组，值类型一致且不含 NULL 时使用具体类型的切片，便于驱动推断绑定类型
+func columnArray(rows [][]any, col int) any {
+	var tp reflect.Type
+	typed := true
+	for _, row := range rows {
+		if row[col] == nil {
+			typed = false
+			break
+		}
+		t := reflect.TypeOf(row[col])
+		if tp == nil {
+			tp = t
+		} else if tp != t {
+			typed = false
+			break
+		}
+	}
+	if !typed || tp == nil {
+		r := make([]any, len(rows))
+		for i, row := range rows {
+			r[i] = row[col]
+		}
+		return r
+	}
+	r := reflect.MakeSlice(reflect.SliceOf(tp), len(rows), len(rows))
+	for i, row := range rows {
+		r.Index(i).Set(reflect.ValueOf(row[col]))
+	}
+	return r.Interface()
+}
@@ -58,74 +58,103 @@ func (s *oracleSql) SupportsBatch() bool {
 }
 
 // BuildReplaceSQL implements DatabaseTransformer for Oracle
-// Oracle 使用 MERGE 语句实现 REPLACE 功能
+// Oracle 使用 MERGE 语句实现 REPLACE 功能：
+// MERGE INTO t target USING (SELECT :a AS a, ... FROM DUAL) source ON (target.pk = source.pk)
+// WHEN MATCHED THEN UPDATE SET target.a = source.a WHEN NOT MATCHED THEN INSERT (a, ...) VALUES (source.a, ...)
 func (s *oracleSql) BuildReplaceSQL(tableName string, bs BuildSql) (sql string, paramOrder []string, err error) {
-	var sb strings.Builder
-	paramOrder = make([]string, 0)
-	tableEscaped := s.EscapeTableName(tableName)
-
-	// 获取字段列表
-	columnNames, err := bs.GetFieldsString(s, false)
+	items, err := bs.GetAssignItems(s, false)
 	if err != nil {
 		return "", nil, err
 	}
-
-	// 获取主键字段
-	primaryKeys, err := bs.GetPkFieldsString(s, false)
-	if err != nil {
-		return "", nil, err
+	if len(items) == 0 {
+		return "", nil, fmt.Errorf("no columns")
 	}
-
-	// 获取值列表
-	columnValues, fields, err := bs.GetValuesString(s, true)
+	updates, err := bs.GetAssignItems(s, true)
 	if err != nil {
 		return "", nil, err
 	}
-	paramOrder = append(paramOrder, fields...)
 
-	// 构建 MERGE 语句
+	var sb strings.Builder
 	sb.WriteString("MERGE INTO ")
-	sb.WriteString(tableEscaped)
+	sb.WriteString(s.EscapeTableName(tableName))
 	sb.WriteString(" target USING (SELECT ")
-
-	// 构建 SELECT 子句 - 使用占位符值
-	sb.WriteString(columnValues)
+	values := map[string]string{}
+	for i, item := range items {
+		if i != 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(item.Value)
+		sb.WriteString(" AS ")
+		sb.WriteString(item.Column)
+		paramOrder = append(paramOrder, item.Fields...)
+		values[item.Column] = item.Value
+	}
 	sb.WriteString(" FROM DUAL) source ON (")
 
-	// 构建 ON 条件（主键匹配）
-	if primaryKeys == "" {
-		// 如果没有主键，使用所有字段
-		sb.WriteString(columnNames)
-	} else {
-		sb.WriteString(primaryKeys)
+	// 没有主键时按全部字段匹配，此时不再更新
+	var on []string
+	for _, item := range items {
+		if item.PK {
+			on = append(on, "target."+item.Column+" = source."+item.Column)
+		}
 	}
-	sb.WriteString(")")
-
-	// 获取更新字段
-	updateAssignStr, updateFields, err := bs.GetAssignString(s, false)
+	hasPK := len(on) > 0
+	if !hasPK {
+		for _, item := range items {
+			on = append(on, "target."+item.Column+" = source."+item.Column)
+		}
+	}
+	// 已有行不满足条件时视为不匹配，插入时因主键冲突失败而不是覆盖该行
+	guardColumn, guardValue, guarded, err := bs.GetConflictGuard(s)
 	if err != nil {
 		return "", nil, err
 	}
+	if guarded {
+		on = append(on, "target."+guardColumn+" = "+guardValue)
+	}
+	sb.WriteString(strings.Join(on, " AND "))
+	sb.WriteString(")")
 
-	// WHEN MATCHED THEN UPDATE
-	if updateAssignStr != "" {
+	// ON 中引用的列不能在 WHEN MATCHED 中更新（ORA-38104）
+	var set []string
+	var setFields []string
+	for _, item := range updates {
+		if !hasPK || item.PK || (guarded && item.Column == guardColumn) {
+			continue
+		}
+		if v, ok := values[item.Column]; ok && v == item.Value {
+			set = append(set, "target."+item.Column+" = source."+item.Column)
+			continue
+		}
+		set = append(set, "target."+item.Column+" = "+item.Value)
+		setFields = append(setFields, item.Fields...)
+	}
+	if len(set) > 0 {
 		sb.WriteString(" WHEN MATCHED THEN UPDATE SET ")
-		sb.WriteString(updateAssignStr)
-		paramOrder = append(paramOrder, updateFields...)
+		sb.WriteString(strings.Join(set, ", "))
+		paramOrder = append(paramOrder, setFields...)
 	}
 
-	// WHEN NOT MATCHED THEN INSERT
 	sb.WriteString(" WHEN NOT MATCHED THEN INSERT (")
-	sb.WriteString(columnNames)
+	for i, item := range items {
+		if i != 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(item.Column)
+	}
 	sb.WriteString(") VALUES (")
-	sb.WriteString(columnValues)
+	for i, item := range items {
+		if i != 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("source.")
+		sb.WriteString(item.Column)
+	}
 	sb.WriteString(")")
 
 	return sb.String(), paramOrder, nil
 }
 
-// BuildUpsertSQL implements DatabaseTransformer for Oracle
-// Oracle 使用 MERGE 语句实现 UPSERT 功能
 func (s *oracleSql) BuildUpsertSQL(tableName string, bs BuildSql) (sql string, paramOrder []string, err error) {
 	// Oracle 的 UPSERT 和 REPLACE 逻辑基本相同，都使用 MERGE
 	return s.BuildReplaceSQL(tableName, bs)
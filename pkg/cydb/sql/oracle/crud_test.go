@@ -0,0 +1,31 @@
+package sqloracle
+
+import (
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOracleMergeSQL(t *testing.T) {
+	s := &oracleSql{}
+	r, err := Builder().Table("users").Fields([]string{"id", "name", "level"}).PrimaryKeys("id").
+		Type(SQLOperationReplace).Build(s)
+	require.NoError(t, err)
+	assert.Equal(t, `MERGE INTO USERS target USING (SELECT :id AS id, :name AS name, :level AS "level" FROM DUAL) source ON (target.id = source.id)`+
+		` WHEN MATCHED THEN UPDATE SET target.name = source.name, target."level" = source."level"`+
+		` WHEN NOT MATCHED THEN INSERT (id, name, "level") VALUES (source.id, source.name, source."level")`, r.SQL)
+	assert.Equal(t, []string{"id", "name", "level"}, r.ParamOrder)
+
+	r, err = Builder().Table("users").Fields([]string{"id", "name"}).Update("name").PrimaryKeys("id").
+		Type(SQLOperationUpsert).Build(s)
+	require.NoError(t, err)
+	assert.Contains(t, r.SQL, "UPDATE SET target.name = source.name WHEN")
+
+	r, err = Builder().TenantPolicy(&TenantPolicy{Tables: []string{"users"}}).Table("users").Tenant(7).Fields([]string{"id", "name"}).PrimaryKeys("id").
+		Type(SQLOperationUpsert).Build(s)
+	require.NoError(t, err)
+	assert.Contains(t, r.SQL, "ON (target.id = source.id AND target.tenant_id = 7)")
+	assert.Contains(t, r.SQL, "UPDATE SET target.name = source.name WHEN")
+}
@@ -651,7 +651,7 @@ func getRefConstraintColumns(cli DatabaseClient, schemaOwner, rConstraintName st
 		AND ac.OWNER = :2
 		ORDER BY acc.POSITION`
 
-	rows, err := cli.Query(query, rConstraintName, schemaOwner)
+	rows, err := InternalQuery(cli, query, rConstraintName, schemaOwner)
 	if err != nil {
 		return nil, err
 	}
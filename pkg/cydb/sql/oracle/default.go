@@ -12,7 +12,6 @@ import (
 	"github.com/fj1981/infrakit/pkg/cylog"
 	"github.com/fj1981/infrakit/pkg/cyutil"
 	"github.com/jmoiron/sqlx"
-	go_ora "github.com/sijms/go-ora/v2"
 )
 
 func init() {
@@ -209,42 +208,11 @@ func (s *oracleSql) GetTableColumns(j DatabaseClient, database, tableName string
 			continue
 		}
 
-		dataType := strings.ToUpper(col.DataType)
 		isPrimaryKey := false
 		if _, ok := pkMap[columnName]; ok {
 			isPrimaryKey = true
 		}
 
-		// Map Oracle data types to internal DBFieldType
-		var fieldType DBFieldType
-		switch dataType {
-		case "NUMBER":
-			// For Oracle NUMBER type, check if it's an integer or float
-			dataScale := col.DataScale
-			if dataScale.Valid && dataScale.Int64 != 0 {
-				fieldType = DBFieldTypeFloat // Decimal number
-			} else {
-				fieldType = DBFieldTypeInt // Integer number
-			}
-		case "VARCHAR2", "VARCHAR", "CHAR", "NCHAR", "NVARCHAR2", "CLOB", "NCLOB":
-			fieldType = DBFieldTypeString
-		case "DATE", "TIMESTAMP", "TIMESTAMP WITH TIME ZONE", "TIMESTAMP WITH LOCAL TIME ZONE":
-			fieldType = DBFieldTypeTime
-		case "BLOB", "BFILE", "RAW", "LONG RAW":
-			fieldType = DBFieldTypeBinary
-		case "FLOAT", "BINARY_FLOAT", "BINARY_DOUBLE":
-			fieldType = DBFieldTypeFloat
-		case "XMLTYPE":
-			// Map XML to string for compatibility
-			fieldType = DBFieldTypeString
-		default:
-			if strings.Contains(dataType, "TIMESTAMP(") {
-				fieldType = DBFieldTypeTime
-			} else {
-				fieldType = DBFieldTypeString
-			}
-		}
-
 		// Create column key (PRI for primary key, empty otherwise)
 		columnKey := ""
 		if isPrimaryKey {
@@ -256,16 +224,44 @@ func (s *oracleSql) GetTableColumns(j DatabaseClient, database, tableName string
 		// Add column to the map
 		columns = append(columns, &DBColumn{
 			Name:        columnName,
-			DBFieldType: fieldType,
+			DBFieldType: fieldType(col),
 			ColumnKey:   columnKey,
 			OrgDataType: buildDataType(col),
 			Nullable:    col.Nullable != "N",
 		})
 	}
-
+	markGeneratedKeys(j, database, tableName, columns)
 	return columns, nil
 }
 
+// fieldType 将 Oracle 数据类型映射为 DBFieldType
+func fieldType(col ColumnInfo) DBFieldType {
+	switch dataType := strings.ToUpper(col.DataType); dataType {
+	case "NUMBER":
+		// For Oracle NUMBER type, check if it's an integer or float
+		if col.DataScale.Valid && col.DataScale.Int64 != 0 {
+			return DBFieldTypeFloat // Decimal number
+		}
+		return DBFieldTypeInt // Integer number
+	case "VARCHAR2", "VARCHAR", "CHAR", "NCHAR", "NVARCHAR2", "CLOB", "NCLOB":
+		return DBFieldTypeString
+	case "DATE", "TIMESTAMP", "TIMESTAMP WITH TIME ZONE", "TIMESTAMP WITH LOCAL TIME ZONE":
+		return DBFieldTypeTime
+	case "BLOB", "BFILE", "RAW", "LONG RAW":
+		return DBFieldTypeBinary
+	case "FLOAT", "BINARY_FLOAT", "BINARY_DOUBLE":
+		return DBFieldTypeFloat
+	case "XMLTYPE":
+		// Map XML to string for compatibility
+		return DBFieldTypeString
+	default:
+		if strings.Contains(dataType, "TIMESTAMP(") {
+			return DBFieldTypeTime
+		}
+		return DBFieldTypeString
+	}
+}
+
 func (s *oracleSql) IsTableExist(j DatabaseClient, tableName string) (bool, error) {
 	tableName = ConvertReservedKeywords(tableName)
 	// Query to check if the table exists in the user's schema
@@ -702,7 +698,7 @@ func (r *oracleRowData) blobReleaseStr() (string, error) {
 }
 
 func (s *oracleSql) GetReplaceSql(cli DatabaseClient, table string, rd *RowData) (ret string, err error) {
-	schema := cli.Database()
+	schema := DefaultSchema(cli)
 	table = ConvertReservedKeywords(table)
 	schema = strings.ToUpper(schema)
 	table = strings.ToUpper(table)
@@ -851,10 +847,6 @@ func (s *oracleSql) MakeSureDBExists(cli DatabaseClient, dbName string) error {
 	return nil
 }
 
-func (s *oracleSql) GetConnectStr(dbConn *DBConnection) (string, string) {
-	return "oracle", go_ora.BuildUrl(dbConn.Host, dbConn.Port, dbConn.Service, dbConn.Un, dbConn.Pw, nil)
-}
-
 func (s *oracleSql) GetDefaultTypeName(tp DefaultDBFieldType) string {
 	switch tp {
 	case DefaultDBFieldTypeString:
@@ -0,0 +1,17 @@
+package sqloracle
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/sijms/go-ora/v2/network"
+)
+
+// IsTransientError 识别 Oracle 死锁(ORA-00060)与序列化失败(ORA-08177)
+func (s *oracleSql) IsTransientError(err error) bool {
+	var oraErr *network.OracleError
+	if errors.As(err, &oraErr) {
+		return oraErr.ErrCode == 60 || oraErr.ErrCode == 8177
+	}
+	return err != nil && (strings.Contains(err.Error(), "ORA-00060") || strings.Contains(err.Error(), "ORA-08177"))
+}
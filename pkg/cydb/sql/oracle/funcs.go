@@ -0,0 +1,131 @@
+package sqloracle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+)
+
+var _ FuncTranslator = (*oracleSql)(nil)
+
+// oracleFuncs MySQL 函数到 Oracle 的翻译表
+var oracleFuncs = FuncTable{
+	"NOW":               FuncConst("SYSDATE"),
+	"SYSDATE":           FuncConst("SYSDATE"),
+	"CURRENT_TIMESTAMP": FuncConst("SYSTIMESTAMP"),
+	"CURDATE":           FuncConst("TRUNC(SYSDATE)"),
+	"CURRENT_DATE":      FuncConst("TRUNC(SYSDATE)"),
+	"IFNULL":            FuncRename("NVL"),
+	"IF":                FuncIf,
+	"CONCAT":            oracleConcat,
+	"CONCAT_WS":         oracleConcatWs,
+	"RAND":              FuncConst("DBMS_RANDOM.VALUE"),
+	"LCASE":             FuncRename("LOWER"),
+	"UCASE":             FuncRename("UPPER"),
+	"SUBSTRING":         FuncRename("SUBSTR"),
+	"CHAR_LENGTH":       FuncRename("LENGTH"),
+	"GROUP_CONCAT":      oracleGroupConcat,
+	"DATE_FORMAT": FuncDateFormat(ToCharDateFormat, ToCharText, func(expr, format string) string {
+		return fmt.Sprintf("TO_CHAR(%s, %s)", expr, format)
+	}),
+	"UNIX_TIMESTAMP": oracleUnixTimestamp,
+	"DATE_ADD":       oracleDateAdd(""),
+	"DATE_SUB":       oracleDateAdd("-"),
+}
+
+// TranslateFunc implements cydb.FuncTranslator.
+func (s *oracleSql) TranslateFunc(name string, args []string, distinct bool) (string, bool) {
+	return oracleFuncs.TranslateFunc(name, args, distinct)
+}
+
+// CONCAT(a, b) -> CASE WHEN a IS NULL OR b IS NULL THEN NULL ELSE a || b END
+// Oracle 的 || 把 NULL 当作空串，MySQL 的 CONCAT 任一参数为 NULL 时结果为 NULL；字符串常量不需要判断
+func oracleConcat(args []string, distinct bool) (string, bool) {
+	if len(args) == 0 {
+		return "", false
+	}
+	var conds []string
+	for _, a := range args {
+		if _, ok := UnquoteLiteral(a); !ok {
+			conds = append(conds, a+" IS NULL")
+		}
+	}
+	concat := strings.Join(args, " || ")
+	if len(conds) == 0 {
+		return "(" + concat + ")", true
+	}
+	return fmt.Sprintf("(CASE WHEN %s THEN NULL ELSE %s END)", strings.Join(conds, " OR "), concat), true
+}
+
+// CONCAT_WS(s, a, b) -> SUBSTR(NVL2(a, s || a, NULL) || NVL2(b, s || b, NULL), LENGTH(s) + 1)
+// 与 MySQL 一致跳过为 NULL 的参数，分隔符为 NULL 时结果为 NULL
+func oracleConcatWs(args []string, distinct bool) (string, bool) {
+	if len(args) < 2 {
+		return "", false
+	}
+	sep, parts := args[0], args[1:]
+	lit, isLit := UnquoteLiteral(sep)
+	if isLit && lit == "" {
+		// Oracle 中 '' 即 NULL，空分隔符直接用 || 连接，NULL 参数自然被跳过
+		return "(" + strings.Join(parts, " || ") + ")", true
+	}
+	items := make([]string, len(parts))
+	for i, a := range parts {
+		items[i] = fmt.Sprintf("NVL2(%s, %s || %s, NULL)", a, sep, a)
+	}
+	start := fmt.Sprintf("LENGTH(%s) + 1", sep)
+	if isLit {
+		start = strconv.Itoa(utf8.RuneCountInString(lit) + 1)
+	}
+	return fmt.Sprintf("SUBSTR(%s, %s)", strings.Join(items, " || "), start), true
+}
+
+// GROUP_CONCAT(x SEPARATOR s) -> LISTAGG(x, s) WITHIN GROUP (ORDER BY x)
+func oracleGroupConcat(args []string, distinct bool) (string, bool) {
+	exprs, sep := SplitGroupConcat(args)
+	if len(exprs) == 0 {
+		return "", false
+	}
+	expr := exprs[0]
+	if len(exprs) > 1 {
+		expr, _ = oracleConcat(exprs, false)
+	}
+	if distinct {
+		return fmt.Sprintf("LISTAGG(DISTINCT %s, %s) WITHIN GROUP (ORDER BY %s)", expr, sep, expr), true
+	}
+	return fmt.Sprintf("LISTAGG(%s, %s) WITHIN GROUP (ORDER BY %s)", expr, sep, expr), true
+}
+
+func oracleUnixTimestamp(args []string, distinct bool) (string, bool) {
+	switch len(args) {
+	case 0:
+		return "ROUND((CAST(SYS_EXTRACT_UTC(SYSTIMESTAMP) AS DATE) - DATE '1970-01-01') * 86400)", true
+	case 1:
+		return fmt.Sprintf("ROUND((CAST(%s AS DATE) - DATE '1970-01-01') * 86400)", args[0]), true
+	}
+	return "", false
+}
+
+// DATE_ADD(x, INTERVAL n UNIT)：日以下单位用 NUMTODSINTERVAL，月/年用 ADD_MONTHS
+func oracleDateAdd(sign string) FuncRewrite {
+	return func(args []string, distinct bool) (string, bool) {
+		if len(args) != 3 {
+			return "", false
+		}
+		n := fmt.Sprintf("%s(%s)", sign, args[1])
+		switch unit := strings.ToUpper(args[2]); unit {
+		case "DAY", "HOUR", "MINUTE", "SECOND":
+			return fmt.Sprintf("(%s + NUMTODSINTERVAL(%s, '%s'))", args[0], n, unit), true
+		case "WEEK":
+			return fmt.Sprintf("(%s + NUMTODSINTERVAL(%s * 7, 'DAY'))", args[0], n), true
+		case "MONTH":
+			return fmt.Sprintf("ADD_MONTHS(%s, %s)", args[0], n), true
+		case "YEAR":
+			return fmt.Sprintf("ADD_MONTHS(%s, %s * 12)", args[0], n), true
+		}
+		return "", false
+	}
+}
@@ -0,0 +1,37 @@
+package sqloracle
+
+import (
+	"testing"
+
+	cydb "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateMySQLFuncs(t *testing.T) {
+	cases := map[string]string{
+		"SELECT NOW() AS ts FROM t":                                          "SYSDATE AS",
+		"SELECT IFNULL(score, 0) FROM t":                                     "NVL(score, 0)",
+		"SELECT DATE_FORMAT(created, '%Y-%m-%d %H:%i') FROM t":               "TO_CHAR(created, 'YYYY-MM-DD HH24:MI')",
+		"SELECT CONCAT_WS('-', `a`, b) FROM t":                               "SUBSTR(NVL2(a, '-' || a, NULL) || NVL2(b, '-' || b, NULL), 2)",
+		"SELECT CONCAT_WS('', a, b) FROM t":                                  "(a || b)",
+		"SELECT CONCAT(a, '-', b) FROM t":                                    "(CASE WHEN a IS NULL OR b IS NULL THEN NULL ELSE a || '-' || b END)",
+		"SELECT IF(a > 1, 'y', 'n') FROM t":                                  "CASE WHEN a > 1 THEN 'y' ELSE 'n' END",
+		"SELECT kind, GROUP_CONCAT(name SEPARATOR ';') FROM t GROUP BY kind": "LISTAGG(name, ';') WITHIN GROUP (ORDER BY name)",
+		"SELECT * FROM t WHERE created > DATE_SUB(NOW(), INTERVAL 7 DAY)":    "(SYSDATE + NUMTODSINTERVAL(-(7), 'DAY'))",
+	}
+	dt, ok := cydb.GetSqlTransformer("oracle")
+	require.True(t, ok)
+	for in, want := range cases {
+		b, err := cydb.ParseMySQL(in)
+		require.NoError(t, err, in)
+		r, err := b.Tenant(cydb.AllTenants).Build(dt)
+		require.NoError(t, err, in)
+		assert.Contains(t, r.SQL, want, in)
+	}
+
+	// Builder 中直接构造的函数不翻译
+	r, err := cydb.Builder().Table("t").Fields(cydb.FUNC("CONCAT", cydb.FIELD("a"), cydb.FIELD("b"))).Type(cydb.SQLOperationSelect).Build(dt)
+	require.NoError(t, err)
+	assert.Contains(t, r.SQL, "CONCAT(a, b)")
+}
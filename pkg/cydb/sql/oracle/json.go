@@ -0,0 +1,34 @@
+package sqloracle
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+)
+
+var _ JSONTransformer = (*oracleSql)(nil)
+
+// JSONPath 文本用 JSON_VALUE，JSON 片段用 JSON_QUERY
+func (s *oracleSql) JSONPath(column string, path []string, asText bool) (string, error) {
+	fn := "JSON_QUERY"
+	if asText {
+		fn = "JSON_VALUE"
+	}
+	return fmt.Sprintf("%s(%s, %s)", fn, column, QuoteLiteral(JSONPathString(path))), nil
+}
+
+// JSONContains Oracle 未支持
+func (s *oracleSql) JSONContains(column, doc string) (string, error) {
+	return "", errors.New("oracle does not support JSON_CONTAINS")
+}
+
+// ArrayContains Oracle 未支持
+func (s *oracleSql) ArrayContains(column string, values []string) (string, error) {
+	return "", errors.New("oracle does not support ARRAY_CONTAINS")
+}
+
+// ArrayAny Oracle 未支持
+func (s *oracleSql) ArrayAny(column, value string) (string, error) {
+	return "", errors.New("oracle does not support ANY")
+}
@@ -0,0 +1,12 @@
+package sqloracle
+
+import (
+	. "github.com/fj1981/infrakit/pkg/cydb"
+)
+
+var _ InListLimiter = (*oracleSql)(nil)
+
+// MaxInListSize Oracle IN 列表最多 1000 个元素（ORA-01795）
+func (s *oracleSql) MaxInListSize() int {
+	return 1000
+}
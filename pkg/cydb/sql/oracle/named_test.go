@@ -0,0 +1,30 @@
+package sqloracle
+
+import (
+	"strings"
+	"testing"
+
+	cydb "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandNamedQueryInListLimit(t *testing.T) {
+	ids := make([]int, 2500)
+	for i := range ids {
+		ids[i] = i
+	}
+	q, args, ok, err := cydb.ExpandNamedQuery("SELECT * FROM t WHERE status = :st AND t.id IN (:ids)", map[string]interface{}{"st": 1, "ids": ids}, (&oracleSql{}).MaxInListSize())
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Len(t, args, 2501)
+	assert.Equal(t, 1, args[0])
+	assert.True(t, strings.HasPrefix(q, "SELECT * FROM t WHERE status = ? AND (t.id IN (?, "))
+	assert.Equal(t, 3, strings.Count(q, "t.id IN ("))
+	assert.Equal(t, 2, strings.Count(q, ") OR t.id IN ("))
+	assert.True(t, strings.HasSuffix(q, "?))"))
+
+	q, _, _, err = cydb.ExpandNamedQuery("DELETE FROM t WHERE id NOT IN (:ids)", map[string]interface{}{"ids": ids}, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(q, ") AND id NOT IN ("))
+}
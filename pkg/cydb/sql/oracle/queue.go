@@ -0,0 +1,13 @@
+package sqloracle
+
+import (
+	. "github.com/fj1981/infrakit/pkg/cydb"
+)
+
+var _ SkipLocker = (*oracleSql)(nil)
+
+// SkipLockedSQL implements cydb.SkipLocker.
+// Oracle 不允许 FETCH FIRST 与 FOR UPDATE 同时使用，SKIP LOCKED 在读取时才加锁，由调用方读够 limit 行后停止
+func (s *oracleSql) SkipLockedSQL(query string, limit int) (string, bool) {
+	return query + " FOR UPDATE SKIP LOCKED", false
+}
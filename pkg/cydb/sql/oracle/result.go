@@ -0,0 +1,31 @@
+package sqloracle
+
+import (
+	"database/sql"
+	"strings"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+)
+
+var _ ResultTypeMapper = (*oracleSql)(nil)
+
+// ResultFieldType implements cydb.ResultTypeMapper.
+// NUMBER 仅在声明了精度且标度为 0 时视为整数，未限定精度的 NUMBER（含 COUNT 等表达式）按定点数处理
+func (s *oracleSql) ResultFieldType(ct *sql.ColumnType) DBFieldType {
+	name := strings.ToUpper(ct.DatabaseTypeName())
+	switch {
+	case name == "NUMBER":
+		if p, scale, ok := ct.DecimalSize(); ok && p > 0 && scale == 0 {
+			return DBFieldTypeInt
+		}
+		return DBFieldTypeFloat
+	case strings.Contains(name, "FLOAT") || strings.Contains(name, "DOUBLE"):
+		return DBFieldTypeFloat
+	case strings.Contains(name, "DATE") || strings.Contains(name, "TIMESTAMP"):
+		return DBFieldTypeTime
+	case strings.Contains(name, "RAW") || name == "OCIBLOBLOCATOR" || name == "OCIFILELOCATOR":
+		return DBFieldTypeBinary
+	default:
+		return DBFieldTypeString
+	}
+}
@@ -0,0 +1,163 @@
+package sqloracle
+
+import (
+	"slices"
+	"strings"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/fj1981/infrakit/pkg/cyutil"
+)
+
+var (
+	_ TableSchemaInspector = (*oracleSql)(nil)
+	_ TableLister          = (*oracleSql)(nil)
+)
+
+var constraintTypes = map[string]ConstraintType{
+	"P": ConstraintPrimaryKey,
+	"U": ConstraintUnique,
+	"R": ConstraintForeignKey,
+	"C": ConstraintCheck,
+}
+
+// GetTableSchema database 为 schema owner
+func (s *oracleSql) GetTableSchema(cli DatabaseClient, database, tableName string) (*TableSchema, error) {
+	tableName = strings.ToUpper(ConvertReservedKeywords(tableName))
+	owner := strings.ToUpper(database)
+	ts := &TableSchema{Schema: owner, Name: tableName}
+
+	row, err := InternalQueryOne(cli, "SELECT COMMENTS FROM ALL_TAB_COMMENTS WHERE OWNER = :1 AND TABLE_NAME = :2", owner, tableName)
+	if err != nil {
+		return nil, err
+	}
+	ts.Comment = cyutil.GetStr(row, "COMMENTS", true)
+
+	cols, err := getColumns(cli, owner, tableName)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := getComments(cli, owner, tableName)
+	if err != nil {
+		return nil, err
+	}
+	commentMap := map[string]string{}
+	for _, c := range comments {
+		commentMap[c.ColumnName] = c.Comments
+	}
+	for i, col := range cols {
+		c := &SchemaColumn{
+			Name:       col.ColumnName,
+			Position:   i + 1,
+			DataType:   col.DataType,
+			ColumnType: buildDataType(col),
+			FieldType:  fieldType(col),
+			Nullable:   col.Nullable != "N",
+			Length:     col.DataLength.Int64,
+			Precision:  col.DataPrecision.Int64,
+			Scale:      col.DataScale.Int64,
+			Comment:    commentMap[col.ColumnName],
+		}
+		if col.CharUsed.Valid && col.CharLength.Int64 > 0 {
+			c.Length = col.CharLength.Int64
+		}
+		if def := s.substractDefault(col.DataDefault); def != "" {
+			c.Default = &def
+		}
+		ts.Columns = append(ts.Columns, c)
+	}
+
+	constraints, err := getConstraints(cli, owner, tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, con := range constraints {
+		tp, ok := constraintTypes[con.ConstraintType]
+		if !ok {
+			continue
+		}
+		if tp != ConstraintForeignKey {
+			c := ts.AppendConstraintColumn(con.ConstraintName, tp, con.ColumnName)
+			if tp == ConstraintCheck {
+				c.Check = con.SearchCondition.String
+			}
+			continue
+		}
+		idx := slices.IndexFunc(ts.ForeignKeys, func(fk *SchemaForeignKey) bool { return fk.Name == con.ConstraintName })
+		if idx < 0 {
+			// 首次出现时读取被引用表的信息，引用列按约束定义一次取全
+			ref, err := getRefConstraintInfo(cli, owner, con.RConstraintName.String)
+			if err != nil {
+				return nil, err
+			}
+			refCols, err := getRefConstraintColumns(cli, owner, con.RConstraintName.String)
+			if err != nil {
+				return nil, err
+			}
+			ts.ForeignKeys = append(ts.ForeignKeys, &SchemaForeignKey{
+				Name:       con.ConstraintName,
+				RefSchema:  ref.Owner,
+				RefTable:   ref.TableName,
+				RefColumns: refCols,
+				OnDelete:   con.DeleteRule.String,
+				// Oracle 不支持 ON UPDATE
+				OnUpdate: "NO ACTION",
+			})
+			idx = len(ts.ForeignKeys) - 1
+		}
+		fk := ts.ForeignKeys[idx]
+		fk.Columns = append(fk.Columns, con.ColumnName)
+	}
+	ts.MarkPrimaryKey()
+	for name, seq := range generatedKeys(cli, owner, tableName, ts.PrimaryKey) {
+		if col := ts.Column(name); col != nil {
+			col.AutoIncrement = true
+			col.Sequence = seq
+		}
+	}
+
+	indexes, err := getIndexes(cli, owner, tableName)
+	if err != nil {
+		return nil, err
+	}
+	pkName := ""
+	for _, c := range ts.Constraints {
+		if c.Type == ConstraintPrimaryKey {
+			pkName = c.Name
+		}
+	}
+	for _, idx := range indexes {
+		// 主键约束对应的同名索引不作为普通索引
+		if idx.IndexName == pkName {
+			continue
+		}
+		ts.AppendIndexColumn(idx.IndexName, idx.ColumnName, idx.Uniqueness == "UNIQUE")
+	}
+
+	var triggers []TriggerInfo
+	if err := cli.Select(&triggers, `SELECT TRIGGER_NAME, TRIGGER_TYPE, TRIGGERING_EVENT AS TRIGGER_EVENT, TRIGGER_BODY
+		FROM ALL_TRIGGERS WHERE TABLE_OWNER = :1 AND TABLE_NAME = :2 ORDER BY TRIGGER_NAME`, owner, tableName); err != nil {
+		return nil, err
+	}
+	for _, t := range triggers {
+		timing := "AFTER"
+		switch {
+		case strings.HasPrefix(t.TriggerType, "BEFORE"):
+			timing = "BEFORE"
+		case strings.HasPrefix(t.TriggerType, "INSTEAD OF"):
+			timing = "INSTEAD OF"
+		case strings.HasPrefix(t.TriggerType, "COMPOUND"):
+			timing = "COMPOUND"
+		}
+		ts.Triggers = append(ts.Triggers, &SchemaTrigger{
+			Name:       t.TriggerName,
+			Timing:     timing,
+			Event:      t.TriggerEvent,
+			Definition: strings.TrimSpace(t.TriggerBody),
+		})
+	}
+	return ts, nil
+}
+
+func (s *oracleSql) GetTableNames(cli DatabaseClient, database string) ([]string, error) {
+	return QueryNames(cli, "SELECT TABLE_NAME AS NAME FROM ALL_TABLES WHERE OWNER = :1 ORDER BY TABLE_NAME", strings.ToUpper(database))
+}
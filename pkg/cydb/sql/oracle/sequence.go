@@ -0,0 +1,120 @@
+package sqloracle
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/fj1981/infrakit/pkg/cyutil"
+	"github.com/jmoiron/sqlx"
+	go_ora "github.com/sijms/go-ora/v2"
+)
+
+var (
+	_ SequenceGenerator = (*oracleSql)(nil)
+	_ InsertReturner    = (*oracleSql)(nil)
+	_ DBOpener          = (*oracleSql)(nil)
+)
+
+// identPattern 不带引号的 Oracle 标识符，可带 owner 前缀
+var identPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_$#]*(\.[A-Za-z][A-Za-z0-9_$#]*)?$`)
+
+// NextSequenceValue implements cydb.SequenceGenerator.
+func (s *oracleSql) NextSequenceValue(cli DatabaseClient, sequence string) (int64, error) {
+	if !identPattern.MatchString(sequence) {
+		return 0, fmt.Errorf("invalid sequence name %q", sequence)
+	}
+	var id int64
+	if err := cli.Get(&id, "SELECT "+strings.ToUpper(sequence)+".NEXTVAL FROM DUAL"); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// InsertReturningClause implements cydb.InsertReturner.
+// 适用于 identity 列及由触发器从序列填充的主键
+func (s *oracleSql) InsertReturningClause(column, param string) string {
+	return " RETURNING " + s.EscapeColumnName(strings.ToUpper(column)) + " INTO :" + param
+}
+
+// OpenDB implements cydb.DBOpener.
+// 配置了 Schema 时每个连接池使用独立的驱动实例，新建连接时设置 CURRENT_SCHEMA，不影响其他连接
+func (s *oracleSql) OpenDB(driverName, dsn string, v *DBConnection) (*sqlx.DB, error) {
+	if v.Schema == "" {
+		return sqlx.Open(driverName, dsn)
+	}
+	if !identPattern.MatchString(v.Schema) || strings.Contains(v.Schema, ".") {
+		return nil, fmt.Errorf("invalid oracle schema %q", v.Schema)
+	}
+	db := sql.OpenDB(go_ora.NewConnector(dsn))
+	if err := go_ora.AddSessionParam(db, "CURRENT_SCHEMA", strings.ToUpper(v.Schema)); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return sqlx.NewDb(db, driverName), nil
+}
+
+// GetConnectStr 连接方式优先级为 TNS 连接描述符、SID、服务名
+func (s *oracleSql) GetConnectStr(dbConn *DBConnection) (string, string) {
+	switch {
+	case dbConn.TNS != "":
+		return "oracle", go_ora.BuildJDBC(dbConn.Un, dbConn.Pw, dbConn.TNS, nil)
+	case dbConn.SID != "":
+		return "oracle", go_ora.BuildUrl(dbConn.Host, dbConn.Port, "", dbConn.Un, dbConn.Pw, map[string]string{"SID": dbConn.SID})
+	default:
+		return "oracle", go_ora.BuildUrl(dbConn.Host, dbConn.Port, dbConn.Service, dbConn.Un, dbConn.Pw, nil)
+	}
+}
+
+// markGeneratedKeys 标记 identity 列及由触发器从序列填充的单列主键
+func markGeneratedKeys(cli DatabaseClient, owner, tableName string, columns []*DBColumn) {
+	var pk []string
+	for _, c := range columns {
+		if c.ColumnKey == "PRI" {
+			pk = append(pk, c.Name)
+		}
+	}
+	keys := generatedKeys(cli, owner, tableName, pk)
+	for _, c := range columns {
+		if seq, ok := keys[strings.ToUpper(c.Name)]; ok {
+			c.AutoIncrement = true
+			c.Sequence = seq
+		}
+	}
+}
+
+// generatedKeys 返回 identity 列及由启用的 INSERT 触发器从序列填充的单列主键，键为大写列名，值为序列名
+// GetTableColumns 与 GetTableSchema 共用，查询失败时视为没有自增列
+func generatedKeys(cli DatabaseClient, owner, tableName string, pk []string) map[string]string {
+	r := map[string]string{}
+	// ALL_TAB_IDENTITY_COLS 自 12c 起提供，低版本忽略
+	if rows, err := InternalQuery(cli, "SELECT COLUMN_NAME, SEQUENCE_NAME FROM ALL_TAB_IDENTITY_COLS WHERE OWNER = :1 AND TABLE_NAME = :2", owner, tableName); err == nil {
+		for _, row := range rows {
+			r[strings.ToUpper(cyutil.GetStr(row, "COLUMN_NAME", true))] = cyutil.GetStr(row, "SEQUENCE_NAME", true)
+		}
+	}
+	if len(pk) != 1 {
+		return r
+	}
+	col := strings.ToUpper(pk[0])
+	if _, ok := r[col]; ok {
+		return r
+	}
+	var triggers []TriggerInfo
+	if err := cli.Select(&triggers, `SELECT TRIGGER_NAME, TRIGGER_TYPE, TRIGGERING_EVENT AS TRIGGER_EVENT, TRIGGER_BODY
+		FROM ALL_TRIGGERS WHERE TABLE_OWNER = :1 AND TABLE_NAME = :2 AND STATUS = 'ENABLED' ORDER BY TRIGGER_NAME`, owner, tableName); err != nil {
+		return r
+	}
+	for _, t := range triggers {
+		if !strings.Contains(t.TriggerEvent, "INSERT") {
+			continue
+		}
+		if seqs := extractSequenceNamesFromTriggerBody(t.TriggerBody); len(seqs) == 1 {
+			r[col] = strings.ToUpper(seqs[0])
+			break
+		}
+	}
+	return r
+}
@@ -0,0 +1,34 @@
+package sqloracle
+
+import (
+	"testing"
+
+	cydb "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOracleConnectStr(t *testing.T) {
+	s := &oracleSql{}
+	_, dsn := s.GetConnectStr(&cydb.DBConnection{Host: "db", Port: 1521, Service: "ORCLPDB1", Un: "app", Pw: "pw"})
+	assert.Equal(t, "oracle://app:pw@db:1521/ORCLPDB1", dsn)
+
+	_, dsn = s.GetConnectStr(&cydb.DBConnection{Host: "db", Port: 1521, Service: "ignored", SID: "ORCL", Un: "app", Pw: "pw"})
+	assert.Equal(t, "oracle://app:pw@db:1521/?SID=ORCL", dsn)
+
+	tns := "(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=db)(PORT=1521))(CONNECT_DATA=(SERVICE_NAME=ORCLPDB1)))"
+	_, dsn = s.GetConnectStr(&cydb.DBConnection{Host: "ignored", TNS: tns, Un: "app", Pw: "pw"})
+	assert.Contains(t, dsn, "oracle://app:pw@")
+	assert.Contains(t, dsn, "connStr=")
+}
+
+func TestOracleSequenceSQL(t *testing.T) {
+	s := &oracleSql{}
+	assert.Equal(t, " RETURNING ID INTO :rid", s.InsertReturningClause("id", "rid"))
+	assert.Equal(t, ` RETURNING "LEVEL" INTO :rid`, s.InsertReturningClause("level", "rid"))
+
+	_, err := s.NextSequenceValue(nil, "seq_user; DROP TABLE t")
+	require.Error(t, err)
+	_, err = s.OpenDB("oracle", "", &cydb.DBConnection{Schema: "app schema"})
+	require.Error(t, err)
+}
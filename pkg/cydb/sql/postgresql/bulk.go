@@ -0,0 +1,43 @@
+package sqlpostgresql
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/lib/pq"
+)
+
+// MaxBindParams PostgreSQL 单条语句最多 65535 个绑定参数
+func (s *postgresqlSql) MaxBindParams() int {
+	return 65535
+}
+
+// BulkLoad 使用 COPY FROM STDIN 导入
+func (s *postgresqlSql) BulkLoad(cli *DBCli, table string, columns []string, rows [][]any, opt *BulkLoadOptions) (int64, error) {
+	var query string
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		query = pq.CopyInSchema(schema, name, columns...)
+	} else {
+		query = pq.CopyIn(table, columns...)
+	}
+	stmt, err := cli.Prepare(query)
+	if err != nil {
+		return 0, fmt.Errorf("[BulkLoad]: %s | => %w", query, err)
+	}
+	defer stmt.Close()
+	var loaded int64
+	for _, chunk := range opt.BulkChunks(rows, 0, 0) {
+		for _, row := range chunk {
+			if _, err := stmt.Exec(row...); err != nil {
+				return loaded, fmt.Errorf("[BulkLoad]: %s | => %w", query, err)
+			}
+		}
+		loaded += int64(len(chunk))
+		opt.Report(loaded, int64(len(rows)))
+	}
+	if _, err := stmt.Exec(); err != nil {
+		return loaded, fmt.Errorf("[BulkLoad]: %s | => %w", query, err)
+	}
+	return loaded, nil
+}
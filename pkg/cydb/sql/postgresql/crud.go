@@ -91,14 +91,13 @@ func (t *postgresqlSql) BuildReplaceSQL(tableName string, bs BuildSql) (sql stri
 		return "", nil, err
 	}
 	sb.WriteString(columnNames)
-	sb.WriteString(") VALUES (")
+	sb.WriteString(") VALUES ")
 
 	valuesStr, fields, err := bs.GetValuesString(t, true)
 	if err != nil {
 		return "", nil, err
 	}
 	sb.WriteString(valuesStr)
-	sb.WriteString(")")
 	paramOrder = fields
 
 	primaryKeys, err := bs.GetPkFieldsString(t, false)
@@ -128,7 +127,7 @@ func (t *postgresqlSql) BuildUpsertSQL(tableName string, bs BuildSql) (sql strin
 		return "", nil, err
 	}
 	sb.WriteString(columnNames)
-	sb.WriteString(") VALUES (")
+	sb.WriteString(") VALUES ")
 
 	// 构建 INSERT VALUES 占位符
 	columnValues, fields, err := bs.GetValuesString(t, true)
@@ -137,7 +136,6 @@ func (t *postgresqlSql) BuildUpsertSQL(tableName string, bs BuildSql) (sql strin
 	}
 	sb.WriteString(columnValues)
 	paramOrder = fields
-	sb.WriteString(")")
 
 	primaryKeys, err := bs.GetPkFieldsString(t, false)
 	if err != nil {
@@ -157,6 +155,18 @@ func (t *postgresqlSql) BuildUpsertSQL(tableName string, bs BuildSql) (sql strin
 			sb.WriteString(" DO UPDATE SET ")
 			sb.WriteString(updateAssignStr)
 			paramOrder = append(paramOrder, updateFields...)
+			column, value, ok, err := bs.GetConflictGuard(t)
+			if err != nil {
+				return "", nil, err
+			}
+			if ok {
+				sb.WriteString(" WHERE ")
+				sb.WriteString(t.EscapeTableName(tableName))
+				sb.WriteString(".")
+				sb.WriteString(column)
+				sb.WriteString(" = ")
+				sb.WriteString(value)
+			}
 		} else {
 			sb.WriteString(" DO NOTHING")
 		}
@@ -154,16 +154,15 @@ func (s *postgresqlSql) GetDDLSqlFunc(funcName DDLSqlFuncName) (DDLSqlFunc, erro
 }
 
 // getPrimaryKeyColumns retrieves primary key columns for a table
+// database 为 schema 名
 func (s *postgresqlSql) getPrimaryKeyColumns(cli DatabaseClient, database, tableName string) ([]PrimaryKeyInfo, error) {
-	pkQuery := fmt.Sprintf(
-		"SELECT a.attname as column_name "+
-			"FROM pg_index i "+
-			"JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey) "+
-			"WHERE i.indrelid = '%s'::regclass AND i.indisprimary",
-		tableName)
+	pkQuery := "SELECT a.attname as column_name " +
+		"FROM pg_index i " +
+		"JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey) " +
+		"WHERE i.indrelid = (quote_ident($1) || '.' || quote_ident($2))::regclass AND i.indisprimary"
 
 	var pkColumns []PrimaryKeyInfo
-	err := cli.Select(&pkColumns, pkQuery)
+	err := cli.Select(&pkColumns, pkQuery, database, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -173,22 +172,20 @@ func (s *postgresqlSql) getPrimaryKeyColumns(cli DatabaseClient, database, table
 
 // getForeignKeyConstraints retrieves foreign key constraints for a table
 func (s *postgresqlSql) getForeignKeyConstraints(cli DatabaseClient, database, tableName string) ([]ForeignKeyInfo, error) {
-	fkQuery := fmt.Sprintf(
-		"SELECT "+
-			"kcu.column_name, "+
-			"ccu.table_schema AS foreign_table_schema, "+
-			"ccu.table_name AS foreign_table_name, "+
-			"ccu.column_name AS foreign_column_name, "+
-			"rc.delete_rule, rc.update_rule "+
-			"FROM information_schema.table_constraints tc "+
-			"JOIN information_schema.key_column_usage kcu ON tc.constraint_name = kcu.constraint_name "+
-			"JOIN information_schema.constraint_column_usage ccu ON ccu.constraint_name = tc.constraint_name "+
-			"JOIN information_schema.referential_constraints rc ON tc.constraint_name = rc.constraint_name "+
-			"WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_name = '%s'",
-		tableName)
+	fkQuery := "SELECT " +
+		"kcu.column_name, " +
+		"ccu.table_schema AS foreign_table_schema, " +
+		"ccu.table_name AS foreign_table_name, " +
+		"ccu.column_name AS foreign_column_name, " +
+		"rc.delete_rule, rc.update_rule " +
+		"FROM information_schema.table_constraints tc " +
+		"JOIN information_schema.key_column_usage kcu ON tc.constraint_name = kcu.constraint_name " +
+		"JOIN information_schema.constraint_column_usage ccu ON ccu.constraint_name = tc.constraint_name " +
+		"JOIN information_schema.referential_constraints rc ON tc.constraint_name = rc.constraint_name " +
+		"WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = $1 AND tc.table_name = $2"
 
 	var fkConstraints []ForeignKeyInfo
-	err := cli.Select(&fkConstraints, fkQuery)
+	err := cli.Select(&fkConstraints, fkQuery, database, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -198,21 +195,19 @@ func (s *postgresqlSql) getForeignKeyConstraints(cli DatabaseClient, database, t
 
 // getTableIndexes retrieves indexes for a table (excluding primary keys)
 func (s *postgresqlSql) getTableIndexes(cli DatabaseClient, database, tableName string) ([]IndexInfo, error) {
-	indexQuery := fmt.Sprintf(
-		"SELECT "+
-			"i.relname as index_name, "+
-			"a.attname as column_name, "+
-			"ix.indisunique as is_unique "+
-			"FROM pg_catalog.pg_class t "+
-			"JOIN pg_catalog.pg_index ix ON t.oid = ix.indrelid "+
-			"JOIN pg_catalog.pg_class i ON i.oid = ix.indexrelid "+
-			"JOIN pg_catalog.pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey) "+
-			"JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace "+
-			"WHERE t.relkind = 'r' AND  t.relname = '%s' AND NOT ix.indisprimary",
-		tableName)
+	indexQuery := "SELECT " +
+		"i.relname as index_name, " +
+		"a.attname as column_name, " +
+		"ix.indisunique as is_unique " +
+		"FROM pg_catalog.pg_class t " +
+		"JOIN pg_catalog.pg_index ix ON t.oid = ix.indrelid " +
+		"JOIN pg_catalog.pg_class i ON i.oid = ix.indexrelid " +
+		"JOIN pg_catalog.pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey) " +
+		"JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace " +
+		"WHERE t.relkind = 'r' AND n.nspname = $1 AND t.relname = $2 AND NOT ix.indisprimary"
 
 	var indexes []IndexInfo
-	err := cli.Select(&indexes, indexQuery)
+	err := cli.Select(&indexes, indexQuery, database, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -222,16 +217,14 @@ func (s *postgresqlSql) getTableIndexes(cli DatabaseClient, database, tableName
 
 // getTableColumns retrieves column information for a table
 func (s *postgresqlSql) getTableColumns(cli DatabaseClient, database, tableName string) ([]ColumnInfo, error) {
-	columnsQuery := fmt.Sprintf(
-		"SELECT column_name, data_type, character_maximum_length, is_nullable, column_default, "+
-			"(SELECT pg_catalog.pg_get_expr(d.adbin, d.adrelid) FROM pg_catalog.pg_attrdef d "+
-			"WHERE d.adrelid = c.table_name::regclass AND d.adnum = c.ordinal_position) as default_expr "+
-			"FROM information_schema.columns c "+
-			"WHERE table_name = '%s' ORDER BY ordinal_position",
-		tableName)
+	columnsQuery := "SELECT column_name, data_type, character_maximum_length, is_nullable, column_default, " +
+		"(SELECT pg_catalog.pg_get_expr(d.adbin, d.adrelid) FROM pg_catalog.pg_attrdef d " +
+		"WHERE d.adrelid = (quote_ident(c.table_schema) || '.' || quote_ident(c.table_name))::regclass AND d.adnum = c.ordinal_position) as default_expr " +
+		"FROM information_schema.columns c " +
+		"WHERE table_schema = $1 AND table_name = $2 ORDER BY ordinal_position"
 
 	var columns []ColumnInfo
-	err := cli.Select(&columns, columnsQuery)
+	err := cli.Select(&columns, columnsQuery, database, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -240,6 +233,10 @@ func (s *postgresqlSql) getTableColumns(cli DatabaseClient, database, tableName
 }
 
 func (s *postgresqlSql) GetCreateTableSql(cli DatabaseClient, database, tableName string) (string, error) {
+	database, tableName, err := resolveSchema(cli, database, tableName)
+	if err != nil {
+		return "", err
+	}
 	// Get table columns
 	columns, err := s.getTableColumns(cli, database, tableName)
 	if err != nil {
@@ -19,7 +19,12 @@ func init() {
 
 var _ SQLDialect = (*postgresqlSql)(nil)
 
+// GetTableColumns database 为 schema 名，规则见 resolveSchema
 func (s *postgresqlSql) GetTableColumns(cli DatabaseClient, database, tableName string) ([]*DBColumn, error) {
+	database, tableName, err := resolveSchema(cli, database, tableName)
+	if err != nil {
+		return nil, err
+	}
 	// Get column information using the getTableColumns function
 	colInfos, err := s.getTableColumns(cli, database, tableName)
 	if err != nil {
@@ -54,32 +59,11 @@ func (s *postgresqlSql) GetTableColumns(cli DatabaseClient, database, tableName
 
 		// Map PostgreSQL data types to DBFieldType
 		dataType := colInfo.DataType
-		var fieldType DBFieldType
-		switch strings.ToLower(dataType) {
-		case "integer", "smallint", "bigint", "serial", "bigserial":
-			fieldType = DBFieldTypeInt
-		case "numeric", "decimal", "real", "double precision":
-			fieldType = DBFieldTypeFloat
-		case "date", "timestamp", "timestamptz", "time", "timetz":
-			fieldType = DBFieldTypeTime
-		case "bytea":
-			fieldType = DBFieldTypeBinary
-		case "json", "jsonb":
-			fieldType = DBFieldTypeJson
-		case "bit", "bit varying":
-			fieldType = DBFieldTypeBit
-		case "boolean":
-			// Map boolean to int for compatibility
-			fieldType = DBFieldTypeInt
-		default:
-			// text, varchar, char, etc.
-			fieldType = DBFieldTypeString
-		}
 
 		// Create DBColumn object and append to result
 		columns = append(columns, &DBColumn{
 			Name:        colInfo.ColumnName,
-			DBFieldType: fieldType,
+			DBFieldType: fieldType(dataType),
 			ColumnKey:   columnKey,
 			OrgDataType: dataType,
 			Nullable:    colInfo.IsNullable == "YES",
@@ -88,7 +72,39 @@ func (s *postgresqlSql) GetTableColumns(cli DatabaseClient, database, tableName
 	return columns, nil
 }
 
+// fieldType 将 PostgreSQL 数据类型映射为 DBFieldType
+func fieldType(dataType string) DBFieldType {
+	switch strings.ToLower(dataType) {
+	case "integer", "smallint", "bigint", "serial", "bigserial":
+		return DBFieldTypeInt
+	case "numeric", "decimal", "real", "double precision":
+		return DBFieldTypeFloat
+	case "date", "timestamp", "timestamptz", "time", "timetz":
+		return DBFieldTypeTime
+	case "bytea":
+		return DBFieldTypeBinary
+	case "json", "jsonb":
+		return DBFieldTypeJson
+	case "bit", "bit varying":
+		return DBFieldTypeBit
+	case "boolean":
+		// Map boolean to int for compatibility
+		return DBFieldTypeInt
+	default:
+		// text, varchar, char, etc.
+		return DBFieldTypeString
+	}
+}
+
 func (s *postgresqlSql) IsTableExist(cli DatabaseClient, tableName string) (bool, error) {
+	// 带 schema 前缀时只检查该 schema
+	if schema, table, ok := strings.Cut(tableName, "."); ok {
+		result, err := InternalQueryOne(cli, "SELECT COUNT(1) as count FROM information_schema.tables WHERE table_schema = $1 AND table_name = $2", unquoteIdent(schema), unquoteIdent(table))
+		if err != nil {
+			return false, err
+		}
+		return cyutil.GetInt(result, "count") > 0, nil
+	}
 	// In PostgreSQL, we should check both table_name and table_schema (default is 'public')
 	// This query checks if the table exists in the public schema or the current schema
 	sql := "SELECT COUNT(1) as count FROM information_schema.tables WHERE table_name = :tableName AND table_schema IN ('public', current_schema())"
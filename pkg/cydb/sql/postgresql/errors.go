@@ -0,0 +1,16 @@
+package sqlpostgresql
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// IsTransientError 识别 PostgreSQL 序列化失败(40001)与死锁(40P01)
+func (s *postgresqlSql) IsTransientError(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "40001" || pqErr.Code == "40P01"
+	}
+	return false
+}
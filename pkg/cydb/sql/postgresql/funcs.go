@@ -0,0 +1,71 @@
+package sqlpostgresql
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+)
+
+var _ FuncTranslator = (*postgresqlSql)(nil)
+
+// pgFuncs MySQL 函数到 PostgreSQL 的翻译表，NOW/CONCAT/CONCAT_WS 等原生支持的不在此列
+var pgFuncs = FuncTable{
+	"CURDATE":      FuncConst("CURRENT_DATE"),
+	"CURTIME":      FuncConst("LOCALTIME"),
+	"SYSDATE":      FuncConst("NOW()"),
+	"IFNULL":       FuncRename("COALESCE"),
+	"IF":           FuncIf,
+	"RAND":         FuncConst("RANDOM()"),
+	"LCASE":        FuncRename("LOWER"),
+	"UCASE":        FuncRename("UPPER"),
+	"GROUP_CONCAT": pgGroupConcat,
+	"DATE_FORMAT": FuncDateFormat(ToCharDateFormat, ToCharText, func(expr, format string) string {
+		return fmt.Sprintf("TO_CHAR(%s, %s)", expr, format)
+	}),
+	"UNIX_TIMESTAMP": pgUnixTimestamp,
+	"DATE_ADD":       pgDateAdd("+"),
+	"DATE_SUB":       pgDateAdd("-"),
+}
+
+// TranslateFunc implements cydb.FuncTranslator.
+func (s *postgresqlSql) TranslateFunc(name string, args []string, distinct bool) (string, bool) {
+	return pgFuncs.TranslateFunc(name, args, distinct)
+}
+
+// GROUP_CONCAT([DISTINCT] a, b SEPARATOR s) -> STRING_AGG([DISTINCT] CAST(a AS TEXT) || CAST(b AS TEXT), s)
+func pgGroupConcat(args []string, distinct bool) (string, bool) {
+	exprs, sep := SplitGroupConcat(args)
+	if len(exprs) == 0 {
+		return "", false
+	}
+	casted := make([]string, len(exprs))
+	for i, e := range exprs {
+		casted[i] = fmt.Sprintf("CAST(%s AS TEXT)", e)
+	}
+	expr := strings.Join(casted, " || ")
+	if distinct {
+		expr = "DISTINCT " + expr
+	}
+	return fmt.Sprintf("STRING_AGG(%s, %s)", expr, sep), true
+}
+
+func pgUnixTimestamp(args []string, distinct bool) (string, bool) {
+	switch len(args) {
+	case 0:
+		return "CAST(EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) AS BIGINT)", true
+	case 1:
+		return fmt.Sprintf("CAST(EXTRACT(EPOCH FROM %s) AS BIGINT)", args[0]), true
+	}
+	return "", false
+}
+
+// DATE_ADD(x, INTERVAL n UNIT) -> (x + (n) * INTERVAL '1 UNIT')
+func pgDateAdd(op string) FuncRewrite {
+	return func(args []string, distinct bool) (string, bool) {
+		if len(args) != 3 {
+			return "", false
+		}
+		return fmt.Sprintf("(%s %s (%s) * INTERVAL '1 %s')", args[0], op, args[1], args[2]), true
+	}
+}
@@ -0,0 +1,43 @@
+package sqlpostgresql
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+)
+
+var _ JSONTransformer = (*postgresqlSql)(nil)
+
+// JSONPath 生成 (col->'a'->0->>'b')，列应为 json/jsonb 类型
+func (s *postgresqlSql) JSONPath(column string, path []string, asText bool) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("(" + column)
+	for i, k := range path {
+		op := "->"
+		if asText && i == len(path)-1 {
+			op = "->>"
+		}
+		if !IsJSONIndex(k) {
+			k = QuoteLiteral(k)
+		}
+		sb.WriteString(op + k)
+	}
+	sb.WriteString(")")
+	return sb.String(), nil
+}
+
+// JSONContains 生成 col @> CAST(doc AS jsonb)
+func (s *postgresqlSql) JSONContains(column, doc string) (string, error) {
+	return fmt.Sprintf("%s @> CAST(%s AS jsonb)", column, doc), nil
+}
+
+// ArrayContains 生成 col @> ARRAY[v1, v2]，列应为数组类型
+func (s *postgresqlSql) ArrayContains(column string, values []string) (string, error) {
+	return fmt.Sprintf("%s @> ARRAY[%s]", column, strings.Join(values, ", ")), nil
+}
+
+// ArrayAny 生成 v = ANY(col)
+func (s *postgresqlSql) ArrayAny(column, value string) (string, error) {
+	return fmt.Sprintf("%s = ANY(%s)", value, column), nil
+}
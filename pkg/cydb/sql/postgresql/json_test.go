@@ -0,0 +1,39 @@
+package sqlpostgresql
+
+import (
+	"testing"
+
+	cydb "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONAndArrayWhere(t *testing.T) {
+	dt, ok := cydb.GetSqlTransformer("postgresql")
+	require.True(t, ok)
+	r, err := cydb.Builder().Table(cydb.TABLE("sales", "orders")).
+		Fields([]cydb.Expression{cydb.FIELD("id"), cydb.JSON_GET("attrs", "items", "0").SetAlias("first_item")}).
+		Where(cydb.AND(
+			cydb.COMPARE(cydb.JSON_TEXT("attrs", "customer", "name"), cydb.OP_EQ, cydb.WithParameter("name")),
+			cydb.JSON_CONTAINS("attrs", cydb.WithParameter("doc")),
+			cydb.ARRAY_CONTAINS("tags", []any{"a", &cydb.ParameterValue{Name: "tag"}}),
+			cydb.ANY("labels", cydb.WithParameter("label")),
+		)).Build(dt)
+	require.NoError(t, err)
+	t.Log(r.SQL)
+	assert.Contains(t, r.SQL, "sales.orders")
+	assert.Contains(t, r.SQL, `(attrs->'items'->0) AS first_item`)
+	assert.Contains(t, r.SQL, `(attrs->'customer'->>'name') = :name`)
+	assert.Contains(t, r.SQL, `attrs @> CAST(:doc AS jsonb)`)
+	assert.Contains(t, r.SQL, `tags @> ARRAY['a', :tag]`)
+	assert.Contains(t, r.SQL, `:label = ANY(labels)`)
+
+	mysql := cydb.MySQLJSON{}
+	s, err := mysql.JSONPath("attrs", []string{"customer", "first name", "0"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, `JSON_UNQUOTE(JSON_EXTRACT(attrs, '$.customer."first name"[0]'))`, s)
+	// MySQL 字面量中的反斜杠需要写两次，服务端读到的路径为 $."say \"hi\""
+	s, err = mysql.JSONPath("attrs", []string{`say "hi"`}, false)
+	require.NoError(t, err)
+	assert.Equal(t, `JSON_EXTRACT(attrs, '$."say \\"hi\\""')`, s)
+}
@@ -0,0 +1,14 @@
+package sqlpostgresql
+
+import (
+	"fmt"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+)
+
+var _ SkipLocker = (*postgresqlSql)(nil)
+
+// SkipLockedSQL implements cydb.SkipLocker.
+func (s *postgresqlSql) SkipLockedSQL(query string, limit int) (string, bool) {
+	return fmt.Sprintf("%s LIMIT %d FOR UPDATE SKIP LOCKED", query, limit), true
+}
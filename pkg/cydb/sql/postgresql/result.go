@@ -0,0 +1,27 @@
+package sqlpostgresql
+
+import (
+	"database/sql"
+	"strings"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+)
+
+var _ ResultTypeMapper = (*postgresqlSql)(nil)
+
+// ResultFieldType implements cydb.ResultTypeMapper.
+// lib/pq 返回 INT4、FLOAT8、TIMESTAMPTZ 等内部类型名，其余按 information_schema 类型名处理
+func (t *postgresqlSql) ResultFieldType(ct *sql.ColumnType) DBFieldType {
+	switch name := strings.ToUpper(ct.DatabaseTypeName()); name {
+	case "INT2", "INT4", "INT8", "BOOL", "OID":
+		return DBFieldTypeInt
+	case "FLOAT4", "FLOAT8", "NUMERIC", "MONEY":
+		return DBFieldTypeFloat
+	case "DATE", "TIME", "TIMETZ", "TIMESTAMP", "TIMESTAMPTZ":
+		return DBFieldTypeTime
+	case "VARBIT":
+		return DBFieldTypeBit
+	default:
+		return fieldType(name)
+	}
+}
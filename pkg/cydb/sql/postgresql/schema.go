@@ -0,0 +1,209 @@
+package sqlpostgresql
+
+import (
+	"strings"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/fj1981/infrakit/pkg/cyutil"
+)
+
+var (
+	_ TableSchemaInspector = (*postgresqlSql)(nil)
+	_ TableLister          = (*postgresqlSql)(nil)
+)
+
+// fkActions pg_constraint 中外键动作的编码
+var fkActions = map[string]string{
+	"a": "NO ACTION",
+	"r": "RESTRICT",
+	"c": "CASCADE",
+	"n": "SET NULL",
+	"d": "SET DEFAULT",
+}
+
+var constraintTypes = map[string]ConstraintType{
+	"p": ConstraintPrimaryKey,
+	"u": ConstraintUnique,
+	"f": ConstraintForeignKey,
+	"c": ConstraintCheck,
+}
+
+// GetTableSchema database 为 schema 名，规则见 resolveSchema
+func (s *postgresqlSql) GetTableSchema(cli DatabaseClient, database, tableName string) (*TableSchema, error) {
+	schema, tableName, err := resolveSchema(cli, database, tableName)
+	if err != nil {
+		return nil, err
+	}
+	row, err := InternalQueryOne(cli, `SELECT obj_description(c.oid, 'pg_class') AS comment
+		FROM pg_catalog.pg_class c JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2`, schema, tableName)
+	if err != nil {
+		return nil, err
+	}
+	ts := &TableSchema{Schema: schema, Name: tableName, Comment: cyutil.GetStr(row, "comment")}
+
+	rows, err := InternalQuery(cli, `SELECT c.column_name AS name, c.ordinal_position AS pos, c.data_type AS data_type, c.udt_name AS udt,
+		pg_catalog.format_type(a.atttypid, a.atttypmod) AS column_type,
+		c.character_maximum_length AS len, c.numeric_precision AS prec, c.numeric_scale AS scale,
+		c.is_nullable AS nullable, c.column_default AS dflt, c.is_identity AS is_identity,
+		pg_catalog.col_description(a.attrelid, a.attnum) AS comment,
+		pg_catalog.pg_get_serial_sequence(quote_ident(c.table_schema) || '.' || quote_ident(c.table_name), c.column_name) AS seq
+		FROM information_schema.columns c
+		JOIN pg_catalog.pg_attribute a
+			ON a.attrelid = (quote_ident(c.table_schema) || '.' || quote_ident(c.table_name))::regclass AND a.attname = c.column_name
+		WHERE c.table_schema = $1 AND c.table_name = $2
+		ORDER BY c.ordinal_position`, ts.Schema, tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		dataType := cyutil.GetStr(r, "data_type")
+		ft := fieldType(dataType)
+		if ft == DBFieldTypeString {
+			// information_schema 中为 timestamp without time zone 等长名称，再按 udt_name 匹配一次
+			ft = fieldType(cyutil.GetStr(r, "udt"))
+		}
+		seq := cyutil.GetStr(r, "seq")
+		ts.Columns = append(ts.Columns, &SchemaColumn{
+			Name:          cyutil.GetStr(r, "name"),
+			Position:      cyutil.GetInt(r, "pos"),
+			DataType:      dataType,
+			ColumnType:    cyutil.GetStr(r, "column_type"),
+			FieldType:     ft,
+			Nullable:      cyutil.GetStr(r, "nullable") == "YES",
+			Default:       NullableString(r["dflt"]),
+			Length:        cyutil.GetInt64(r, "len"),
+			Precision:     cyutil.GetInt64(r, "prec"),
+			Scale:         cyutil.GetInt64(r, "scale"),
+			AutoIncrement: seq != "" || cyutil.GetStr(r, "is_identity") == "YES",
+			Sequence:      seq,
+			Comment:       cyutil.GetStr(r, "comment"),
+		})
+	}
+
+	rows, err = InternalQuery(cli, `SELECT con.conname AS name, con.contype AS type, a.attname AS col,
+		pg_catalog.pg_get_constraintdef(con.oid, true) AS def,
+		rn.nspname AS ref_schema, rc.relname AS ref_table, ra.attname AS ref_col,
+		con.confupdtype AS on_update, con.confdeltype AS on_delete
+		FROM pg_catalog.pg_constraint con
+		JOIN pg_catalog.pg_class c ON c.oid = con.conrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(attnum, refnum, ord) ON true
+		LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = c.oid AND a.attnum = k.attnum
+		LEFT JOIN pg_catalog.pg_class rc ON rc.oid = con.confrelid
+		LEFT JOIN pg_catalog.pg_namespace rn ON rn.oid = rc.relnamespace
+		LEFT JOIN pg_catalog.pg_attribute ra ON ra.attrelid = con.confrelid AND ra.attnum = k.refnum
+		WHERE n.nspname = $1 AND c.relname = $2 AND con.contype IN ('p', 'u', 'f', 'c')
+		ORDER BY con.conname, k.ord`, ts.Schema, tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		name := cyutil.GetStr(r, "name")
+		col := cyutil.GetStr(r, "col")
+		tp := constraintTypes[cyutil.GetStr(r, "type")]
+		if tp == ConstraintForeignKey {
+			fk := ts.AppendForeignKeyColumn(name, col, cyutil.GetStr(r, "ref_col"))
+			fk.RefSchema = cyutil.GetStr(r, "ref_schema")
+			fk.RefTable = cyutil.GetStr(r, "ref_table")
+			fk.OnUpdate = fkActions[cyutil.GetStr(r, "on_update")]
+			fk.OnDelete = fkActions[cyutil.GetStr(r, "on_delete")]
+			continue
+		}
+		c := ts.AppendConstraintColumn(name, tp, col)
+		if tp == ConstraintCheck {
+			c.Check = strings.TrimPrefix(cyutil.GetStr(r, "def"), "CHECK ")
+		}
+	}
+	ts.MarkPrimaryKey()
+
+	// pg_get_indexdef 按位置取列名或表达式，兼容表达式索引
+	rows, err = InternalQuery(cli, `SELECT i.relname AS name, ix.indisunique AS is_unique,
+		pg_catalog.pg_get_indexdef(ix.indexrelid, k.ord, true) AS col
+		FROM pg_catalog.pg_index ix
+		JOIN pg_catalog.pg_class t ON t.oid = ix.indrelid
+		JOIN pg_catalog.pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace
+		CROSS JOIN LATERAL generate_series(1, ix.indnkeyatts) AS k(ord)
+		WHERE n.nspname = $1 AND t.relname = $2 AND NOT ix.indisprimary
+		ORDER BY i.relname, k.ord`, ts.Schema, tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		ts.AppendIndexColumn(cyutil.GetStr(r, "name"), cyutil.GetStr(r, "col"), cyutil.GetBool(r, "is_unique"))
+	}
+
+	rows, err = InternalQuery(cli, `SELECT t.tgname AS name, t.tgtype AS tgtype, pg_catalog.pg_get_triggerdef(t.oid, true) AS def
+		FROM pg_catalog.pg_trigger t
+		JOIN pg_catalog.pg_class c ON c.oid = t.tgrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE NOT t.tgisinternal AND n.nspname = $1 AND c.relname = $2
+		ORDER BY t.tgname`, ts.Schema, tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		timing, event := triggerTiming(cyutil.GetInt(r, "tgtype"))
+		ts.Triggers = append(ts.Triggers, &SchemaTrigger{
+			Name:       cyutil.GetStr(r, "name"),
+			Timing:     timing,
+			Event:      event,
+			Definition: cyutil.GetStr(r, "def"),
+		})
+	}
+	return ts, nil
+}
+
+// triggerTiming 解析 pg_trigger.tgtype 位标记
+func triggerTiming(tgtype int) (string, string) {
+	timing := "AFTER"
+	if tgtype&2 != 0 {
+		timing = "BEFORE"
+	} else if tgtype&64 != 0 {
+		timing = "INSTEAD OF"
+	}
+	var events []string
+	for _, e := range []struct {
+		bit  int
+		name string
+	}{{4, "INSERT"}, {16, "UPDATE"}, {8, "DELETE"}, {32, "TRUNCATE"}} {
+		if tgtype&e.bit != 0 {
+			events = append(events, e.name)
+		}
+	}
+	return timing, strings.Join(events, " OR ")
+}
+
+// GetTableNames database 为 schema 名，规则见 resolveSchema
+func (s *postgresqlSql) GetTableNames(cli DatabaseClient, database string) ([]string, error) {
+	schema, _, err := resolveSchema(cli, database, "")
+	if err != nil {
+		return nil, err
+	}
+	return QueryNames(cli, "SELECT table_name AS name FROM information_schema.tables WHERE table_schema = $1 AND table_type = 'BASE TABLE' ORDER BY table_name", schema)
+}
+
+// resolveSchema 确定表所在的 schema：tableName 带 schema 前缀时使用该前缀；
+// database 为空或为当前库名时使用 current_schema()，否则 database 即为 schema 名
+func resolveSchema(cli DatabaseClient, database, tableName string) (string, string, error) {
+	if schema, table, ok := strings.Cut(tableName, "."); ok {
+		return unquoteIdent(schema), unquoteIdent(table), nil
+	}
+	tableName = unquoteIdent(tableName)
+	if database != "" && database != cli.Database() {
+		return database, tableName, nil
+	}
+	row, err := InternalQueryOne(cli, "SELECT current_schema() AS name")
+	if err != nil {
+		return "", "", err
+	}
+	return cyutil.GetStr(row, "name"), tableName, nil
+}
+
+func unquoteIdent(name string) string {
+	if len(name) >= 2 && name[0] == '"' && name[len(name)-1] == '"' {
+		return strings.ReplaceAll(name[1:len(name)-1], `""`, `"`)
+	}
+	return name
+}
@@ -53,7 +53,7 @@ func (s *postgresqlSql) sortTables(j DatabaseClient, tableNames []string) ([]*Sq
 		AND ccu.table_name IN (%s)`,
 		inClause, inClause)
 
-	result, err := j.Query(query)
+	result, err := InternalQuery(j, query)
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +147,7 @@ func (s *postgresqlSql) sortFunctions(j DatabaseClient, functionNames []string)
 		AND caller.proname <> called.proname`,
 		database, inClause, inClause)
 
-	result, err := j.Query(query)
+	result, err := InternalQuery(j, query)
 	if err != nil {
 		return nil, err
 	}
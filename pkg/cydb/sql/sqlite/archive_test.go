@@ -0,0 +1,63 @@
+package sqlsqlite
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiver(t *testing.T) {
+	const ddl = "CREATE TABLE ar_log (id INTEGER PRIMARY KEY, msg TEXT, created_at INTEGER)"
+	src, dst := openSQLite(t, ddl), openSQLite(t, ddl)
+	for i := 1; i <= 30; i++ {
+		_, err := src.GetDB().Exec("INSERT INTO ar_log VALUES (?, ?, ?)", i, fmt.Sprintf("msg-%d", i), i*100)
+		require.NoError(t, err)
+	}
+
+	opts := []ArchiveOption{
+		WithArchiveTarget(dst),
+		WithArchiveWhere(map[string]interface{}{"created_at": 2500}, WithLT("created_at")),
+		WithArchiveBatchSize(4),
+		WithArchiveMaxBatches(3),
+	}
+	a, err := NewArchiver(src, "ar_log", opts...)
+	require.NoError(t, err)
+
+	res, err := a.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, res.Batches)
+	assert.EqualValues(t, 12, res.Archived)
+	assert.False(t, res.Done)
+
+	// 新建的 Archiver 从断点继续
+	a, err = NewArchiver(src, "ar_log", opts...)
+	require.NoError(t, err)
+	res, err = a.Run(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 12, res.Archived)
+	assert.False(t, res.Done)
+	res, err = a.Run(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, res.Archived)
+	assert.True(t, res.Done)
+
+	left, err := src.Count("ar_log", map[string]interface{}{})
+	require.NoError(t, err)
+	assert.EqualValues(t, 6, left)
+	archived, err := dst.List("ar_log", map[string]interface{}{}, WithOrderBy(ASC("id")))
+	require.NoError(t, err)
+	require.Len(t, archived, 24)
+	assert.Equal(t, "msg-24", archived[23]["msg"])
+
+	// 仅清理，不归档
+	p, err := NewArchiver(src, "ar_log", WithArchiveBatchSize(4), WithArchiveCheckpoint("ar_log_purge"))
+	require.NoError(t, err)
+	require.NoError(t, p.Handler()(context.Background()))
+	left, err = src.Count("ar_log", map[string]interface{}{})
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, left)
+}
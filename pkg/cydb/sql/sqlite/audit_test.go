@@ -0,0 +1,69 @@
+package sqlsqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditPolicy(t *testing.T) {
+	cli := openSQLite(t,
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, name VARCHAR(64),
+		created_at DATETIME, updated_at DATETIME, created_by VARCHAR(64), deleted_at DATETIME)`)
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	policy := DefaultAuditPolicy()
+	policy.Now = func() time.Time { return now }
+	cli.SetAuditPolicy(policy, "users")
+
+	db := cli.WithContext(ContextWithActor(context.Background(), "alice"))
+	data := map[string]interface{}{"id": 1, "name": "a"}
+	_, err := db.Insert("users", data)
+	require.NoError(t, err)
+	assert.NotContains(t, data, "created_at", "caller data must not be modified")
+	_, err = db.Insert("users", map[string]interface{}{"id": 2, "name": "b"})
+	require.NoError(t, err)
+
+	row, err := db.First("users", map[string]interface{}{"id": 1}, WithEQ("id"))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", row["created_by"])
+	assert.NotNil(t, row["created_at"])
+	assert.Nil(t, row["deleted_at"])
+
+	// Upsert 命中已有行时保留创建列
+	created := row["created_at"]
+	now = now.Add(time.Hour)
+	_, err = cli.WithContext(ContextWithActor(context.Background(), "bob")).Upsert("users", map[string]interface{}{"id": 1, "name": "a2"})
+	require.NoError(t, err)
+	row, err = db.First("users", map[string]interface{}{"id": 1}, WithEQ("id"))
+	require.NoError(t, err)
+	assert.Equal(t, "a2", row["name"])
+	assert.Equal(t, "alice", row["created_by"])
+	assert.Equal(t, created, row["created_at"])
+	assert.NotEqual(t, created, row["updated_at"])
+
+	affected, err := db.Delete("users", map[string]interface{}{"id": 1}, WithEQ("id"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, affected)
+
+	count, err := db.Count("users", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	list, err := db.List("users", nil)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	count, err = db.Unscoped().Count("users", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+
+	_, err = db.Unscoped().Delete("users", map[string]interface{}{"id": 1}, WithEQ("id"))
+	require.NoError(t, err)
+	count, err = db.Unscoped().Count("users", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+}
@@ -0,0 +1,15 @@
+package sqlsqlite
+
+import (
+	. "github.com/fj1981/infrakit/pkg/cydb"
+)
+
+// MaxBindParams SQLite 默认 SQLITE_MAX_VARIABLE_NUMBER 为 32766
+func (s *sqliteSql) MaxBindParams() int {
+	return 32766
+}
+
+// BulkLoad 在同一事务中使用预编译语句逐行写入
+func (s *sqliteSql) BulkLoad(cli *DBCli, table string, columns []string, rows [][]any, opt *BulkLoadOptions) (int64, error) {
+	return cli.BulkInsertPrepared(table, columns, rows, opt)
+}
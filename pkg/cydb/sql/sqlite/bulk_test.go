@@ -0,0 +1,74 @@
+package sqlsqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkInsert(t *testing.T) {
+	cli := openSQLite(t, "CREATE TABLE items (id INTEGER PRIMARY KEY, name VARCHAR(32), note VARCHAR(32))")
+
+	data := make([]map[string]interface{}, 0, 2500)
+	for i := 1; i <= 2500; i++ {
+		data = append(data, map[string]interface{}{"id": i, "name": fmt.Sprintf("n%d", i), "unknown": 1})
+	}
+	var reports []int64
+	n, err := cli.BulkInsert("items", data, WithBulkProgress(func(loaded, total int64) {
+		assert.EqualValues(t, 2500, total)
+		reports = append(reports, loaded)
+	}))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2500, n)
+	assert.Equal(t, []int64{1000, 2000, 2500}, reports)
+
+	more := []map[string]interface{}{
+		{"id": 3001, "name": "a"},
+		{"id": 3002, "name": "b", "note": "x"},
+		{"id": 3003, "name": "c"},
+	}
+	reports = nil
+	n, err = cli.BulkInsert("items", more, WithBulkNative(false), WithBulkChunkSize(2), WithBulkProgress(func(loaded, total int64) {
+		reports = append(reports, loaded)
+	}))
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, n)
+	assert.Equal(t, []int64{2, 3}, reports)
+
+	count, err := cli.Count("items", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2503, count)
+	row, err := cli.First("items", map[string]interface{}{"id": 3002}, WithEQ("id"))
+	require.NoError(t, err)
+	assert.Equal(t, "x", row["note"])
+}
+
+func TestBulkInsertTenantAudit(t *testing.T) {
+	cli := openSQLite(t, "CREATE TABLE bk_doc (id INTEGER PRIMARY KEY, tenant_id INTEGER, name TEXT, created_at DATETIME, updated_at DATETIME)")
+	cli.SetTenantPolicy(&TenantPolicy{Tables: []string{"bk_doc"}})
+	cli.SetAuditPolicy(DefaultAuditPolicy(), "bk_doc")
+
+	_, err := cli.BulkInsert("bk_doc", []map[string]interface{}{{"id": 1, "name": "a"}})
+	assert.True(t, errors.Is(err, ErrTenantRequired))
+
+	data := []map[string]interface{}{{"id": 1, "name": "a"}, {"id": 2, "name": "b", "tenant_id": 2}}
+	db := cli.WithContext(ContextWithTenant(context.Background(), 1))
+	n, err := db.BulkInsert("bk_doc", data)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+	assert.NotContains(t, data[0], "tenant_id", "caller data must not be modified")
+
+	rows, err := db.List("bk_doc", nil, WithOrderBy(ASC("id")))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	for _, row := range rows {
+		assert.EqualValues(t, 1, row["tenant_id"])
+		assert.NotNil(t, row["created_at"])
+		assert.NotNil(t, row["updated_at"])
+	}
+}
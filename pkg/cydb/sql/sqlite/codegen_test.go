@@ -0,0 +1,99 @@
+package sqlsqlite
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateModels(t *testing.T) {
+	cli := openSQLite(t,
+		"CREATE TABLE user_account (id INTEGER PRIMARY KEY, email VARCHAR(64) NOT NULL, score REAL, avatar BLOB, type TEXT NOT NULL)",
+		"CREATE TABLE kv (type TEXT PRIMARY KEY, value TEXT)")
+
+	files, err := cli.GenerateModels(WithCodegenPackage("model"), WithCodegenCRUD(true))
+	require.NoError(t, err)
+	src := string(files["user_account.gen.go"])
+	assert.Contains(t, src, CodegenHeader)
+	assert.Contains(t, src, "package model")
+	assert.Contains(t, src, "type UserAccount struct")
+	assert.Contains(t, src, "`db:\"id\" json:\"id\" pk:\"true\"`")
+	assert.Contains(t, src, "sql.NullFloat64 `db:\"score\"")
+	assert.Contains(t, src, "Avatar []byte")
+	assert.Contains(t, src, "func (r *UserAccountRepo) Get(id int64) (*UserAccount, error)")
+	assert.Contains(t, src, "if m.ID == 0 {")
+	assert.Contains(t, src, "data := m.ToMapOmit(r.cli.AuditColumns(UserAccountTable)...)")
+	assert.Contains(t, src, "cydb.WithEQ(pk...), cydb.WithOmitFields(pk...)")
+	assert.Contains(t, string(files["kv.gen.go"]), "func (r *KvRepo) Delete(typeVal string) (int64, error)")
+
+	again, err := cli.GenerateModels(WithCodegenPackage("model"), WithCodegenCRUD(true))
+	require.NoError(t, err)
+	assert.Equal(t, files, again)
+
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "old.gen.go")
+	require.NoError(t, os.WriteFile(stale, []byte(CodegenHeader+"\npackage model\n"), 0o644))
+	kept := filepath.Join(dir, "custom.gen.go")
+	require.NoError(t, os.WriteFile(kept, []byte("package model\n"), 0o644))
+	require.NoError(t, cli.GenerateModelsToDir(dir, WithCodegenPackage("model")))
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, kept)
+	assert.FileExists(t, filepath.Join(dir, "user_account.gen.go"))
+}
+
+func TestListAs(t *testing.T) {
+	cli := openSQLite(t, "CREATE TABLE item (id INTEGER PRIMARY KEY, name TEXT NOT NULL, price REAL)")
+	_, err := cli.Insert("item", map[string]interface{}{"id": 1, "name": "a", "price": 1.5})
+	require.NoError(t, err)
+	_, err = cli.Insert("item", map[string]interface{}{"id": 2, "name": "b"})
+	require.NoError(t, err)
+
+	type item struct {
+		ID    int64           `db:"id"`
+		Name  string          `db:"name"`
+		Price sql.NullFloat64 `db:"price"`
+	}
+	list, err := ListAs[item](cli, "item", nil)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, sql.NullFloat64{Float64: 1.5, Valid: true}, list[0].Price)
+	assert.False(t, list[1].Price.Valid)
+
+	one, err := FirstAs[item](cli, "item", map[string]interface{}{"name": "b"}, WithEQ("name"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), one.ID)
+
+	none, err := FirstAs[item](cli, "item", map[string]interface{}{"name": "x"}, WithEQ("name"))
+	require.NoError(t, err)
+	assert.Nil(t, none)
+}
+
+func TestUpdateOmitFields(t *testing.T) {
+	cli := openSQLite(t, "CREATE TABLE doc (id INTEGER PRIMARY KEY, name TEXT, created_at DATETIME, updated_at DATETIME)")
+	cli.SetAuditPolicy(DefaultAuditPolicy(), "doc")
+	assert.Equal(t, []string{"created_at", "updated_at", "created_by", "updated_by", "deleted_at", "deleted_by"}, cli.AuditColumns("doc"))
+	assert.Nil(t, cli.AuditColumns("other"))
+	_, err := cli.Insert("doc", map[string]interface{}{"id": 1, "name": "a"})
+	require.NoError(t, err)
+	row, err := cli.First("doc", map[string]interface{}{"id": 1}, WithEQ("id"))
+	require.NoError(t, err)
+
+	affected, err := cli.Update("doc", map[string]interface{}{"id": 1, "name": "b"}, WithEQ("id"), WithOmitFields("id"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, affected)
+	updated, err := cli.First("doc", map[string]interface{}{"id": 1}, WithEQ("id"))
+	require.NoError(t, err)
+	assert.Equal(t, "b", updated["name"])
+	assert.Equal(t, row["created_at"], updated["created_at"])
+	assert.NotNil(t, updated["updated_at"])
+
+	dt, _ := GetSqlTransformer("sqlite")
+	r, err := WithOmitFields("ID")(Builder().Table("doc").Fields("id,name")).Type(SQLOperationUpdate).Where(EQ("id")).Build(dt)
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE doc SET name = :name WHERE id = :id", r.SQL)
+}
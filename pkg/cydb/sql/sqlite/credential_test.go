@@ -0,0 +1,87 @@
+package sqlsqlite
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveCredential(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("CYDB_TEST_PW", "from-env")
+	pw, err := ResolveCredential(ctx, "env:CYDB_TEST_PW")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", pw)
+	_, err = ResolveCredential(ctx, "env:CYDB_TEST_PW_MISSING")
+	assert.Error(t, err)
+
+	file := filepath.Join(t.TempDir(), "pw")
+	require.NoError(t, os.WriteFile(file, []byte("from-file\n"), 0o600))
+	pw, err = ResolveCredential(ctx, "file:"+file)
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", pw)
+
+	RegisterSecretProvider("vault", SecretProviderFunc(func(ctx context.Context, name string) (string, error) {
+		if name == "db/primary" {
+			return "from-vault", nil
+		}
+		return "", errors.New("not found")
+	}))
+	defer RegisterSecretProvider("vault", nil)
+	pw, err = ResolveCredential(ctx, "vault:db/primary")
+	require.NoError(t, err)
+	assert.Equal(t, "from-vault", pw)
+	_, err = ResolveCredential(ctx, "vault:db/other")
+	assert.Error(t, err)
+	_, err = ResolveCredential(ctx, "kms:db")
+	assert.Error(t, err)
+}
+
+func TestRefreshCredentials(t *testing.T) {
+	t.Setenv("CYDB_TEST_ROTATE", "v1")
+	mgr := &DBMgr{}
+	conn := DBConnection{Key: "cred_a", Type: "sqlite", Path: filepath.Join(t.TempDir(), "cred.db"), PwRef: "env:CYDB_TEST_ROTATE"}
+	require.NoError(t, mgr.InitByConfig(&Config{Connections: []DBConnection{conn}}))
+	defer mgr.CloseAll()
+	cli := mgr.GetCli("cred_a")
+	assert.Empty(t, cli.PW())
+	db := cli.GetDB()
+
+	// 密码未变化时不重建连接
+	require.NoError(t, mgr.RefreshCredentials(context.Background()))
+	assert.Same(t, db, cli.GetDB())
+
+	// 轮换后原地替换连接池，调用方持有的 DBCli 及其副本继续可用
+	cli.SetQueryCache(NewLocalQueryCache(time.Minute), time.Minute)
+	copied := cli.NoCache()
+	t.Setenv("CYDB_TEST_ROTATE", "v2")
+	require.NoError(t, mgr.RefreshCredentials(context.Background()))
+	assert.Same(t, cli, mgr.GetCli("cred_a"))
+	assert.NotSame(t, db, cli.GetDB())
+	assert.Empty(t, cli.PW())
+	_, err := cli.Count("sqlite_master", map[string]interface{}{})
+	require.NoError(t, err)
+	_, err = copied.Count("sqlite_master", map[string]interface{}{})
+	require.NoError(t, err)
+	db = cli.GetDB()
+
+	// 引用解析失败时保留现有连接
+	os.Unsetenv("CYDB_TEST_ROTATE")
+	assert.Error(t, mgr.RefreshCredentials(context.Background()))
+	assert.Same(t, db, cli.GetDB())
+}
+
+func TestStartCredentialRefreshInterval(t *testing.T) {
+	mgr := &DBMgr{}
+	assert.Error(t, mgr.StartCredentialRefresh(0))
+	assert.Error(t, mgr.StartCredentialRefresh(-time.Second))
+	require.NoError(t, mgr.StartCredentialRefresh(time.Hour))
+	mgr.StopCredentialRefresh()
+}
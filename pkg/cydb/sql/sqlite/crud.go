@@ -91,6 +91,12 @@ func (s *sqliteSql) SupportsBatch() bool {
 // BuildReplaceSQL implements DatabaseTransformer for SQLite
 // SQLite 支持原生 REPLACE INTO 语法
 func (s *sqliteSql) BuildReplaceSQL(tableName string, bs BuildSql) (sql string, paramOrder []string, err error) {
+	if _, _, ok, err := bs.GetConflictGuard(s); err != nil || ok {
+		if err == nil {
+			err = TenantReplaceError(tableName)
+		}
+		return "", nil, err
+	}
 	// 构建 SQLite REPLACE INTO 语句
 	var sb strings.Builder
 	sb.WriteString("REPLACE INTO ")
@@ -102,14 +108,13 @@ func (s *sqliteSql) BuildReplaceSQL(tableName string, bs BuildSql) (sql string,
 		return "", nil, err
 	}
 	sb.WriteString(columnNames)
-	sb.WriteString(") VALUES (")
+	sb.WriteString(") VALUES ")
 
 	columnValues, fields, err := bs.GetValuesString(s, true)
 	if err != nil {
 		return "", nil, err
 	}
 	sb.WriteString(columnValues)
-	sb.WriteString(")")
 
 	return sb.String(), fields, nil
 }
@@ -130,7 +135,7 @@ func (s *sqliteSql) BuildUpsertSQL(tableName string, bs BuildSql) (sql string, p
 		return "", nil, err
 	}
 	sb.WriteString(columnNames)
-	sb.WriteString(") VALUES (")
+	sb.WriteString(") VALUES ")
 
 	// 构建 INSERT VALUES 占位符
 	columnValues, paramOrder, err := bs.GetValuesString(s, true)
@@ -139,7 +144,6 @@ func (s *sqliteSql) BuildUpsertSQL(tableName string, bs BuildSql) (sql string, p
 	}
 
 	sb.WriteString(columnValues)
-	sb.WriteString(")")
 
 	// 构建 ON CONFLICT 部分
 	// 获取主键字段用于冲突检测
@@ -162,6 +166,18 @@ func (s *sqliteSql) BuildUpsertSQL(tableName string, bs BuildSql) (sql string, p
 			sb.WriteString(updateAssignStr)
 			paramOrder = append(paramOrder, updateFields...)
 		}
+		column, value, ok, err := bs.GetConflictGuard(s)
+		if err != nil {
+			return "", nil, err
+		}
+		if ok {
+			sb.WriteString(" WHERE ")
+			sb.WriteString(s.EscapeTableName(tableName))
+			sb.WriteString(".")
+			sb.WriteString(column)
+			sb.WriteString(" = ")
+			sb.WriteString(value)
+		}
 	}
 
 	return sb.String(), paramOrder, nil
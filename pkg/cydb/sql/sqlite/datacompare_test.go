@@ -0,0 +1,129 @@
+package sqlsqlite
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareTableData(t *testing.T) {
+	const ddl = "CREATE TABLE dc_item (id INTEGER PRIMARY KEY, name TEXT, qty INTEGER)"
+	src, dst := openSQLite(t, ddl), openSQLite(t, ddl)
+	for _, cli := range []*DBCli{src, dst} {
+		for i := 1; i <= 25; i++ {
+			_, err := cli.GetDB().Exec("INSERT INTO dc_item VALUES (?, ?, ?)", i, fmt.Sprintf("item-%d", i), i*10)
+			require.NoError(t, err)
+		}
+	}
+
+	report, err := CompareTableData(src, dst, "dc_item", WithCompareChunkSize(10))
+	require.NoError(t, err)
+	assert.True(t, report.Equal())
+	assert.Equal(t, 3, report.Chunks)
+	assert.EqualValues(t, 25, report.TargetRows)
+
+	_, err = dst.GetDB().Exec("DELETE FROM dc_item WHERE id = 4")
+	require.NoError(t, err)
+	_, err = dst.GetDB().Exec("UPDATE dc_item SET name = 'it''s changed' WHERE id = 12")
+	require.NoError(t, err)
+	_, err = dst.GetDB().Exec("INSERT INTO dc_item VALUES (40, 'extra', 1), (41, 'extra', 2)")
+	require.NoError(t, err)
+	_, err = src.GetDB().Exec("UPDATE dc_item SET qty = NULL WHERE id = 20")
+	require.NoError(t, err)
+
+	report, err = CompareTableData(src, dst, "dc_item", WithCompareChunkSize(10), WithCompareFixSQL())
+	require.NoError(t, err)
+	assert.False(t, report.Equal())
+	assert.Len(t, report.Mismatched, 3)
+	kinds := map[string]DataDiffKind{}
+	for _, d := range report.Diffs {
+		kinds[fmt.Sprint(d.PK["id"])] = d.Kind
+	}
+	assert.Equal(t, map[string]DataDiffKind{"4": DataMissing, "12": DataDifferent, "20": DataDifferent, "40": DataExtra, "41": DataExtra}, kinds)
+	for _, d := range report.Diffs {
+		if d.Kind == DataDifferent && fmt.Sprint(d.PK["id"]) == "12" {
+			assert.Equal(t, []string{"name"}, d.Columns)
+		}
+	}
+	for _, sql := range report.FixSQL() {
+		_, err = dst.GetDB().Exec(sql)
+		require.NoError(t, err, sql)
+	}
+	report, err = CompareTableData(src, dst, "dc_item", WithCompareChunkSize(7))
+	require.NoError(t, err)
+	assert.True(t, report.Equal(), report.Diffs)
+
+	_, err = dst.GetDB().Exec("DELETE FROM dc_item WHERE id > 10")
+	require.NoError(t, err)
+	report, err = CompareTableData(src, dst, "dc_item", WithCompareMaxDiffs(3))
+	require.NoError(t, err)
+	assert.True(t, report.Truncated)
+	assert.Len(t, report.Diffs, 3)
+}
+
+func TestCompareTableDataCompositeKey(t *testing.T) {
+	const ddl = "CREATE TABLE dc_pair (a INTEGER, b TEXT, v TEXT, PRIMARY KEY (a, b))"
+	src, dst := openSQLite(t, ddl), openSQLite(t, ddl)
+	for _, cli := range []*DBCli{src, dst} {
+		for i := 1; i <= 4; i++ {
+			for _, b := range []string{"x", "y", "z"} {
+				_, err := cli.GetDB().Exec("INSERT INTO dc_pair VALUES (?, ?, ?)", i, b, "v")
+				require.NoError(t, err)
+			}
+		}
+	}
+	_, err := dst.GetDB().Exec("UPDATE dc_pair SET v = 'w' WHERE a = 2 AND b = 'y'")
+	require.NoError(t, err)
+	report, err := CompareTableData(src, dst, "dc_pair", WithCompareChunkSize(4), WithCompareFixSQL())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, report.PK)
+	assert.EqualValues(t, 12, report.SourceRows)
+	assert.EqualValues(t, 12, report.TargetRows)
+	require.Len(t, report.Diffs, 1)
+	assert.Equal(t, DataDifferent, report.Diffs[0].Kind)
+	assert.Equal(t, `UPDATE dc_pair SET v = 'v' WHERE a = 2 AND b = 'y';`, report.Diffs[0].FixSQL)
+}
+
+func TestCompareTableDataColumnsFixSQL(t *testing.T) {
+	const ddl = "CREATE TABLE dc_note (id INTEGER PRIMARY KEY, name TEXT, memo TEXT)"
+	src, dst := openSQLite(t, ddl), openSQLite(t, ddl)
+	_, err := src.GetDB().Exec("INSERT INTO dc_note VALUES (1, 'new', 'src memo')")
+	require.NoError(t, err)
+	_, err = dst.GetDB().Exec("INSERT INTO dc_note VALUES (1, 'old', 'dst memo')")
+	require.NoError(t, err)
+
+	report, err := CompareTableData(src, dst, "dc_note", WithCompareColumns("name"), WithCompareFixSQL())
+	require.NoError(t, err)
+	require.Len(t, report.Diffs, 1)
+	assert.Equal(t, `UPDATE dc_note SET name = 'new' WHERE id = 1;`, report.Diffs[0].FixSQL)
+	_, err = dst.GetDB().Exec(report.Diffs[0].FixSQL)
+	require.NoError(t, err)
+	rows, err := dst.Query("SELECT name, memo FROM dc_note WHERE id = 1")
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "new", rows[0]["name"])
+	assert.Equal(t, "dst memo", rows[0]["memo"])
+}
+
+func TestCompareTableDataReservedColumns(t *testing.T) {
+	const ddl = `CREATE TABLE dc_kw (id INTEGER PRIMARY KEY, "order" INTEGER, "key" TEXT)`
+	src, dst := openSQLite(t, ddl), openSQLite(t, ddl)
+	_, err := src.GetDB().Exec(`INSERT INTO dc_kw VALUES (1, 5, 'a'), (2, 6, 'b')`)
+	require.NoError(t, err)
+	_, err = dst.GetDB().Exec(`INSERT INTO dc_kw VALUES (1, 9, 'a'), (3, 7, 'c')`)
+	require.NoError(t, err)
+
+	report, err := CompareTableData(src, dst, "dc_kw", WithCompareChunkSize(1), WithCompareFixSQL())
+	require.NoError(t, err)
+	require.Len(t, report.Diffs, 3)
+	for _, sql := range report.FixSQL() {
+		_, err = dst.GetDB().Exec(sql)
+		require.NoError(t, err, sql)
+	}
+	report, err = CompareTableData(src, dst, "dc_kw")
+	require.NoError(t, err)
+	assert.True(t, report.Equal(), report.Diffs)
+}
@@ -15,7 +15,22 @@ type sqliteSql struct {
 
 // GetReplaceSql implements cydb.SQLDialect.
 func (s *sqliteSql) GetReplaceSql(cli DatabaseClient, table string, rd *RowData) (string, error) {
-	panic("unimplemented")
+	cols := make([]string, 0, len(rd.Data))
+	vals := make([]string, 0, len(rd.Data))
+	for _, fd := range rd.Data {
+		cols = append(cols, fmt.Sprintf("\"%s\"", fd.Name))
+		// SQLite 字符串不支持反斜杠转义，单引号按 '' 转义
+		if fd.Type == DBFieldTypeString && fd.Data != nil {
+			vals = append(vals, QuoteLiteral(cyutil.ToStr(fd.Data)))
+			continue
+		}
+		v, err := FormatValue(fd.Data, fd.Type)
+		if err != nil {
+			return "", err
+		}
+		vals = append(vals, v)
+	}
+	return fmt.Sprintf("INSERT OR REPLACE INTO \"%s\" (%s) VALUES (%s);", table, strings.Join(cols, ", "), strings.Join(vals, ", ")), nil
 }
 
 func init() {
@@ -40,7 +55,7 @@ func (s *sqliteSql) GetTableColumns(cli DatabaseClient, database string, tableNa
 	// SQLite stores table schema information in the sqlite_master table and PRAGMA table_info
 	// First check if the table exists
 	query := "SELECT name FROM sqlite_master WHERE type='table' AND name=?"
-	rows, err := cli.Query(query, tableName)
+	rows, err := InternalQuery(cli, query, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("error checking if table exists: %w", err)
 	}
@@ -50,7 +65,7 @@ func (s *sqliteSql) GetTableColumns(cli DatabaseClient, database string, tableNa
 
 	// Use PRAGMA table_info to get column information
 	query = fmt.Sprintf("PRAGMA table_info(%s)", tableName)
-	rows, err = cli.Query(query)
+	rows, err = InternalQuery(cli, query)
 	if err != nil {
 		return nil, fmt.Errorf("error getting table columns: %w", err)
 	}
@@ -65,7 +80,7 @@ func (s *sqliteSql) GetTableColumns(cli DatabaseClient, database string, tableNa
 		colName = cyutil.GetStr(row, "name")
 		dataType = cyutil.GetStr(row, "type")
 		nullable = cyutil.GetInt(row, "notnull") == 0
-		isPK = cyutil.GetInt(row, "pk") == 1
+		isPK = cyutil.GetInt(row, "pk") > 0
 
 		column := &DBColumn{
 			Name:        colName,
@@ -79,27 +94,7 @@ func (s *sqliteSql) GetTableColumns(cli DatabaseClient, database string, tableNa
 		}
 
 		// Map SQLite data types to DBFieldType
-		// SQLite has dynamic typing, but we can infer from type affinity
-		dataTypeLower := strings.ToLower(dataType)
-
-		// Check for integer types
-		if strings.Contains(dataTypeLower, "int") {
-			column.DBFieldType = DBFieldTypeInt
-		} else if strings.Contains(dataTypeLower, "real") || strings.Contains(dataTypeLower, "float") ||
-			strings.Contains(dataTypeLower, "double") || strings.Contains(dataTypeLower, "decimal") {
-			column.DBFieldType = DBFieldTypeFloat
-		} else if strings.Contains(dataTypeLower, "date") || strings.Contains(dataTypeLower, "time") {
-			column.DBFieldType = DBFieldTypeTime
-		} else if strings.Contains(dataTypeLower, "blob") || strings.Contains(dataTypeLower, "binary") {
-			column.DBFieldType = DBFieldTypeBinary
-		} else if strings.Contains(dataTypeLower, "json") {
-			column.DBFieldType = DBFieldTypeJson
-		} else if strings.Contains(dataTypeLower, "bool") || strings.Contains(dataTypeLower, "boolean") {
-			column.DBFieldType = DBFieldTypeInt
-		} else {
-			// Default to string for text and other types
-			column.DBFieldType = DBFieldTypeString
-		}
+		column.DBFieldType = fieldType(dataType)
 
 		columns = append(columns, column)
 	}
@@ -107,6 +102,29 @@ func (s *sqliteSql) GetTableColumns(cli DatabaseClient, database string, tableNa
 	return columns, nil
 }
 
+// fieldType 按 SQLite 类型亲和性推断 DBFieldType
+func fieldType(dataType string) DBFieldType {
+	dataTypeLower := strings.ToLower(dataType)
+	switch {
+	case strings.Contains(dataTypeLower, "int"):
+		return DBFieldTypeInt
+	case strings.Contains(dataTypeLower, "real") || strings.Contains(dataTypeLower, "float") ||
+		strings.Contains(dataTypeLower, "double") || strings.Contains(dataTypeLower, "decimal"):
+		return DBFieldTypeFloat
+	case strings.Contains(dataTypeLower, "date") || strings.Contains(dataTypeLower, "time"):
+		return DBFieldTypeTime
+	case strings.Contains(dataTypeLower, "blob") || strings.Contains(dataTypeLower, "binary"):
+		return DBFieldTypeBinary
+	case strings.Contains(dataTypeLower, "json"):
+		return DBFieldTypeJson
+	case strings.Contains(dataTypeLower, "bool"):
+		return DBFieldTypeInt
+	default:
+		// Default to string for text and other types
+		return DBFieldTypeString
+	}
+}
+
 // IsTableExist implements database.ISql.
 func (s *sqliteSql) IsTableExist(cli DatabaseClient, tableName string) (bool, error) {
 	// SQLite stores table information in the sqlite_master table
@@ -0,0 +1,13 @@
+package sqlsqlite
+
+import "strings"
+
+// IsTransientError 识别 SQLite 数据库忙(SQLITE_BUSY)与锁定(SQLITE_LOCKED)错误
+func (s *sqliteSql) IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "SQLITE_BUSY") || strings.Contains(msg, "SQLITE_LOCKED") ||
+		strings.Contains(msg, "database is locked") || strings.Contains(msg, "database table is locked")
+}
@@ -0,0 +1,85 @@
+package sqlsqlite
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+)
+
+var _ FuncTranslator = (*sqliteSql)(nil)
+
+// sqliteFuncs MySQL 函数到 SQLite 的翻译表，NOW/CURDATE 按本地时间计算与 MySQL 保持一致
+var sqliteFuncs = FuncTable{
+	"NOW":          FuncConst("DATETIME('now', 'localtime')"),
+	"SYSDATE":      FuncConst("DATETIME('now', 'localtime')"),
+	"CURDATE":      FuncConst("DATE('now', 'localtime')"),
+	"CURTIME":      FuncConst("TIME('now', 'localtime')"),
+	"IF":           FuncIf,
+	"CONCAT":       FuncConcatOp,
+	"CONCAT_WS":    FuncConcatWsOp,
+	"RAND":         FuncConst("(ABS(RANDOM()) / 9223372036854775808.0)"),
+	"LCASE":        FuncRename("LOWER"),
+	"UCASE":        FuncRename("UPPER"),
+	"SUBSTRING":    FuncRename("SUBSTR"),
+	"CHAR_LENGTH":  FuncRename("LENGTH"),
+	"GROUP_CONCAT": sqliteGroupConcat,
+	"DATE_FORMAT": FuncDateFormat(StrftimeDateFormat, StrftimeText, func(expr, format string) string {
+		return fmt.Sprintf("STRFTIME(%s, %s)", format, expr)
+	}),
+	"UNIX_TIMESTAMP": sqliteUnixTimestamp,
+	"DATE_ADD":       sqliteDateAdd(""),
+	"DATE_SUB":       sqliteDateAdd("-"),
+}
+
+// TranslateFunc implements cydb.FuncTranslator.
+func (s *sqliteSql) TranslateFunc(name string, args []string, distinct bool) (string, bool) {
+	return sqliteFuncs.TranslateFunc(name, args, distinct)
+}
+
+// SQLite 的 GROUP_CONCAT(DISTINCT x) 不允许指定分隔符，默认分隔符时省略
+func sqliteGroupConcat(args []string, distinct bool) (string, bool) {
+	exprs, sep := SplitGroupConcat(args)
+	if len(exprs) == 0 {
+		return "", false
+	}
+	expr := exprs[0]
+	if len(exprs) > 1 {
+		expr = "(" + strings.Join(exprs, " || ") + ")"
+	}
+	if distinct {
+		if sep != QuoteLiteral(",") {
+			return "", false
+		}
+		return fmt.Sprintf("GROUP_CONCAT(DISTINCT %s)", expr), true
+	}
+	return fmt.Sprintf("GROUP_CONCAT(%s, %s)", expr, sep), true
+}
+
+func sqliteUnixTimestamp(args []string, distinct bool) (string, bool) {
+	switch len(args) {
+	case 0:
+		return "CAST(STRFTIME('%s', 'now') AS INTEGER)", true
+	case 1:
+		return fmt.Sprintf("CAST(STRFTIME('%%s', %s) AS INTEGER)", args[0]), true
+	}
+	return "", false
+}
+
+// DATE_ADD(x, INTERVAL n UNIT) -> DATETIME(x, (n) || ' unit')
+func sqliteDateAdd(sign string) FuncRewrite {
+	return func(args []string, distinct bool) (string, bool) {
+		if len(args) != 3 {
+			return "", false
+		}
+		unit := strings.ToLower(args[2])
+		switch unit {
+		case "day", "hour", "minute", "second", "month", "year":
+		case "week":
+			return fmt.Sprintf("DATETIME(%s, (%s(%s) * 7) || ' day')", args[0], sign, args[1]), true
+		default:
+			return "", false
+		}
+		return fmt.Sprintf("DATETIME(%s, (%s(%s)) || ' %s')", args[0], sign, args[1], unit), true
+	}
+}
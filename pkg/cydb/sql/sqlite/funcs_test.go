@@ -0,0 +1,55 @@
+package sqlsqlite
+
+import (
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslatedQuery(t *testing.T) {
+	cli := openSQLite(t,
+		"CREATE TABLE fn_item (id INTEGER PRIMARY KEY, kind TEXT, name TEXT, score INTEGER, created TEXT)",
+		`INSERT INTO fn_item VALUES (1, 'a', 'x', NULL, '2024-03-05 10:20:30'), (2, 'a', 'y', 2, '2024-03-06 00:00:00'), (3, 'b', 'z', 3, '2024-03-07 00:00:00')`)
+
+	row, err := cli.NQueryOne("SELECT IFNULL(score, 0) AS score, DATE_FORMAT(created, '%Y/%m/%d %H::%i') AS day, CONCAT_WS('-', `kind`, name) AS tag, IF(id > 1, 'y', 'n') AS flag FROM fn_item WHERE id = :id", map[string]interface{}{"id": 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, row["score"])
+	assert.Equal(t, "2024/03/05 10:20", row["day"])
+	assert.Equal(t, "a-x", row["tag"])
+	assert.Equal(t, "n", row["flag"])
+
+	_, err = cli.Query("SELECT kind, GROUP_CONCAT(name SEPARATOR ';') AS names FROM fn_item WHERE id > ? GROUP BY kind", 0)
+	assert.Error(t, err)
+	rows, err := cli.Translated().Query("SELECT kind, GROUP_CONCAT(name SEPARATOR ';') AS names FROM fn_item WHERE id > ? AND name <> '?' GROUP BY kind ORDER BY kind LIMIT 0, 10", 0)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "x;y", rows[0]["names"])
+
+	rows, err = cli.Translated().Query("SELECT DATE_FORMAT(created, '%H:%i') AS hm FROM fn_item WHERE created <= DATE_ADD('2024-03-04 10:20:30', INTERVAL 1 DAY) AND UNIX_TIMESTAMP(created) > ?", 0)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "10:20", rows[0]["hm"])
+}
+
+func TestTranslateConnIntrospection(t *testing.T) {
+	cli := openSQLiteConn(t, &DBConnection{Translate: true},
+		"CREATE TABLE tr_item (id INTEGER PRIMARY KEY, name TEXT NOT NULL)",
+		"CREATE INDEX idx_tr_name ON tr_item (name)")
+
+	cols, err := cli.GetTableColumns("tr_item")
+	require.NoError(t, err)
+	assert.Len(t, cols, 2)
+	schema, err := cli.GetTableSchema("tr_item")
+	require.NoError(t, err)
+	assert.Len(t, schema.Columns, 2)
+	assert.Len(t, schema.Indexes, 1)
+	_, err = cli.Insert("tr_item", map[string]interface{}{"id": 1, "name": "a"})
+	require.NoError(t, err)
+	rows, err := cli.Query("SELECT CONCAT(name, '!') AS v FROM tr_item WHERE id = ?", 1)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "a!", rows[0]["v"])
+}
@@ -0,0 +1,72 @@
+package sqlsqlite
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckStatement(t *testing.T) {
+	p := GuardPolicy{}
+	assert.NoError(t, CheckStatement("UPDATE t SET a = 1 WHERE id = :id", p))
+	assert.NoError(t, CheckStatement("SELECT ';' FROM t -- ; DROP TABLE t", p))
+	assert.NoError(t, CheckStatement("DELETE FROM t WHERE name = 'where';", p))
+	for _, sql := range []string{
+		"DELETE FROM t",
+		"UPDATE t SET name = 'x WHERE y'",
+		"/* cleanup */ TRUNCATE TABLE t",
+		"DROP TABLE t",
+		"SELECT 1; SELECT 2",
+		"WITH old AS (SELECT id FROM t WHERE id < 10) DELETE FROM t",
+		"WITH a AS (SELECT 1 WHERE 1 = 1), b (x) AS NOT MATERIALIZED (SELECT ')') UPDATE t SET a = 1",
+		"with recursive r as (select 1) delete from t",
+	} {
+		err := CheckStatement(sql, p)
+		assert.True(t, errors.Is(err, ErrDangerousStatement), sql)
+	}
+	assert.NoError(t, CheckStatement("WITH old AS (SELECT id FROM t) DELETE FROM t WHERE id IN (SELECT id FROM old)", p))
+	assert.NoError(t, CheckStatement("WITH x AS (SELECT 1) SELECT * FROM x", p))
+	assert.NoError(t, CheckStatement("DELETE FROM t", GuardPolicy{AllowNoWhere: true}))
+	assert.NoError(t, CheckStatement("CREATE INDEX i ON t (a); DROP INDEX j", GuardPolicy{AllowDDL: true, AllowMultiStatement: true}))
+}
+
+func TestGuardedDBCli(t *testing.T) {
+	cli := openSQLiteConn(t, &DBConnection{Guard: &GuardPolicy{}}, "CREATE TABLE guard_item (id INTEGER PRIMARY KEY, name TEXT)")
+	for i := 1; i <= 3; i++ {
+		_, err := cli.Insert("guard_item", map[string]interface{}{"id": i, "name": "n"})
+		require.NoError(t, err)
+	}
+
+	_, err := cli.Delete("guard_item", nil)
+	assert.True(t, errors.Is(err, ErrDangerousStatement))
+	_, err = cli.Update("guard_item", map[string]interface{}{"name": "x"})
+	assert.True(t, errors.Is(err, ErrDangerousStatement))
+	_, err = cli.Excute("DELETE FROM guard_item")
+	assert.True(t, errors.Is(err, ErrDangerousStatement))
+	_, err = cli.Query("SELECT 1; DROP TABLE guard_item")
+	assert.True(t, errors.Is(err, ErrDangerousStatement))
+
+	n, err := cli.Update("guard_item", map[string]interface{}{"id": 1, "name": "x"}, WithEQ("id"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = cli.AllowFullTable().Delete("guard_item", nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	// 批量导入与 InsertReturningID 的语句同样经过检查
+	n, err = cli.BulkInsert("guard_item", []map[string]interface{}{{"id": 4, "name": "b"}, {"id": 5, "name": "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	id, err := cli.InsertReturningID("guard_item", map[string]interface{}{"name": "r"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), id)
+	_, err = cli.Prepare("DROP TABLE guard_item")
+	assert.True(t, errors.Is(err, ErrDangerousStatement))
+
+	cli.SetGuard(nil)
+	_, err = cli.Excute("DELETE FROM guard_item")
+	assert.NoError(t, err)
+}
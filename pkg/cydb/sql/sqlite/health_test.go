@@ -0,0 +1,79 @@
+package sqlsqlite
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBMgrHealth(t *testing.T) {
+	mgr := &DBMgr{}
+	defer mgr.CloseAll()
+	cli := openSQLiteConn(t, &DBConnection{Key: "health"})
+	mgr.SetCli("health", cli)
+
+	_, err := cli.Query("SELECT 1")
+	require.NoError(t, err)
+	_, err = cli.Query("SELECT * FROM missing_table")
+	require.Error(t, err)
+
+	stats := mgr.Stats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, "health", stats[0].Key)
+	assert.Equal(t, ConnStateUp, stats[0].State)
+	assert.Equal(t, int64(2), stats[0].Queries)
+	assert.Equal(t, int64(1), stats[0].Errors)
+	assert.Equal(t, 1, stats[0].Pool.OpenConnections)
+
+	var events []ConnEvent
+	mgr.OnConnEvent(func(e ConnEvent) { events = append(events, e) })
+	mgr.CheckHealth(context.Background())
+	assert.Empty(t, events)
+
+	// 关闭底层连接池，健康检查先发出 down 事件，原地重连后发出 up 事件
+	require.NoError(t, cli.GetDB().Close())
+	mgr.CheckHealth(context.Background())
+	require.Len(t, events, 2)
+	assert.Equal(t, ConnStateDown, events[0].State)
+	assert.Error(t, events[0].Err)
+	assert.Equal(t, ConnStateUp, events[1].State)
+	assert.Same(t, cli, mgr.GetCli("health"))
+	_, err = cli.Query("SELECT 1")
+	require.NoError(t, err, "the DBCli held by callers must keep working")
+	_, err = cli.WithContext(context.Background()).Query("SELECT 1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), mgr.Stats()[0].Queries)
+
+	// 重连失败时发出 down 事件，恢复后发出 up 事件
+	events = nil
+	broken := openSQLiteConn(t, &DBConnection{Key: "broken"})
+	mgr.SetCli("broken", broken)
+	require.NoError(t, broken.GetDB().Close())
+	mgr.SetCli("broken", NewDBCli(broken.GetDB(), "sqlite", "broken", "", "", ""))
+	mgr.CheckHealth(context.Background())
+	require.Len(t, events, 1)
+	assert.Equal(t, "broken", events[0].Key)
+	assert.Equal(t, ConnStateDown, events[0].State)
+	assert.Equal(t, ConnStateDown, mgr.Stats()[0].State)
+	assert.NotEmpty(t, mgr.Stats()[0].LastError)
+
+	fresh := openSQLiteConn(t, &DBConnection{Key: "broken"})
+	mgr.SetCli("broken", fresh)
+	mgr.CheckHealth(context.Background())
+	require.Len(t, events, 2)
+	assert.Equal(t, ConnStateUp, events[1].State)
+
+	// 调用方 Close 的连接不会被健康检查重新打开
+	events = nil
+	closed := openSQLiteConn(t, &DBConnection{Key: "closed"})
+	mgr.SetCli("closed", closed)
+	db := closed.GetDB()
+	require.NoError(t, closed.Close())
+	mgr.CheckHealth(context.Background())
+	assert.Empty(t, events)
+	assert.Same(t, db, closed.GetDB())
+	assert.Error(t, closed.Ping(context.Background()))
+}
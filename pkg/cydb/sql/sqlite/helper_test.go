@@ -0,0 +1,48 @@
+package sqlsqlite
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/require"
+)
+
+var sqliteSeq atomic.Int64
+
+// openSQLite 在临时目录打开一个 sqlite 库并依次执行 ddl，测试结束时关闭
+func openSQLite(t *testing.T, ddl ...string) *DBCli {
+	t.Helper()
+	return openSQLiteConn(t, &DBConnection{}, ddl...)
+}
+
+// openSQLiteConn 同 openSQLite，conn 未设置 Key、Path 时按测试名与临时目录生成
+func openSQLiteConn(t *testing.T, conn *DBConnection, ddl ...string) *DBCli {
+	t.Helper()
+	conn.Type = "sqlite"
+	if conn.Key == "" {
+		conn.Key = fmt.Sprintf("%s_%d", strings.ReplaceAll(t.Name(), "/", "_"), sqliteSeq.Add(1))
+	}
+	if conn.Path == "" {
+		conn.Path = filepath.Join(t.TempDir(), "test.db")
+	}
+	cli, err := TryConnect(conn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cli.Close() })
+	for _, s := range ddl {
+		_, err = cli.GetDB().Exec(s)
+		require.NoError(t, err, s)
+	}
+	return cli
+}
+
+// openCounter 打开带有 counter 表及一行 id=1、n=0 数据的库
+func openCounter(t *testing.T) *DBCli {
+	t.Helper()
+	return openSQLite(t,
+		"CREATE TABLE counter (id INTEGER PRIMARY KEY, n INTEGER)",
+		"INSERT INTO counter (id, n) VALUES (1, 0)")
+}
@@ -0,0 +1,36 @@
+package sqlsqlite
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+)
+
+var _ JSONTransformer = (*sqliteSql)(nil)
+
+// JSONPath 生成 json_extract(col, '$.a[0]')，标量按文本返回，对象与数组返回 JSON 文本
+func (s *sqliteSql) JSONPath(column string, path []string, asText bool) (string, error) {
+	return fmt.Sprintf("json_extract(%s, %s)", column, QuoteLiteral(JSONPathString(path))), nil
+}
+
+// JSONContains SQLite 无 JSON 文档包含判断
+func (s *sqliteSql) JSONContains(column, doc string) (string, error) {
+	return "", errors.New("sqlite does not support JSON_CONTAINS")
+}
+
+// ArrayContains 列为 JSON 数组，每个元素生成一个 json_each 子查询
+func (s *sqliteSql) ArrayContains(column string, values []string) (string, error) {
+	conds := make([]string, 0, len(values))
+	for _, v := range values {
+		c, _ := s.ArrayAny(column, v)
+		conds = append(conds, c)
+	}
+	return "(" + strings.Join(conds, " AND ") + ")", nil
+}
+
+// ArrayAny 列为 JSON 数组，生成 EXISTS (SELECT 1 FROM json_each(col) WHERE value = v)
+func (s *sqliteSql) ArrayAny(column, value string) (string, error) {
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s) WHERE value = %s)", column, value), nil
+}
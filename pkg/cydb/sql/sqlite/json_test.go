@@ -0,0 +1,51 @@
+package sqlsqlite
+
+import (
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONWhere(t *testing.T) {
+	cli := openSQLite(t,
+		"CREATE TABLE json_doc (id INTEGER PRIMARY KEY, attrs TEXT, tags TEXT)",
+		`INSERT INTO json_doc VALUES
+		(1, '{"color":"red","size":{"w":10}}', '["a","b"]'),
+		(2, '{"color":"blue","size":{"w":20}}', '["b","c"]')`)
+
+	rows, err := cli.List("json_doc", map[string]interface{}{"color": "blue"},
+		WithWhere(COMPARE(JSON_TEXT("attrs", "color"), OP_EQ, WithParameter("color"))))
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.EqualValues(t, 2, rows[0]["id"])
+
+	rows, err = cli.List("json_doc", map[string]interface{}{"tags": "a"}, WithWhere(ANY("tags")))
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.EqualValues(t, 1, rows[0]["id"])
+
+	rows, err = cli.List("json_doc", nil, WithWhere(ARRAY_CONTAINS("tags", []any{"b", "c"})))
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.EqualValues(t, 2, rows[0]["id"])
+
+	_, err = cli.List("json_doc", map[string]interface{}{"attrs": `{"color":"red"}`}, WithWhere(JSON_CONTAINS("attrs")))
+	assert.Error(t, err)
+}
+
+func TestJSONPathQuotedKey(t *testing.T) {
+	cli := openSQLite(t,
+		"CREATE TABLE json_key (id INTEGER PRIMARY KEY, attrs TEXT)",
+		`INSERT INTO json_key VALUES (1, '{"say \"hi\"":"quoted","a\\b":"slash"}')`)
+
+	assert.Equal(t, `$."say \"hi\""`, JSONPathString([]string{`say "hi"`}))
+	assert.Equal(t, `$."a\\b"`, JSONPathString([]string{`a\b`}))
+	for key, want := range map[string]string{`say "hi"`: "quoted", `a\b`: "slash"} {
+		rows, err := cli.List("json_key", map[string]interface{}{"v": want},
+			WithWhere(COMPARE(JSON_TEXT("attrs", key), OP_EQ, WithParameter("v"))))
+		require.NoError(t, err)
+		assert.Len(t, rows, 1, key)
+	}
+}
@@ -0,0 +1,67 @@
+package sqlsqlite
+
+import (
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamedSliceExpansion(t *testing.T) {
+	cli := openSQLite(t, "CREATE TABLE tag_item (id INTEGER PRIMARY KEY, kind TEXT, name TEXT)")
+	for i, name := range []string{"a", "b", "c", "d"} {
+		_, err := cli.Insert("tag_item", map[string]interface{}{"id": i + 1, "kind": []string{"x", "y"}[i%2], "name": name})
+		require.NoError(t, err)
+	}
+
+	rows, err := cli.NQuery("SELECT name FROM tag_item WHERE id IN (:ids) ORDER BY id", map[string]interface{}{"ids": []int{1, 3, 4}})
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, "d", rows[2]["name"])
+
+	rows, err = cli.NQuery("SELECT name FROM tag_item WHERE id NOT IN (:ids) AND kind = :kind", map[string]interface{}{"ids": []int{1}, "kind": "x"})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "c", rows[0]["name"])
+
+	rows, err = cli.NQuery("SELECT name FROM tag_item WHERE id IN (:ids)", map[string]interface{}{"ids": []int{}})
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+	rows, err = cli.NQuery("SELECT name FROM tag_item WHERE id NOT IN ( :ids ) AND kind = :kind", map[string]interface{}{"ids": []int{}, "kind": "x"})
+	require.NoError(t, err)
+	assert.Len(t, rows, 2, "empty NOT IN must match every row")
+	_, err = cli.NQuery("SELECT name FROM tag_item WHERE id = ANY(:ids)", map[string]interface{}{"ids": []int{}})
+	assert.Error(t, err)
+
+	type filter struct {
+		Kind  string   `db:"kind"`
+		Names []string `db:"names"`
+	}
+	rows, err = cli.NQuery("SELECT id FROM tag_item WHERE kind = :kind AND name IN (:names)", filter{Kind: "x", Names: []string{"a", "b", "c"}})
+	require.NoError(t, err)
+	assert.Len(t, rows, 2)
+
+	rows, err = cli.NQuery("SELECT id FROM tag_item WHERE (kind, name) IN (:pairs)", map[string]interface{}{"pairs": [][]any{{"x", "a"}, {"y", "d"}, {"y", "c"}}})
+	require.NoError(t, err)
+	assert.Len(t, rows, 2)
+
+	n, err := cli.NExcute("DELETE FROM tag_item WHERE id IN (:ids)", map[string]interface{}{"ids": []int64{1, 2}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+func TestExpandNamedQueryQuoted(t *testing.T) {
+	// 字符串、引号标识符和注释中的 ? 不是占位符
+	q, args, ok, err := ExpandNamedQuery("SELECT a AS \"x?\", `y?` FROM t /* ? */ WHERE b <> 'a?' -- ?\nAND id IN (:ids) AND c = :c",
+		map[string]interface{}{"ids": []int{1, 2}, "c": 3}, 0)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "SELECT a AS \"x?\", `y?` FROM t /* ? */ WHERE b <> 'a?' -- ?\nAND id IN (?, ?) AND c = ?", q)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+
+	q, args, _, err = ExpandNamedQuery("SELECT * FROM t WHERE a IN (:ids) OR b NOT IN(:ids)", map[string]interface{}{"ids": []string{}}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM t WHERE 1=0 OR 1=1", q)
+	assert.Empty(t, args)
+}
@@ -0,0 +1,92 @@
+package sqlsqlite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type outboxPub struct {
+	fail int
+	got  []string
+}
+
+func (p *outboxPub) PublishSimple(channel string, payload interface{}) error {
+	if p.fail > 0 {
+		p.fail--
+		return errors.New("broker down")
+	}
+	b, _ := json.Marshal(payload)
+	p.got = append(p.got, channel+":"+string(b))
+	return nil
+}
+
+type denyLocker struct{}
+
+func (denyLocker) TryLock(ctx context.Context, key string) (func(), bool) { return nil, false }
+
+func TestOutboxRelay(t *testing.T) {
+	cli := openSQLite(t, "CREATE TABLE ob_order (id INTEGER PRIMARY KEY, amount INTEGER)")
+
+	pub := &outboxPub{fail: 1}
+	relay, err := NewOutboxRelay(cli, pub, WithOutboxRetry(WithRetryAttempts(2), WithRetryBackoff(0, 0)))
+	require.NoError(t, err)
+
+	err = cli.WithTransaction(func(tx *DBCli) error {
+		if _, err := tx.Insert("ob_order", map[string]interface{}{"id": 1, "amount": 10}); err != nil {
+			return err
+		}
+		_, err := tx.Enqueue("order.created", map[string]interface{}{"id": 1})
+		return err
+	})
+	require.NoError(t, err)
+	err = cli.WithTransaction(func(tx *DBCli) error {
+		if _, err := tx.Enqueue("order.created", map[string]interface{}{"id": 2}); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+
+	locked, err := NewOutboxRelay(cli, pub, WithOutboxLocker(denyLocker{}))
+	require.NoError(t, err)
+	n, err := locked.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	n, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	row, err := cli.QueryOne("SELECT status, attempts, last_error FROM cy_outbox")
+	require.NoError(t, err)
+	assert.EqualValues(t, OutboxPending, row["status"])
+	assert.EqualValues(t, 1, row["attempts"])
+	assert.Equal(t, "broker down", row["last_error"])
+
+	time.Sleep(5 * time.Millisecond)
+	n, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{`order.created:{"id":1}`}, pub.got)
+	row, err = cli.QueryOne("SELECT status FROM cy_outbox")
+	require.NoError(t, err)
+	assert.EqualValues(t, OutboxSent, row["status"])
+
+	pub.fail = 5
+	_, err = cli.Enqueue("order.paid", 3)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = relay.RelayOnce(context.Background())
+		require.NoError(t, err)
+	}
+	row, err = cli.NQueryOne("SELECT status, attempts FROM cy_outbox WHERE topic = :t", map[string]interface{}{"t": "order.paid"})
+	require.NoError(t, err)
+	assert.EqualValues(t, OutboxDead, row["status"])
+	assert.EqualValues(t, 2, row["attempts"])
+}
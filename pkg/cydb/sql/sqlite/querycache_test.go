@@ -0,0 +1,59 @@
+package sqlsqlite
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryCache(t *testing.T) {
+	cli := openCounter(t)
+	cli.SetQueryCache(NewLocalQueryCache(time.Minute), time.Minute)
+
+	first, err := cli.First("counter", map[string]interface{}{"id": 1}, WithEQ("id"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, first["n"])
+
+	// 绕过 DBCli 写入，缓存不会失效
+	_, err = cli.GetDB().Exec("UPDATE counter SET n = 5 WHERE id = 1")
+	require.NoError(t, err)
+	cached, err := cli.First("counter", map[string]interface{}{"id": 1}, WithEQ("id"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, cached["n"])
+
+	// 修改返回的行不影响缓存
+	cached["n"] = 99
+	cached, err = cli.First("counter", map[string]interface{}{"id": 1}, WithEQ("id"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, cached["n"])
+
+	fresh, err := cli.NoCache().First("counter", map[string]interface{}{"id": 1}, WithEQ("id"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, fresh["n"])
+
+	// 通过 Update 写入会清理 counter 表相关缓存
+	_, err = cli.Update("counter", map[string]interface{}{"id": 1, "n": 7}, WithEQ("id"))
+	require.NoError(t, err)
+	after, err := cli.First("counter", map[string]interface{}{"id": 1}, WithEQ("id"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 7, after["n"])
+
+	// 事务中的写入在提交后清理缓存
+	count, err := cli.Count("counter", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	err = cli.WithTransaction(func(tx *DBCli) error {
+		_, err := tx.Insert("counter", map[string]interface{}{"id": 2, "n": 1})
+		return err
+	})
+	require.NoError(t, err)
+	count, err = cli.Count("counter", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+
+	assert.Equal(t, []string{"counter", "orders"},
+		BuilderTables(Builder().Table("counter").Join(TABLE("orders"), ON("counter.id", "orders.cid"))))
+}
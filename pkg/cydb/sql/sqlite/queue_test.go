@@ -0,0 +1,114 @@
+package sqlsqlite
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobQueue(t *testing.T) {
+	cli := openSQLite(t)
+	q, err := NewJobQueue(cli, "mail", WithJobVisibility(50*time.Millisecond), WithJobRetry(WithRetryBackoff(0, 0)))
+	require.NoError(t, err)
+
+	_, err = q.Enqueue("send", map[string]string{"to": "low"})
+	require.NoError(t, err)
+	_, err = q.Enqueue("send", map[string]string{"to": "high"}, WithJobPriority(10))
+	require.NoError(t, err)
+	_, err = q.Enqueue("send", []byte(`{"to":"later"}`), WithJobDelay(time.Hour))
+	require.NoError(t, err)
+
+	jobs, err := q.Dequeue(5)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.JSONEq(t, `{"to":"high"}`, string(jobs[0].Payload))
+	assert.Equal(t, 1, jobs[0].Attempts)
+	require.NoError(t, q.Ack(jobs[0]))
+	assert.ErrorIs(t, q.Ack(jobs[0]), ErrJobLeaseLost)
+
+	// 租约过期后重新领取，旧的领取结果失效
+	time.Sleep(60 * time.Millisecond)
+	again, err := q.Dequeue(5)
+	require.NoError(t, err)
+	require.Len(t, again, 1)
+	assert.Equal(t, jobs[1].ID, again[0].ID)
+	assert.Equal(t, 2, again[0].Attempts)
+	assert.ErrorIs(t, q.Nack(jobs[1], errors.New("stale")), ErrJobLeaseLost)
+
+	require.NoError(t, q.Nack(again[0], errors.New("smtp down")))
+	last, err := q.Dequeue(1)
+	require.NoError(t, err)
+	require.Len(t, last, 1)
+	assert.Equal(t, "smtp down", last[0].LastError)
+
+	_, err = q.Enqueue("send", 1, WithJobMaxAttempts(1))
+	require.NoError(t, err)
+	require.NoError(t, q.Nack(last[0], errors.New("boom")))
+	consumer := NewJobConsumer(q, 1, 10*time.Millisecond)
+	var mu sync.Mutex
+	var handled []string
+	require.NoError(t, consumer.RegisterHandlerFunc("send", func(ctx context.Context, payload []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, string(payload))
+		if string(payload) == "1" {
+			return errors.New("bad payload")
+		}
+		return nil
+	}))
+	for {
+		n, err := consumer.ProcessOnce(context.Background())
+		require.NoError(t, err)
+		if n == 0 {
+			break
+		}
+	}
+	assert.ElementsMatch(t, []string{`{"to":"low"}`, "1"}, handled)
+
+	dead, err := q.DeadLetters(10)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "bad payload", dead[0].LastError)
+	require.NoError(t, q.Requeue(dead[0].ID))
+	jobs, err = q.Dequeue(1)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, 1, jobs[0].Attempts)
+}
+
+func TestJobConsumerTxEnqueue(t *testing.T) {
+	cli := openSQLite(t)
+	q, err := NewJobQueue(cli, "report")
+	require.NoError(t, err)
+	err = cli.WithTransaction(func(tx *DBCli) error {
+		if _, err := q.Tx(tx).Enqueue("build", 1); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+
+	done := make(chan string, 1)
+	consumer := NewJobConsumer(q, 2, 5*time.Millisecond)
+	assert.Error(t, consumer.Start(context.Background()))
+	require.NoError(t, consumer.RegisterHandlerFunc("build", func(ctx context.Context, payload []byte) error {
+		done <- string(payload)
+		return nil
+	}))
+	require.NoError(t, consumer.Start(context.Background()))
+	defer consumer.Shutdown()
+	_, err = q.Enqueue("build", 2)
+	require.NoError(t, err)
+	select {
+	case got := <-done:
+		assert.Equal(t, "2", got)
+	case <-time.After(2 * time.Second):
+		t.Fatal("job not consumed")
+	}
+}
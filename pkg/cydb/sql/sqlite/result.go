@@ -0,0 +1,15 @@
+package sqlsqlite
+
+import (
+	"database/sql"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+)
+
+var _ ResultTypeMapper = (*sqliteSql)(nil)
+
+// ResultFieldType implements cydb.ResultTypeMapper.
+// 驱动返回列的声明类型，表达式列为空，按类型亲和性推断
+func (s *sqliteSql) ResultFieldType(ct *sql.ColumnType) DBFieldType {
+	return fieldType(ct.DatabaseTypeName())
+}
@@ -0,0 +1,50 @@
+package sqlsqlite
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRetryTransaction(t *testing.T) {
+	cli := openCounter(t)
+	transient := errors.New("database is locked")
+
+	attempts := 0
+	err := cli.WithRetryTransaction(func(tx *DBCli) error {
+		attempts++
+		if _, err := tx.Excute("UPDATE counter SET n = n + 1 WHERE id = 1"); err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return transient
+		}
+		return nil
+	}, WithRetryAttempts(5), WithRetryBackoff(time.Millisecond, 5*time.Millisecond))
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	n, err := cli.Count("counter", map[string]interface{}{"n": 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "rolled back attempts must not leave changes")
+
+	attempts = 0
+	err = cli.WithRetryTransaction(func(tx *DBCli) error {
+		attempts++
+		return errors.New("constraint failed")
+	}, WithRetryBackoff(time.Millisecond, time.Millisecond))
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts, "non-transient errors must not be retried")
+
+	attempts = 0
+	err = cli.WithRetryTransaction(func(tx *DBCli) error {
+		attempts++
+		return transient
+	}, WithRetryAttempts(2), WithRetryBackoff(time.Millisecond, time.Millisecond))
+	assert.ErrorIs(t, err, transient)
+	assert.Equal(t, 2, attempts)
+}
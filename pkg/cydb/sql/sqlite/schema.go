@@ -0,0 +1,142 @@
+package sqlsqlite
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/fj1981/infrakit/pkg/cyutil"
+)
+
+var (
+	_ TableSchemaInspector = (*sqliteSql)(nil)
+	_ TableLister          = (*sqliteSql)(nil)
+)
+
+var typeSizePattern = regexp.MustCompile(`^\s*([^(]+?)\s*\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\)`)
+
+// GetTableSchema SQLite 不支持列注释，外键没有名称时以 fk_<id> 命名
+func (s *sqliteSql) GetTableSchema(cli DatabaseClient, database, tableName string) (*TableSchema, error) {
+	exists, err := s.IsTableExist(cli, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("table '%s' does not exist", tableName)
+	}
+	ts := &TableSchema{Schema: database, Name: tableName}
+	quoted := "'" + strings.ReplaceAll(tableName, "'", "''") + "'"
+
+	rows, err := InternalQuery(cli, "PRAGMA table_info("+quoted+")")
+	if err != nil {
+		return nil, err
+	}
+	pkCount := 0
+	for _, r := range rows {
+		if cyutil.GetInt(r, "pk") > 0 {
+			pkCount++
+		}
+	}
+	pk := make([]string, pkCount)
+	for i, r := range rows {
+		columnType := cyutil.GetStr(r, "type")
+		col := &SchemaColumn{
+			Name:       cyutil.GetStr(r, "name"),
+			Position:   i + 1,
+			DataType:   columnType,
+			ColumnType: columnType,
+			FieldType:  fieldType(columnType),
+			Nullable:   cyutil.GetInt(r, "notnull") == 0,
+			Default:    NullableString(r["dflt_value"]),
+		}
+		if m := typeSizePattern.FindStringSubmatch(columnType); m != nil {
+			col.DataType = m[1]
+			if m[3] != "" || col.FieldType == DBFieldTypeFloat || col.FieldType == DBFieldTypeInt {
+				col.Precision, col.Scale = cyutil.ToInt64(m[2]), cyutil.ToInt64(m[3])
+			} else {
+				col.Length = cyutil.ToInt64(m[2])
+			}
+		}
+		if n := cyutil.GetInt(r, "pk"); n > 0 && n <= pkCount {
+			pk[n-1] = col.Name
+			// INTEGER PRIMARY KEY 是 rowid 的别名，自动分配
+			col.AutoIncrement = pkCount == 1 && strings.EqualFold(columnType, "INTEGER")
+		}
+		ts.Columns = append(ts.Columns, col)
+	}
+	if len(pk) > 0 {
+		ts.Constraints = append(ts.Constraints, &SchemaConstraint{Type: ConstraintPrimaryKey, Columns: pk})
+	}
+
+	indexes, err := InternalQuery(cli, "PRAGMA index_list("+quoted+")")
+	if err != nil {
+		return nil, err
+	}
+	for _, idx := range indexes {
+		name := cyutil.GetStr(idx, "name")
+		origin := cyutil.GetStr(idx, "origin")
+		if origin == "pk" {
+			continue
+		}
+		cols, err := InternalQuery(cli, "PRAGMA index_info('"+strings.ReplaceAll(name, "'", "''")+"')")
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cols {
+			if origin == "u" {
+				// 表定义中的 UNIQUE 约束
+				ts.AppendConstraintColumn(name, ConstraintUnique, cyutil.GetStr(c, "name"))
+			} else {
+				ts.AppendIndexColumn(name, cyutil.GetStr(c, "name"), cyutil.GetInt(idx, "unique") == 1)
+			}
+		}
+	}
+	ts.MarkPrimaryKey()
+
+	fks, err := InternalQuery(cli, "PRAGMA foreign_key_list("+quoted+")")
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range fks {
+		fk := ts.AppendForeignKeyColumn(fmt.Sprintf("fk_%d", cyutil.GetInt(r, "id")), cyutil.GetStr(r, "from"), cyutil.GetStr(r, "to"))
+		fk.RefTable = cyutil.GetStr(r, "table")
+		fk.OnUpdate = cyutil.GetStr(r, "on_update")
+		fk.OnDelete = cyutil.GetStr(r, "on_delete")
+	}
+
+	triggers, err := InternalQuery(cli, "SELECT name, sql FROM sqlite_master WHERE type = 'trigger' AND tbl_name = ? ORDER BY name", tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range triggers {
+		def := cyutil.GetStr(r, "sql")
+		timing, event := triggerTiming(def)
+		ts.Triggers = append(ts.Triggers, &SchemaTrigger{
+			Name:       cyutil.GetStr(r, "name"),
+			Timing:     timing,
+			Event:      event,
+			Definition: def,
+		})
+	}
+	return ts, nil
+}
+
+var triggerPattern = regexp.MustCompile(`(?is)\bTRIGGER\b.*?\b(BEFORE|AFTER|INSTEAD\s+OF)?\s*(INSERT|UPDATE|DELETE)\b`)
+
+// triggerTiming 从 CREATE TRIGGER 语句中解析触发时机和事件，未指定时机时默认为 BEFORE
+func triggerTiming(def string) (string, string) {
+	m := triggerPattern.FindStringSubmatch(def)
+	if m == nil {
+		return "", ""
+	}
+	timing := strings.ToUpper(strings.Join(strings.Fields(m[1]), " "))
+	if timing == "" {
+		timing = "BEFORE"
+	}
+	return timing, strings.ToUpper(m[2])
+}
+
+func (s *sqliteSql) GetTableNames(cli DatabaseClient, database string) ([]string, error) {
+	return QueryNames(cli, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+}
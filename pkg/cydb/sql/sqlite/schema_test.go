@@ -0,0 +1,62 @@
+package sqlsqlite
+
+import (
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTableSchema(t *testing.T) {
+	cli := openSQLite(t,
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email VARCHAR(64) NOT NULL UNIQUE, score DECIMAL(10,2) DEFAULT 0)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE, created_at DATETIME)",
+		"CREATE INDEX idx_orders_user ON orders (user_id, created_at)",
+		"CREATE TRIGGER trg_orders_insert AFTER INSERT ON orders BEGIN SELECT 1; END")
+
+	users, err := cli.GetTableSchema("users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id"}, users.PrimaryKey)
+	id := users.Column("ID")
+	require.NotNil(t, id)
+	assert.True(t, id.PrimaryKey)
+	assert.True(t, id.AutoIncrement)
+	email := users.Column("email")
+	assert.Equal(t, "VARCHAR", email.DataType)
+	assert.EqualValues(t, 64, email.Length)
+	assert.False(t, email.Nullable)
+	score := users.Column("score")
+	assert.EqualValues(t, 10, score.Precision)
+	assert.EqualValues(t, 2, score.Scale)
+	assert.Equal(t, DBFieldTypeFloat, score.FieldType)
+	require.NotNil(t, score.Default)
+	assert.Equal(t, "0", *score.Default)
+	var unique *SchemaConstraint
+	for _, c := range users.Constraints {
+		if c.Type == ConstraintUnique {
+			unique = c
+		}
+	}
+	require.NotNil(t, unique)
+	assert.Equal(t, []string{"email"}, unique.Columns)
+
+	orders, err := cli.GetTableSchema("orders")
+	require.NoError(t, err)
+	require.Len(t, orders.ForeignKeys, 1)
+	fk := orders.ForeignKeys[0]
+	assert.Equal(t, "users", fk.RefTable)
+	assert.Equal(t, []string{"user_id"}, fk.Columns)
+	assert.Equal(t, []string{"id"}, fk.RefColumns)
+	assert.Equal(t, "CASCADE", fk.OnDelete)
+	require.Len(t, orders.Indexes, 1)
+	assert.Equal(t, "idx_orders_user", orders.Indexes[0].Name)
+	assert.Equal(t, []string{"user_id", "created_at"}, orders.Indexes[0].Columns)
+	assert.False(t, orders.Indexes[0].Unique)
+	require.Len(t, orders.Triggers, 1)
+	assert.Equal(t, "AFTER", orders.Triggers[0].Timing)
+	assert.Equal(t, "INSERT", orders.Triggers[0].Event)
+
+	_, err = cli.GetTableSchema("missing")
+	assert.Error(t, err)
+}
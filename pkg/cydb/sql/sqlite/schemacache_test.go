@@ -0,0 +1,75 @@
+package sqlsqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memBroadcaster struct {
+	handlers map[string]func(ctx context.Context, payload []byte) error
+	sent     []string
+}
+
+func (b *memBroadcaster) PublishSimple(channel string, payload interface{}) error {
+	b.sent = append(b.sent, channel)
+	return nil
+}
+
+func (b *memBroadcaster) RegisterHandlerFunc(messageType string, fn func(ctx context.Context, payload []byte) error) error {
+	b.handlers[messageType] = fn
+	return nil
+}
+
+func TestSchemaCacheInvalidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sc.db")
+	cli := openSQLiteConn(t, &DBConnection{Key: "sc_a", Path: path})
+	_, err := InternalExcute(cli, "CREATE TABLE sc_user (id INTEGER PRIMARY KEY, name TEXT)")
+	require.NoError(t, err)
+	cols, err := cli.GetTableColumns("sc_user")
+	require.NoError(t, err)
+	assert.Len(t, cols, 2)
+
+	// 通过 DBCli 执行的 DDL 自动清理缓存，新字段不会被 filterFields 丢弃
+	_, err = InternalExcute(cli, "ALTER TABLE sc_user ADD COLUMN email TEXT")
+	require.NoError(t, err)
+	_, err = cli.Insert("sc_user", map[string]interface{}{"id": 1, "name": "a", "email": "a@x"})
+	require.NoError(t, err)
+	row, err := cli.First("sc_user", map[string]interface{}{"id": 1}, WithEQ("id"))
+	require.NoError(t, err)
+	assert.Equal(t, "a@x", row["email"])
+
+	// 另一个进程的连接通过广播得知表结构变化
+	other := openSQLiteConn(t, &DBConnection{Key: "sc_a", Path: path})
+	b := &memBroadcaster{handlers: map[string]func(ctx context.Context, payload []byte) error{}}
+	require.NoError(t, other.SetSchemaBroadcaster(b))
+	cols, err = other.GetTableColumns("sc_user")
+	require.NoError(t, err)
+	assert.Len(t, cols, 3)
+	_, err = cli.GetDB().Exec("ALTER TABLE sc_user ADD COLUMN age INTEGER")
+	require.NoError(t, err)
+	cols, err = other.GetTableColumns("sc_user")
+	require.NoError(t, err)
+	assert.Len(t, cols, 3)
+	handler := b.handlers[SchemaInvalidateTopic+":sc_a"]
+	require.NotNil(t, handler)
+	require.NoError(t, handler(context.Background(), []byte(`{"tables":["SC_USER"]}`)))
+	cols, err = other.GetTableColumns("sc_user")
+	require.NoError(t, err)
+	assert.Len(t, cols, 4)
+
+	other.InvalidateSchema("sc_user")
+	assert.Equal(t, []string{SchemaInvalidateTopic + ":sc_a"}, b.sent)
+
+	// 关闭缓存后每次都读取最新结构
+	cli.SetSchemaCacheTTL(-1)
+	_, err = cli.GetDB().Exec("ALTER TABLE sc_user ADD COLUMN note TEXT")
+	require.NoError(t, err)
+	cols, err = cli.GetTableColumns("sc_user")
+	require.NoError(t, err)
+	assert.Len(t, cols, 5)
+}
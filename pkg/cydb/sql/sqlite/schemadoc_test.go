@@ -0,0 +1,41 @@
+package sqlsqlite
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSchemaDoc(t *testing.T) {
+	cli := openSQLite(t,
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email VARCHAR(64) NOT NULL UNIQUE)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users(id), note TEXT)",
+		"CREATE TABLE tmp_log (id INTEGER)")
+
+	tables, err := cli.GetTableNames()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders", "tmp_log", "users"}, tables)
+
+	var sb strings.Builder
+	require.NoError(t, cli.GenerateSchemaDoc(&sb, DocFormatMarkdown, WithDocTitle("shop"), WithDocExclude("tmp_*")))
+	md := sb.String()
+	assert.Contains(t, md, "# shop")
+	assert.Contains(t, md, "## orders")
+	assert.Contains(t, md, "| 2 | user_id | INTEGER | NO |  | FK |  |")
+	assert.Contains(t, md, "| 2 | email | VARCHAR(64) | NO |  | UK |  |")
+	assert.NotContains(t, md, "tmp_log")
+	assert.Contains(t, md, "users ||--o{ orders : \"user_id\"")
+
+	sb.Reset()
+	require.NoError(t, cli.GenerateSchemaDoc(&sb, DocFormatPlantUML, WithDocTables("users", "orders")))
+	assert.Contains(t, sb.String(), "users ||..o{ orders : user_id")
+	assert.Contains(t, sb.String(), "* id : INTEGER <<PK>>")
+
+	sb.Reset()
+	require.NoError(t, cli.GenerateSchemaDoc(&sb, DocFormatHTML, WithDocInclude("user*")))
+	assert.Contains(t, sb.String(), `<h2 id="users">users</h2>`)
+	assert.NotContains(t, sb.String(), "orders")
+}
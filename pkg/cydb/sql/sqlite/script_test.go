@@ -0,0 +1,77 @@
+package sqlsqlite
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testScript = `INSERT INTO counter (id, n) VALUES (2, 0);
+INSERT INTO counter (id, n) VALUES (3, 0);
+INSERT INTO missing (id) VALUES (1);
+INSERT INTO counter (id, n) VALUES (4, 0);
+`
+
+func TestRunScript(t *testing.T) {
+	cli := openCounter(t)
+
+	res, err := cli.RunScript(strings.NewReader(testScript), WithScriptTxMode(ScriptTxScript))
+	var se *ScriptStatementError
+	require.ErrorAs(t, err, &se)
+	assert.Equal(t, 3, se.Statement.Index)
+	assert.Equal(t, 3, se.Statement.StartLine)
+	assert.Equal(t, 0, res.LastSuccess)
+	n, err := cli.Count("counter", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n, "whole script must be rolled back")
+
+	var lines []int
+	res, err = cli.RunScript(strings.NewReader(testScript), WithScriptTxMode(ScriptTxStatement), WithScriptProgress(func(stmt *SQLStatement, err error) {
+		lines = append(lines, stmt.StartLine)
+	}))
+	require.Error(t, err)
+	assert.Equal(t, 2, res.LastSuccess)
+	assert.Equal(t, []int{1, 2, 3}, lines)
+
+	fixed := strings.Replace(testScript, "missing (id) VALUES (1)", "counter (id, n) VALUES (5, 0)", 1)
+	res, err = cli.RunScript(strings.NewReader(fixed), WithScriptResume(res.LastSuccess))
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.Skipped)
+	assert.Equal(t, 2, res.Executed)
+	assert.Equal(t, 4, res.LastSuccess)
+	n, err = cli.Count("counter", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, n)
+
+	res, err = cli.RunScript(strings.NewReader(testScript), WithScriptContinueOnError(true))
+	require.Error(t, err)
+	assert.Equal(t, 0, res.Executed)
+	assert.Len(t, res.Errors, 4)
+	assert.Equal(t, 1, res.FirstFailed)
+}
+
+func TestRunScriptContinueOnError(t *testing.T) {
+	cli := openCounter(t)
+	res, err := cli.RunScript(strings.NewReader(testScript), WithScriptContinueOnError(true))
+	require.Error(t, err)
+	assert.Equal(t, 3, res.Executed)
+	assert.Equal(t, 3, res.FirstFailed)
+	assert.Equal(t, 2, res.LastSuccess, "must not move past the failed statement")
+}
+
+func TestRunScriptGuard(t *testing.T) {
+	cli := openCounter(t)
+	cli.SetGuard(&GuardPolicy{})
+	res, err := cli.RunScript(strings.NewReader(`CREATE TABLE tmp_script (id INTEGER);
+DELETE FROM counter;
+`))
+	assert.ErrorIs(t, err, ErrDangerousStatement)
+	assert.Equal(t, 1, res.LastSuccess)
+	assert.Equal(t, 2, res.FirstFailed)
+	n, err := cli.Count("counter", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+}
@@ -0,0 +1,75 @@
+package sqlsqlite
+
+import (
+	"sync/atomic"
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertReturningID(t *testing.T) {
+	cli := openSQLite(t, "CREATE TABLE seq_user (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)")
+
+	id, err := cli.InsertReturningID("seq_user", map[string]interface{}{"name": "a"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, id)
+	id, err = cli.InsertReturningID("seq_user", map[string]interface{}{"name": "b"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, id)
+	// 已提供主键时返回该值
+	id, err = cli.InsertReturningID("seq_user", map[string]interface{}{"id": 10, "name": "c"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, id)
+	row, err := cli.First("seq_user", map[string]interface{}{"id": 10}, WithEQ("id"))
+	require.NoError(t, err)
+	assert.Equal(t, "c", row["name"])
+
+	// SQLite 没有序列，配置序列后 Insert 报错而不是静默忽略
+	cli.SetSequence("seq_user", "seq_user_id")
+	_, err = cli.Insert("seq_user", map[string]interface{}{"name": "d"})
+	assert.Error(t, err)
+	cli.SetSequence("seq_user", "")
+	_, err = cli.Insert("seq_user", map[string]interface{}{"name": "d"})
+	assert.NoError(t, err)
+}
+
+// seqSqlite 带序列的 sqlite 方言，序列取值为递增计数
+type seqSqlite struct {
+	*sqliteSql
+	n atomic.Int64
+}
+
+func (s *seqSqlite) NextSequenceValue(cli DatabaseClient, sequence string) (int64, error) {
+	return 100 + s.n.Add(1), nil
+}
+
+func TestSequenceKeyWriteMethods(t *testing.T) {
+	RegisterSqlDialect("sqlite_seq", &seqSqlite{sqliteSql: &sqliteSql{}})
+	db := openSQLite(t, "CREATE TABLE seq_doc (id INTEGER PRIMARY KEY, name TEXT)")
+	cli := NewDBCli(db.GetDB(), "sqlite_seq", "sqlite_seq", "", "", "")
+	cli.SetSequence("seq_doc", "seq_doc_id")
+
+	item := map[string]interface{}{"name": "a"}
+	_, err := cli.Insert("seq_doc", item)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "a"}, item)
+	_, err = cli.BatchInsert("seq_doc", []map[string]interface{}{item, {"name": "b"}})
+	require.NoError(t, err)
+	_, err = cli.Upsert("seq_doc", item)
+	require.NoError(t, err)
+	_, err = cli.Replace("seq_doc", item)
+	require.NoError(t, err)
+	_, err = cli.BulkInsert("seq_doc", []map[string]interface{}{item}, WithBulkColumns("name"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "a"}, item)
+
+	rows, err := cli.Query("SELECT id FROM seq_doc ORDER BY id")
+	require.NoError(t, err)
+	ids := make([]interface{}, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r["id"])
+	}
+	assert.Equal(t, []interface{}{int64(101), int64(102), int64(103), int64(104), int64(105), int64(106)}, ids)
+}
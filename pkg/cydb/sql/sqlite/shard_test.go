@@ -0,0 +1,120 @@
+package sqlsqlite
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardRouter(t *testing.T) {
+	mgr := &DBMgr{}
+	defer mgr.CloseAll()
+	for i, key := range []string{"db0", "db1"} {
+		cli := openSQLiteConn(t, &DBConnection{Key: key},
+			fmt.Sprintf("CREATE TABLE event_%02d (id INTEGER PRIMARY KEY, name VARCHAR(32))", i*2),
+			fmt.Sprintf("CREATE TABLE event_%02d (id INTEGER PRIMARY KEY, name VARCHAR(32))", i*2+1))
+		mgr.SetCli(key, cli)
+	}
+
+	router := NewShardRouter(mgr)
+	require.NoError(t, router.Register(&ShardTable{Table: "event", Key: "id", Rule: ModShard(4), Count: 4, DBKeys: []string{"db0", "db1"}}))
+
+	for id := 1; id <= 10; id++ {
+		_, err := router.Insert("event", map[string]interface{}{"id": id, "name": fmt.Sprintf("e%d", id)})
+		require.NoError(t, err)
+	}
+
+	cli, name, err := router.Route("event", 7)
+	require.NoError(t, err)
+	assert.Equal(t, "event_03", name)
+	assert.Equal(t, "db1", cli.Key())
+
+	rows, err := router.ListAll("event", nil, WithOrderBy(DESC("id")), WithLimit(3), WithOffset(2))
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.EqualValues(t, 8, rows[0]["id"])
+	assert.EqualValues(t, 6, rows[2]["id"])
+
+	count, err := router.CountAll("event", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, count)
+
+	// 只有 offset 时每个分片也不能跳过数据
+	rows, err = router.ListAll("event", nil, WithOrderBy(ASC("id")), WithOffset(8))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.EqualValues(t, 9, rows[0]["id"])
+
+	dt, _ := GetSqlTransformer("sqlite")
+	b := Builder().Table("event").Where(EQ("id"))
+	rb, cli, err := router.Rewrite(b, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "db0", cli.Key())
+	r, err := rb.Build(dt)
+	require.NoError(t, err)
+	assert.Contains(t, r.SQL, "event_01")
+	orig, err := b.Build(dt)
+	require.NoError(t, err)
+	assert.NotContains(t, orig.SQL, "event_01", "rewrite must not modify the caller's builder")
+	found, err := InternalNQuery(cli, r.SQL, map[string]interface{}{"id": 5})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "e5", found[0]["name"])
+
+	sub := Builder().Table("event").Select("id").Where(EQ("name"))
+	rb, _, err = router.Rewrite(Builder().Table("event").Where(IN("id", []any{SUBQUERY(sub)})), 5)
+	require.NoError(t, err)
+	r, err = rb.Build(dt)
+	require.NoError(t, err)
+	assert.NotContains(t, r.SQL, "event ")
+	assert.Equal(t, 2, strings.Count(r.SQL, "event_01"))
+}
+
+func TestShardRuleCount(t *testing.T) {
+	_, err := ModShard(0)(1)
+	assert.Error(t, err)
+	_, err = HashShard(-1)("a")
+	assert.Error(t, err)
+}
+
+func TestShardRouterErrors(t *testing.T) {
+	mgr := &DBMgr{}
+	defer mgr.CloseAll()
+	cli := openSQLiteConn(t, &DBConnection{Key: "sdb"},
+		"CREATE TABLE log_00 (id INTEGER PRIMARY KEY)",
+		"CREATE TABLE log_01 (id INTEGER PRIMARY KEY)",
+		"INSERT INTO log_00 VALUES (2), (4)",
+		"INSERT INTO log_01 VALUES (1), (3)")
+	mgr.SetCli("sdb", cli)
+	router := NewShardRouter(mgr)
+	require.NoError(t, router.Register(&ShardTable{Table: "log", Key: "id", Rule: ModShard(2), Count: 2, DBKeys: []string{"sdb"}}))
+	require.NoError(t, router.Register(&ShardTable{Table: "day", Key: "d", Rule: DateShard("20060102"), Count: 1, DBKeys: []string{"sdb"}}))
+	require.NoError(t, router.Register(&ShardTable{Table: "bad", Key: "id", Rule: ModShard(0), Count: 1, DBKeys: []string{"sdb"}}))
+
+	// limit 占位符从 data 中取值
+	limitParam := func(b SQLBuilder) SQLBuilder { return b.LimitPlaceholder(":n") }
+	rows, err := router.ListAll("log", map[string]interface{}{"n": 3}, WithOrderBy(ASC("id")), limitParam)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.EqualValues(t, 3, rows[2]["id"])
+	_, err = router.ListAll("log", map[string]interface{}{}, limitParam)
+	assert.Error(t, err)
+
+	// 无法对应到结果列的排序表达式不能合并
+	_, err = router.ListAll("log", nil, WithOrderBy(ASC(FUNC("ABS", FIELD("id")))))
+	assert.Error(t, err)
+
+	// 规则产生 Shards 以外的分片序号且未设置 DBRoute 时报错
+	_, _, err = router.Route("day", "2024-03-01")
+	assert.Error(t, err)
+
+	// 第一个分片表路由失败时不返回改写了一半的构建器
+	b := Builder().Table("bad").Join(&Table{Name: "log"}, ON("bad.id", "log.id"))
+	rb, _, err := router.Rewrite(b, 1)
+	assert.Error(t, err)
+	assert.Nil(t, rb)
+}
@@ -0,0 +1,72 @@
+package sqlsqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantUpsert(t *testing.T) {
+	cli := openSQLite(t, "CREATE TABLE tn_doc (id INTEGER PRIMARY KEY, tenant_id INTEGER, name TEXT)")
+	cli.SetTenantPolicy(&TenantPolicy{Tables: []string{"tn_doc"}})
+	a := cli.WithContext(ContextWithTenant(context.Background(), 1))
+	b := cli.WithContext(ContextWithTenant(context.Background(), 2))
+
+	_, err := a.Insert("tn_doc", map[string]interface{}{"id": 1, "name": "a"})
+	require.NoError(t, err)
+	_, err = a.Upsert("tn_doc", map[string]interface{}{"id": 1, "name": "a2"})
+	require.NoError(t, err)
+
+	// 其他租户主键冲突时不能覆盖或转移该行
+	affected, err := b.Upsert("tn_doc", map[string]interface{}{"id": 1, "name": "b", "tenant_id": 2})
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, affected)
+	row, err := cli.First("tn_doc", map[string]interface{}{"id": 1}, WithEQ("id"), WithTenant(AllTenants))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, row["tenant_id"])
+	assert.Equal(t, "a2", row["name"])
+
+	_, err = b.Replace("tn_doc", map[string]interface{}{"id": 1, "name": "b"})
+	assert.True(t, errors.Is(err, ErrTenantReplace))
+}
+
+func TestTenantPolicyPerCli(t *testing.T) {
+	ddl := []string{
+		"CREATE TABLE tp_doc (id INTEGER PRIMARY KEY, org INTEGER, name TEXT)",
+		"INSERT INTO tp_doc VALUES (1, 1, 'a'), (2, 2, 'b')",
+	}
+	scoped := openSQLiteConn(t, &DBConnection{Tenant: &TenantPolicy{Column: "org", Tables: []string{"tp_doc"}}}, ddl...)
+	plain := openSQLite(t, ddl...)
+
+	ctx := ContextWithTenant(context.Background(), 1)
+	rows, err := scoped.WithContext(ctx).List("tp_doc", nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "a", rows[0]["name"])
+	tx, err := scoped.WithContext(ctx).BeginX()
+	require.NoError(t, err)
+	rows, err = tx.List("tp_doc", nil)
+	require.NoError(t, err)
+	assert.Len(t, rows, 1)
+	require.NoError(t, tx.Rollback())
+
+	// 其他连接的策略互不影响
+	rows, err = plain.WithContext(ctx).List("tp_doc", nil)
+	require.NoError(t, err)
+	assert.Len(t, rows, 2)
+	_, err = plain.WithContext(ctx).Query("SELECT * FROM tp_doc")
+	assert.NoError(t, err)
+
+	// 原生 SQL 不做隔离，指定了租户时拒绝执行
+	_, err = scoped.WithContext(ctx).Query("SELECT * FROM tp_doc")
+	assert.True(t, errors.Is(err, ErrTenantRawSQL))
+	_, err = scoped.WithContext(ctx).NExcute("DELETE FROM tp_doc WHERE id = :id", map[string]interface{}{"id": 2})
+	assert.True(t, errors.Is(err, ErrTenantRawSQL))
+	rows, err = scoped.WithContext(ContextWithTenant(context.Background(), AllTenants)).Query("SELECT * FROM tp_doc")
+	require.NoError(t, err)
+	assert.Len(t, rows, 2)
+}
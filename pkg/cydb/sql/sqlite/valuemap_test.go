@@ -0,0 +1,65 @@
+package sqlsqlite
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedValueMapper(t *testing.T) {
+	cli := openSQLite(t,
+		"CREATE TABLE vm_item (id INTEGER PRIMARY KEY, price DECIMAL(10,2), ratio REAL, created_at DATETIME, data BLOB, attrs JSON, name TEXT)")
+	_, err := cli.GetDB().Exec("INSERT INTO vm_item VALUES (1, '12.30', 0.5, '2024-03-01 08:00:00', X'00FF', '{\"a\":1}', 'x')")
+	require.NoError(t, err)
+
+	row, err := cli.First("vm_item", map[string]interface{}{"id": 1}, WithEQ("id"))
+	require.NoError(t, err)
+	assert.IsType(t, "", row["created_at"])
+
+	shanghai := time.FixedZone("CST", 8*3600)
+	row, err = cli.Typed(shanghai).First("vm_item", map[string]interface{}{"id": 1}, WithEQ("id"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), row["id"])
+	assert.Equal(t, "12.3", row["price"])
+	assert.Equal(t, 0.5, row["ratio"])
+	assert.Equal(t, []byte{0x00, 0xff}, row["data"])
+	assert.Equal(t, json.RawMessage(`{"a":1}`), row["attrs"])
+	assert.Equal(t, "x", row["name"])
+	ts, ok := row["created_at"].(time.Time)
+	require.True(t, ok)
+	assert.Equal(t, shanghai, ts.Location())
+
+	rows, err := cli.WithValueMapper(ValueMapperFunc(func(col *ResultColumn, v interface{}) (interface{}, error) {
+		return col.DBFieldType, nil
+	})).Query("SELECT id, name, COUNT(1) AS n FROM vm_item")
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, DBFieldTypeInt, rows[0]["id"])
+	assert.Equal(t, DBFieldTypeString, rows[0]["name"])
+}
+
+func TestValueMapperError(t *testing.T) {
+	cli := openSQLite(t, "CREATE TABLE vm_err (id INTEGER PRIMARY KEY, name TEXT)")
+	_, err := cli.GetDB().Exec("INSERT INTO vm_err VALUES (1, 'a'), (2, 'b')")
+	require.NoError(t, err)
+	failing := cli.WithValueMapper(ValueMapperFunc(func(col *ResultColumn, v interface{}) (interface{}, error) {
+		if col.Name == "name" && v == "b" {
+			return nil, errors.New("bad value")
+		}
+		return v, nil
+	}))
+
+	rows, err := failing.Query("SELECT id, name FROM vm_err ORDER BY id")
+	assert.ErrorContains(t, err, "bad value")
+	assert.Nil(t, rows)
+	rows, err = failing.NQuery("SELECT id, name FROM vm_err ORDER BY id", map[string]interface{}{})
+	assert.ErrorContains(t, err, "bad value")
+	assert.Nil(t, rows)
+	_, err = failing.QueryOne("SELECT id, name FROM vm_err WHERE id = 2")
+	assert.ErrorContains(t, err, "bad value")
+}
@@ -0,0 +1,46 @@
+package sqlsqlite
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/fj1981/infrakit/pkg/cydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type configRecord struct {
+	ID      int    `db:"id"`
+	Value   string `db:"value"`
+	Version int    `db:"version"`
+}
+
+func TestOptimisticLock(t *testing.T) {
+	cli := openSQLite(t, "CREATE TABLE config (id INTEGER PRIMARY KEY, value VARCHAR(64), version INTEGER)")
+	_, err := cli.Insert("config", map[string]interface{}{"id": 1, "value": "a", "version": 1})
+	require.NoError(t, err)
+
+	_, err = cli.Update("config", map[string]interface{}{"id": 1, "value": "b", "version": 1}, WithEQ("id"), WithVersion("version"))
+	require.NoError(t, err)
+
+	_, err = cli.Update("config", map[string]interface{}{"id": 1, "value": "c", "version": 1}, WithEQ("id"), WithVersion("version"))
+	var stale *StaleUpdateError
+	require.True(t, errors.As(err, &stale))
+	assert.True(t, errors.Is(err, ErrStaleUpdate))
+	assert.Equal(t, "config", stale.Table)
+
+	_, err = cli.BatchUpdate("config", []map[string]interface{}{{"id": 1, "value": "d", "version": 1}}, WithEQ("id"), WithVersion("version"))
+	assert.True(t, errors.Is(err, ErrStaleUpdate))
+
+	rec := &configRecord{ID: 1, Value: "e", Version: 2}
+	require.NoError(t, UpdateVersioned(cli, "config", rec, "version", WithEQ("id")))
+	assert.Equal(t, 3, rec.Version)
+
+	row, err := cli.First("config", map[string]interface{}{"id": 1}, WithEQ("id"))
+	require.NoError(t, err)
+	assert.Equal(t, "e", row["value"])
+	assert.EqualValues(t, 3, row["version"])
+
+	rec.Version = 1
+	assert.True(t, errors.Is(UpdateVersioned(cli, "config", rec, "version", WithEQ("id")), ErrStaleUpdate))
+}
@@ -301,6 +301,9 @@ type FuncExpr struct {
 	Args     []Expression // 函数参数
 	Distinct bool         // 是否使用 DISTINCT
 	Alias    string       // 别名（可选）
+	// Translate 为 true 时按目标库的函数翻译表改写，ParseMySQL 解析的函数会设置该标记
+	// Builder 中直接构造的函数按原样输出
+	Translate bool
 }
 
 // WhenThenClause 表示 CASE 表达式中的 WHEN-THEN 子句
@@ -342,6 +345,13 @@ func (fe *FuncExpr) ToSQL(dt DatabaseTransformer) (string, error) {
 		sql = fmt.Sprintf("%s(%s)", fe.Name, args)
 	}
 
+	// 来自 MySQL 方言 SQL 的函数，方言提供了函数翻译表时改写为目标库的等价写法
+	if ft, ok := dt.(FuncTranslator); ok && fe.Translate {
+		if translated, ok := ft.TranslateFunc(fe.Name, argStrs, fe.Distinct); ok {
+			sql = translated
+		}
+	}
+
 	// 如果有别名，添加 AS 子句
 	if fe.Alias != "" {
 		sql = fmt.Sprintf("%s AS %s", sql, dt.EscapeColumnName(fe.Alias))
@@ -713,6 +723,9 @@ func (w *whereItem) ToCondition(dt DatabaseTransformer) (*Condition, error) {
 			Fields:    allFields,
 		}, nil
 
+	case OP_JSON_CONTAINS, OP_ARRAY_CONTAINS, OP_ANY:
+		return w.jsonCondition(dt)
+
 	case OP_EXISTS, OP_NOT_EXISTS:
 		if expr, ok := w.Right.(Expression); ok {
 			r, err := expr.ToSQL(dt)
@@ -1005,6 +1018,12 @@ type sqlBuilder struct {
 	orderBy       []OrderBy
 	limitValue    string
 	offsetValue   string
+	tenant        any
+	// tenantConf 多租户隔离策略，子查询未设置时继承外层
+	tenantConf    *tenantConf
+	versionColumn string
+	// conflictGuard Upsert、Replace 命中已有行时该行必须满足的列值，由租户隔离设置
+	conflictGuard *SimpleExpr
 }
 
 // OffsetExpr implements SQLBuilder.
@@ -1135,11 +1154,13 @@ func IN(field string, values []any, f ...WhereOptionFunc) Where {
 }
 
 func NOT_IN(field string, values []any, f ...WhereOptionFunc) Where {
-	if _, ok := values[0].(SQLBuilder); ok {
-		return createWhere(field, OP_IN, append(f, WithNativeValue(values[0]))...)
-	}
-	if _, ok := values[0].(Expression); ok {
-		return createWhere(field, OP_IN, append(f, WithNativeValue(values[0]))...)
+	if len(values) == 1 {
+		if _, ok := values[0].(SQLBuilder); ok {
+			return createWhere(field, OP_NOT_IN, append(f, WithNativeValue(values[0]))...)
+		}
+		if _, ok := values[0].(Expression); ok {
+			return createWhere(field, OP_NOT_IN, append(f, WithNativeValue(values[0]))...)
+		}
 	}
 	return createWhere(field, OP_NOT_IN, append(f, WithNativeValue(values))...)
 }
@@ -1183,6 +1204,9 @@ func (t *Table) GetAlias() string {
 
 func (t *Table) ToSQL(dt DatabaseTransformer) (string, error) {
 	name := dt.EscapeTableName(t.Name)
+	if t.Schema != "" {
+		name = dt.EscapeTableName(t.Schema) + "." + name
+	}
 	if t.Alias != "" {
 		return name + " " + dt.EscapeTableName(t.Alias), nil
 	}
@@ -1423,6 +1447,25 @@ func WithEQ(field ...string) FuncWithBuilder {
 		return swc.Where(AND(ws...))
 	}
 }
+
+// WithOmitFields 从写入的字段中去掉指定列，例如 Update 时数据中的主键只用于条件，不出现在 SET 中
+func WithOmitFields(field ...string) FuncWithBuilder {
+	return func(swc SQLBuilder) SQLBuilder {
+		qb, ok := swc.(*sqlBuilder)
+		if !ok {
+			return swc
+		}
+		columns := make([]Expression, 0, len(qb.columns))
+		for _, c := range qb.columns {
+			if se, ok := c.(*SimpleExpr); ok && slice.ContainBy(field, func(f string) bool { return strings.EqualFold(f, se.Field) }) {
+				continue
+			}
+			columns = append(columns, c)
+		}
+		qb.columns = columns
+		return qb
+	}
+}
 func WithNEQ(field ...string) FuncWithBuilder {
 	return func(swc SQLBuilder) SQLBuilder {
 		ws := slice.Map(field, func(_ int, f string) Where {
@@ -2149,7 +2192,161 @@ func (s *sqlBuilder) GetAssignString(dt DatabaseTransformer, all bool) (string,
 	return sb.String(), fields, nil
 }
 
+func (s *sqlBuilder) GetAssignItems(dt DatabaseTransformer, update bool) ([]AssignItem, error) {
+	cols := s.columns
+	if update && len(s.updates) > 0 {
+		cols = s.updates
+	}
+	pks, err := s.getPrimaryKeys(dt)
+	if err != nil {
+		return nil, err
+	}
+	r := make([]AssignItem, 0, len(cols))
+	for _, col := range cols {
+		se, ok := col.(*SimpleExpr)
+		if !ok {
+			return nil, fmt.Errorf("unsupported column type: %T", col)
+		}
+		name, err := se.toFieldsStr(dt)
+		if err != nil {
+			return nil, err
+		}
+		value, field, err := se.toValueStr(dt)
+		if err != nil {
+			return nil, err
+		}
+		item := AssignItem{Column: name, Value: value}
+		if se.Value == nil {
+			item.Fields = []string{field}
+		}
+		for pk := range pks {
+			if strings.EqualFold(pk, name) {
+				item.PK = true
+			}
+		}
+		r = append(r, item)
+	}
+	return r, nil
+}
+
+// withSubBuilders 返回 qb 的浅拷贝，FROM、JOIN、WHERE、HAVING 及 INSERT ... SELECT 中的子查询构建器替换为 fn 的返回值
+// 引用了子查询的切片与条件一并复制，qb 及其子查询本身不被修改
+func (qb *sqlBuilder) withSubBuilders(fn func(sub *sqlBuilder) *sqlBuilder) *sqlBuilder {
+	mapBuilder := func(b SQLBuilder) SQLBuilder {
+		if sub, ok := b.(*sqlBuilder); ok && sub != nil {
+			return fn(sub)
+		}
+		return b
+	}
+	mapSub := func(sq *SubQuery) *SubQuery {
+		cp := *sq
+		cp.Builder = mapBuilder(sq.Builder)
+		return &cp
+	}
+	mapSrc := func(ts TableSource) TableSource {
+		if sq, ok := ts.(*SubQuery); ok && sq != nil {
+			return mapSub(sq)
+		}
+		return ts
+	}
+	var mapValue func(v any) any
+	mapValue = func(v any) any {
+		switch val := v.(type) {
+		case *SubQuery:
+			if val != nil {
+				return mapSub(val)
+			}
+		case SQLBuilder:
+			return mapBuilder(val)
+		case []any:
+			r := make([]any, len(val))
+			for i, item := range val {
+				r[i] = mapValue(item)
+			}
+			return r
+		}
+		return v
+	}
+	var mapWhere func(w Where) Where
+	mapWhere = func(w Where) Where {
+		switch v := w.(type) {
+		case *whereGroup:
+			if v == nil {
+				return v
+			}
+			cp := &whereGroup{operator: v.operator, conditions: make([]Where, len(v.conditions))}
+			for i, c := range v.conditions {
+				cp.conditions[i] = mapWhere(c)
+			}
+			return cp
+		case *whereItem:
+			if v == nil {
+				return v
+			}
+			cp := *v
+			cp.Left, cp.Right = mapValue(v.Left), mapValue(v.Right)
+			return &cp
+		case *SubQuery:
+			if v != nil {
+				return mapSub(v)
+			}
+		}
+		return w
+	}
+
+	cp := *qb
+	cp.table = mapSrc(qb.table)
+	cp.from = mapSrc(qb.from)
+	if len(qb.joins) > 0 {
+		cp.joins = make([]joinClause, len(qb.joins))
+		for i, j := range qb.joins {
+			j.table = mapSrc(j.table)
+			if len(j.onConditions) > 0 {
+				ons := make([]Where, len(j.onConditions))
+				for k, on := range j.onConditions {
+					ons[k] = mapWhere(on)
+				}
+				j.onConditions = ons
+			}
+			cp.joins[i] = j
+		}
+	}
+	if qb.whereClause != nil {
+		cp.whereClause = mapWhere(qb.whereClause).(*whereGroup)
+	}
+	if qb.having != nil {
+		cp.having = mapWhere(qb.having)
+	}
+	if qb.subQueryData != nil {
+		cp.subQueryData = mapBuilder(qb.subQueryData)
+	}
+	return &cp
+}
+
+func (s *sqlBuilder) GetConflictGuard(dt DatabaseTransformer) (string, string, bool, error) {
+	if s.conflictGuard == nil {
+		return "", "", false, nil
+	}
+	column, err := s.conflictGuard.toFieldsStr(dt)
+	if err != nil {
+		return "", "", false, err
+	}
+	value, _, err := s.conflictGuard.toValueStr(dt)
+	if err != nil {
+		return "", "", false, err
+	}
+	return column, value, true, nil
+}
+
 func (s *sqlBuilder) Build(dt DatabaseTransformer) (*BuildResult, error) {
+	scoped, err := s.tenantScoped()
+	if err != nil {
+		return nil, err
+	}
+	return scoped.versioned().build(dt)
+}
+
+func (s *sqlBuilder) build(dt DatabaseTransformer) (*BuildResult, error) {
 	if s.operationType == SQLOperationUnknown {
 		s.operationType = SQLOperationSelect
 	}
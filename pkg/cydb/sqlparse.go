@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -133,6 +134,7 @@ func ParseMySQL(sql string) (SQLBuilder, error) {
 	if len(stmts) == 0 {
 		return nil, fmt.Errorf("no statement found")
 	}
+	orderParamMarkers(stmts[0])
 
 	// Create a new builder
 	builder := Builder()
@@ -565,8 +567,9 @@ func buildExpression(ctx *ParseMysqlContext, expr ast.ExprNode) (Expression, err
 		}
 
 		return &FuncExpr{
-			Name: x.FnName.O,
-			Args: args,
+			Name:      x.FnName.O,
+			Args:      args,
+			Translate: true,
 		}, nil
 
 	case *ast.AggregateFuncExpr:
@@ -581,9 +584,10 @@ func buildExpression(ctx *ParseMysqlContext, expr ast.ExprNode) (Expression, err
 		}
 
 		return &FuncExpr{
-			Name:     x.F,
-			Args:     args,
-			Distinct: x.Distinct,
+			Name:      x.F,
+			Args:      args,
+			Distinct:  x.Distinct,
+			Translate: true,
 		}, nil
 	case *test_driver.ParamMarkerExpr:
 		return ctx.GetParamExpr(x.Order), nil
@@ -757,6 +761,11 @@ func buildWhereCondition(ctx *ParseMysqlContext, expr ast.ExprNode) (Where, erro
 			// Handle value list case
 			values := make([]any, 0, len(x.List))
 			for _, item := range x.List {
+				// 命名参数保留为 :name，由执行时展开切片
+				if pm, ok := item.(*test_driver.ParamMarkerExpr); ok {
+					values = append(values, ctx.GetParamExpr(pm.Order))
+					continue
+				}
 				var buf strings.Builder
 				ctx := format.NewRestoreCtx(format.RestoreStringSingleQuotes, &buf)
 				item.Restore(ctx)
@@ -769,6 +778,11 @@ func buildWhereCondition(ctx *ParseMysqlContext, expr ast.ExprNode) (Where, erro
 			return IN(col.Name.Name.O, values), nil
 		}
 
+		// (a, b) IN (:pairs) 多列 IN，命名参数保留为 :name
+		if row, ok := x.Expr.(*ast.RowExpr); ok && x.Sel == nil {
+			return &whereCondition{condition: restoreRowIn(ctx, row, x)}, nil
+		}
+
 		// Default case: create a raw condition
 		var buf strings.Builder
 		ctx := format.NewRestoreCtx(format.RestoreStringSingleQuotes, &buf)
@@ -907,19 +921,105 @@ func extractTableRefs(node ast.ResultSetNode, tables *[]ast.ResultSetNode) {
 	}
 }
 
+type paramMarkerCollector struct {
+	markers []*test_driver.ParamMarkerExpr
+}
+
+func (c *paramMarkerCollector) Enter(n ast.Node) (ast.Node, bool) {
+	if pm, ok := n.(*test_driver.ParamMarkerExpr); ok {
+		c.markers = append(c.markers, pm)
+	}
+	return n, false
+}
+
+func (c *paramMarkerCollector) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+// orderParamMarkers 按出现位置为参数占位符编号，与 preprocessSQLParams 记录的参数名一一对应
+func orderParamMarkers(stmt ast.StmtNode) {
+	c := &paramMarkerCollector{}
+	stmt.Accept(c)
+	sort.SliceStable(c.markers, func(i, j int) bool {
+		return c.markers[i].Offset < c.markers[j].Offset
+	})
+	for i, pm := range c.markers {
+		pm.SetOrder(i)
+	}
+}
+
+func restoreNode(n ast.Node) string {
+	var buf strings.Builder
+	n.Restore(format.NewRestoreCtx(format.RestoreStringSingleQuotes, &buf))
+	return buf.String()
+}
+
+func restoreRowIn(ctx *ParseMysqlContext, row *ast.RowExpr, x *ast.PatternInExpr) string {
+	cols := make([]string, 0, len(row.Values))
+	for _, v := range row.Values {
+		cols = append(cols, restoreNode(v))
+	}
+	items := make([]string, 0, len(x.List))
+	for _, item := range x.List {
+		if pm, ok := item.(*test_driver.ParamMarkerExpr); ok {
+			items = append(items, ctx.GetParamValue(pm.Order))
+			continue
+		}
+		if r, ok := item.(*ast.RowExpr); ok {
+			vals := make([]string, 0, len(r.Values))
+			for _, v := range r.Values {
+				if pm, ok := v.(*test_driver.ParamMarkerExpr); ok {
+					vals = append(vals, ctx.GetParamValue(pm.Order))
+				} else {
+					vals = append(vals, restoreNode(v))
+				}
+			}
+			items = append(items, "("+strings.Join(vals, ", ")+")")
+			continue
+		}
+		items = append(items, restoreNode(item))
+	}
+	op := "IN"
+	if x.Not {
+		op = "NOT IN"
+	}
+	return fmt.Sprintf("(%s) %s (%s)", strings.Join(cols, ", "), op, strings.Join(items, ", "))
+}
+
 // preprocessSQLParams replaces parameter placeholders like :active with temporary values
 // that the TiDB parser can handle, and stores the original placeholders in the provided map
+// 引号内的内容与 :: 转义不视为参数，如 '10:20:30'、'%H::%i'
 func preprocessSQLParams(sql string, paramPlaceholders *[]string) string {
 	// Regular expression to match parameter placeholders like :active
-	paramRegex := regexp.MustCompile(`:(\w+)\b`)
-
-	// Replace each parameter placeholder with a temporary value
-	processedSQL := paramRegex.ReplaceAllStringFunc(sql, func(match string) string {
-		*paramPlaceholders = append(*paramPlaceholders, match)
-		return "?"
-	})
-
-	return processedSQL
+	paramRegex := regexp.MustCompile(`^:(\w+)\b`)
+
+	var sb strings.Builder
+	var quote byte
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == ':' && i+1 < len(sql) && sql[i+1] == ':':
+			sb.WriteString("::")
+			i++
+			continue
+		case c == ':':
+			// Replace each parameter placeholder with a temporary value
+			if match := paramRegex.FindString(sql[i:]); match != "" {
+				*paramPlaceholders = append(*paramPlaceholders, match)
+				sb.WriteByte('?')
+				i += len(match) - 1
+				continue
+			}
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
 }
 
 // TestParseMySQL tests the SQL parsing functionality
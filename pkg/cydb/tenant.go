@@ -0,0 +1,322 @@
+package cydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/fj1981/infrakit/pkg/cyutil"
+)
+
+// ErrTenantRequired 查询租户隔离的表时未指定租户
+var ErrTenantRequired = errors.New("tenant required")
+
+// ErrTenantRawSQL 原生 SQL 无法追加租户条件，指定了租户的上下文中不允许执行
+var ErrTenantRawSQL = errors.New("raw sql in tenant context")
+
+// ErrTenantReplace REPLACE INTO 会删除主键冲突的行再插入，无法限定在当前租户内，租户隔离的表需改用 Upsert
+var ErrTenantReplace = errors.New("replace on tenant scoped table")
+
+// TenantPolicy 多租户隔离策略，Tables 中的表在构建 SQL 时自动追加租户条件
+type TenantPolicy struct {
+	// Column 租户列名，默认 tenant_id
+	Column string
+	// Tables 需要隔离的表
+	Tables []string
+}
+
+type tenantConf struct {
+	column string
+	tables map[string]struct{}
+}
+
+func (c *tenantConf) scoped(ts TableSource) (*Table, bool) {
+	t, ok := ts.(*Table)
+	if !ok || t == nil {
+		return nil, false
+	}
+	_, ok = c.tables[normalTableTag(t.Name)]
+	return t, ok
+}
+
+func newTenantConf(p *TenantPolicy) *tenantConf {
+	if p == nil {
+		return nil
+	}
+	c := &tenantConf{column: p.Column, tables: map[string]struct{}{}}
+	if c.column == "" {
+		c.column = "tenant_id"
+	}
+	for _, t := range p.Tables {
+		c.tables[normalTableTag(t)] = struct{}{}
+	}
+	return c
+}
+
+// SetTenantPolicy 设置当前 DBCli 及之后创建的副本、事务的多租户隔离策略，p 为 nil 时关闭
+// 策略只作用于 List、Insert 等通过构建器生成的 SQL；Query、Excute 等原生 SQL 无法追加租户条件，
+// 上下文指定了具体租户时返回 ErrTenantRawSQL，需要执行时改用 AllTenants 上下文并自行添加条件
+func (d *DBCli) SetTenantPolicy(p *TenantPolicy) {
+	d.tenant = newTenantConf(p)
+}
+
+type tenantCtxKey struct{}
+
+// ContextWithTenant 将租户写入上下文，配合 DBCli.WithContext 使用
+func ContextWithTenant(ctx context.Context, tenant any) context.Context {
+	return context.WithValue(ctx, tenantCtxKey{}, tenant)
+}
+
+func TenantFromContext(ctx context.Context) (any, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	v := ctx.Value(tenantCtxKey{})
+	return v, v != nil
+}
+
+type allTenants struct{}
+
+// AllTenants 作为租户传入时跳过租户隔离，仅用于管理类查询
+var AllTenants any = allTenants{}
+
+func WithTenant(tenant any) FuncWithBuilder {
+	return func(b SQLBuilder) SQLBuilder {
+		return b.Tenant(tenant)
+	}
+}
+
+func (qb *sqlBuilder) Tenant(tenant any) SQLBuilder {
+	qb.tenant = tenant
+	return qb
+}
+
+func (qb *sqlBuilder) TenantPolicy(p *TenantPolicy) SQLBuilder {
+	qb.tenantConf = newTenantConf(p)
+	return qb
+}
+
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.:@\-]+$`)
+
+// tenantLiteral 租户值直接嵌入 SQL，仅允许整数和安全字符组成的字符串
+func tenantLiteral(tenant any) (Expression, error) {
+	switch v := tenant.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return &LiteralValue{Value: cyutil.ToString(v), Embed: true}, nil
+	case string:
+		if tenantIDPattern.MatchString(v) {
+			return &LiteralValue{Value: "'" + v + "'", Embed: true}, nil
+		}
+	}
+	return nil, &DatabaseError{
+		Code:    ErrCodeInvalidParam,
+		Message: fmt.Sprintf("invalid tenant value: %v", tenant),
+	}
+}
+
+// propagateTenant 返回子查询继承了租户及隔离策略的构建器副本，不修改调用方的子查询构建器
+// 子查询 Build 时再各自追加租户条件并继续向下传递
+func (qb *sqlBuilder) propagateTenant() *sqlBuilder {
+	return qb.withSubBuilders(func(sub *sqlBuilder) *sqlBuilder {
+		if (sub.tenant != nil || qb.tenant == nil) && sub.tenantConf != nil {
+			return sub
+		}
+		cp := *sub
+		if cp.tenant == nil {
+			cp.tenant = qb.tenant
+		}
+		if cp.tenantConf == nil {
+			cp.tenantConf = qb.tenantConf
+		}
+		return &cp
+	})
+}
+
+// TenantReplaceError 方言无法限制 REPLACE 的租户时返回的错误
+func TenantReplaceError(tableName string) error {
+	return &DatabaseError{
+		Code:    ErrCodeInvalidParam,
+		Message: "replace into tenant scoped table " + tableName + " is not supported, use upsert",
+		Cause:   ErrTenantReplace,
+	}
+}
+
+func tenantRequiredError(t *Table) error {
+	return &DatabaseError{
+		Code:    ErrCodeInvalidParam,
+		Message: "tenant required for table " + t.Name,
+		Cause:   ErrTenantRequired,
+	}
+}
+
+// tenantScoped 返回追加了租户条件的构建器副本，不修改原构建器，便于重复 Build
+func (qb *sqlBuilder) tenantScoped() (*sqlBuilder, error) {
+	c := qb.tenantConf
+	if c == nil {
+		return qb, nil
+	}
+	qb = qb.propagateTenant()
+	main := qb.table
+	if qb.from != nil {
+		main = qb.from
+	}
+	mainTable, mainScoped := c.scoped(main)
+	var scopedJoins []int
+	for i, j := range qb.joins {
+		if _, ok := c.scoped(j.table); ok {
+			scopedJoins = append(scopedJoins, i)
+		}
+	}
+	if !mainScoped && len(scopedJoins) == 0 {
+		return qb, nil
+	}
+	if qb.tenant == nil {
+		if mainScoped {
+			return nil, tenantRequiredError(mainTable)
+		}
+		t, _ := c.scoped(qb.joins[scopedJoins[0]].table)
+		return nil, tenantRequiredError(t)
+	}
+	if _, ok := qb.tenant.(allTenants); ok {
+		return qb, nil
+	}
+	lit, err := tenantLiteral(qb.tenant)
+	if err != nil {
+		return nil, err
+	}
+	cp := *qb
+	cp.joins = slices.Clone(qb.joins)
+	for _, i := range scopedJoins {
+		t, _ := c.scoped(cp.joins[i].table)
+		cp.joins[i].onConditions = append(slices.Clone(cp.joins[i].onConditions), EQ(t.GetAlias()+"."+c.column, WithNativeValue(lit)))
+	}
+	if !mainScoped {
+		return &cp, nil
+	}
+	switch qb.operationType {
+	case SQLOperationInsert, SQLOperationUpsert, SQLOperationReplace:
+		if err := cp.tenantColumns(c.column, lit); err != nil {
+			return nil, err
+		}
+		if qb.operationType != SQLOperationInsert {
+			// 主键冲突时只能更新当前租户的行，且不能改变行的租户
+			cp.conflictGuard = &SimpleExpr{Field: c.column, Value: lit}
+			if idx := columnIndex(qb.updates, c.column); idx >= 0 {
+				cp.updates = slices.Delete(slices.Clone(qb.updates), idx, idx+1)
+			}
+		}
+	default:
+		if qb.whereClause != nil {
+			cp.whereClause = &whereGroup{operator: qb.whereClause.operator, conditions: slices.Clone(qb.whereClause.conditions)}
+		}
+		col := c.column
+		if mainTable.Alias != "" || len(qb.joins) > 0 {
+			col = mainTable.GetAlias() + "." + col
+		}
+		cp.Where(EQ(col, WithNativeValue(lit)))
+		if qb.operationType == SQLOperationUpdate {
+			// 不允许把数据改到其他租户
+			if idx := columnIndex(qb.columns, c.column); idx >= 0 {
+				cp.columns = slices.Clone(qb.columns)
+				cp.columns[idx] = &SimpleExpr{Field: c.column, Value: lit}
+			}
+		}
+	}
+	return &cp, nil
+}
+
+func columnIndex(columns []Expression, column string) int {
+	return slices.IndexFunc(columns, func(e Expression) bool {
+		se, ok := e.(*SimpleExpr)
+		return ok && strings.EqualFold(se.Field, column)
+	})
+}
+
+// tenantColumns 写入时强制租户列为当前租户
+func (qb *sqlBuilder) tenantColumns(column string, lit Expression) error {
+	idx := columnIndex(qb.columns, column)
+	if idx < 0 && qb.subQueryData != nil {
+		return &DatabaseError{
+			Code:    ErrCodeInvalidParam,
+			Message: "insert from subquery into tenant scoped table must select column " + column,
+		}
+	}
+	expr := &SimpleExpr{Field: column, Value: lit}
+	qb.columns = slices.Clone(qb.columns)
+	if idx < 0 {
+		qb.columns = append(qb.columns, expr)
+	} else {
+		qb.columns[idx] = expr
+	}
+	if len(qb.values) == 0 {
+		return nil
+	}
+	values := make([][]Expression, 0, len(qb.values))
+	for _, row := range qb.values {
+		row = slices.Clone(row)
+		if idx < 0 {
+			row = append(row, lit)
+		} else if idx < len(row) {
+			row[idx] = lit
+		}
+		values = append(values, row)
+	}
+	qb.values = values
+	return nil
+}
+
+// builder 创建绑定当前数据库、隔离策略和上下文租户的构建器
+func (d *DBCli) builder() SQLBuilder {
+	b := Builder().Database(d.Database())
+	b.(*sqlBuilder).tenantConf = d.tenant
+	if tenant, ok := TenantFromContext(d.Context()); ok {
+		b = b.Tenant(tenant)
+	}
+	return b
+}
+
+// tenantValue 返回写入 tableName 时需要强制的租户列和租户值，表未隔离或使用 AllTenants 时 ok 为 false
+// 用于不经过构建器的写入，例如 BulkInsert
+func (d *DBCli) tenantValue(tableName string) (column string, tenant any, ok bool, err error) {
+	c := d.tenant
+	if c == nil {
+		return "", nil, false, nil
+	}
+	t, scoped := c.scoped(TABLE(tableName))
+	if !scoped {
+		return "", nil, false, nil
+	}
+	tenant, ok = TenantFromContext(d.Context())
+	if !ok {
+		return "", nil, false, tenantRequiredError(t)
+	}
+	if _, all := tenant.(allTenants); all {
+		return "", nil, false, nil
+	}
+	if _, err := tenantLiteral(tenant); err != nil {
+		return "", nil, false, err
+	}
+	return c.column, tenant, true, nil
+}
+
+// tenantRawSQL 策略生效且上下文指定了具体租户时拒绝原生 SQL，避免调用方误以为结果已按租户隔离
+func (d *DBCli) tenantRawSQL(sql string) error {
+	if d.tenant == nil {
+		return nil
+	}
+	tenant, ok := TenantFromContext(d.Context())
+	if !ok {
+		return nil
+	}
+	if _, all := tenant.(allTenants); all {
+		return nil
+	}
+	return &DatabaseError{
+		Code:    ErrCodeInvalidParam,
+		Message: "raw sql is not tenant scoped, use builder methods or AllTenants context: " + sql,
+		Cause:   ErrTenantRawSQL,
+	}
+}
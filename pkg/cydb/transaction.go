@@ -24,3 +24,19 @@ func WithTransaction[T IRepo](repo T, fn func(repo T) error) error {
 		return fn(tempRepo)
 	})
 }
+
+// WithRetryTransaction 与 WithTransaction 相同，但遇到死锁、序列化失败等瞬时错误时会整体重试 fn
+func WithRetryTransaction[T IRepo](repo T, fn func(repo T) error, opts ...RetryOption) error {
+	tx := repo.GetDBCli()
+	if tx == nil {
+		return fmt.Errorf("failed to begin transaction")
+	}
+	return tx.WithRetryTransaction(func(tx *DBCli) error {
+		tempRepo, b := cyutil.NewObj[T]()
+		if !b {
+			return fmt.Errorf("failed to create repo")
+		}
+		tempRepo.SetDBCli(tx)
+		return fn(tempRepo)
+	}, opts...)
+}
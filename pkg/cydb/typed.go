@@ -0,0 +1,71 @@
+package cydb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ListAs 与 List 相同，但按 db 标签直接扫描为结构体，保留 time.Time、sql.NullXXX 等原始类型
+// 不经过查询缓存
+func ListAs[T any](d *DBCli, tableName any, data map[string]interface{}, cc ...FuncWithBuilder) ([]*T, error) {
+	sqlContent, err := d.selectSQL(tableName, nil, cc...)
+	if err != nil {
+		return nil, err
+	}
+	return nSelect[T](d, sqlContent, data)
+}
+
+// FirstAs 与 First 相同，但扫描为结构体，没有数据时返回 nil, nil
+func FirstAs[T any](d *DBCli, tableName any, data map[string]interface{}, cc ...FuncWithBuilder) (*T, error) {
+	limit := 1
+	sqlContent, err := d.selectSQL(tableName, &limit, cc...)
+	if err != nil {
+		return nil, err
+	}
+	r, err := nSelect[T](d, sqlContent, data)
+	if err != nil || len(r) == 0 {
+		return nil, err
+	}
+	return r[0], nil
+}
+
+func (d *DBCli) selectSQL(tableName any, limit *int, cc ...FuncWithBuilder) (string, error) {
+	sqlFunc, ok := GetSqlTransformer(d.dbtype)
+	if !ok {
+		return "", errors.New("not support db type: " + d.dbtype)
+	}
+	builder := d.builder().Table(tableName)
+	if limit != nil {
+		builder = builder.Limit(*limit)
+	}
+	for _, c := range cc {
+		builder = c(builder)
+	}
+	builder = d.softDeleteScope(builder, tableName)
+	sqlContent, err := builder.Type(SQLOperationSelect).Build(sqlFunc)
+	if err != nil {
+		return "", err
+	}
+	return sqlContent.SQL, nil
+}
+
+func nSelect[T any](d *DBCli, sql string, data map[string]interface{}) ([]*T, error) {
+	DBLog().Debug("nSelect sql", "sql", sql, "data", data)
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	rows, err := d.namedQuery(sql, data)
+	if err != nil {
+		return nil, fmt.Errorf("[nSelect]: %s | => %w", sql, err)
+	}
+	defer rows.Close()
+	var r []*T
+	for rows.Next() {
+		var v T
+		if err := rows.StructScan(&v); err != nil {
+			return nil, fmt.Errorf("[nSelect]: %s | => %w", sql, err)
+		}
+		r = append(r, &v)
+	}
+	return r, rows.Err()
+}
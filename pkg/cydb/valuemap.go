@@ -0,0 +1,301 @@
+package cydb
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fj1981/infrakit/pkg/cyutil"
+	"github.com/jmoiron/sqlx"
+)
+
+// ResultColumn 结果集中一列的类型信息，由驱动返回的列类型推断
+type ResultColumn struct {
+	Name        string
+	DBFieldType DBFieldType
+	// OrgDataType 驱动返回的数据库类型名，如 DECIMAL、INT8、NUMBER
+	OrgDataType string
+	// Precision、Scale 仅数值类型有效，驱动未提供时为 -1
+	Precision int64
+	Scale     int64
+}
+
+// IsDecimal 是否为定点数类型，TypedValueMapper 将其输出为十进制字符串以免丢失精度
+func (c *ResultColumn) IsDecimal() bool {
+	t := strings.ToUpper(c.OrgDataType)
+	return strings.Contains(t, "DEC") || strings.Contains(t, "NUMERIC") || strings.Contains(t, "NUMBER") || strings.Contains(t, "MONEY")
+}
+
+// ResultTypeMapper 由方言可选实现，按驱动返回的列类型推断 DBFieldType
+// 未实现时所有列按 DBFieldTypeString 处理
+type ResultTypeMapper interface {
+	ResultFieldType(ct *sql.ColumnType) DBFieldType
+}
+
+// ValueMapper 将驱动返回的非 nil 原始值转换为查询结果中的值
+type ValueMapper interface {
+	MapValue(col *ResultColumn, v interface{}) (interface{}, error)
+}
+
+// ValueMapperFunc 函数形式的 ValueMapper
+type ValueMapperFunc func(col *ResultColumn, v interface{}) (interface{}, error)
+
+// MapValue implements ValueMapper.
+func (f ValueMapperFunc) MapValue(col *ResultColumn, v interface{}) (interface{}, error) {
+	return f(col, v)
+}
+
+// WithValueMapper 返回使用 m 转换查询结果的 DBCli 副本，m 为 nil 时恢复默认的 NormalData 转换
+// 使用 ValueMapper 的查询不读写查询缓存，缓存序列化后无法保留类型
+func (d *DBCli) WithValueMapper(m ValueMapper) *DBCli {
+	cp := *d
+	cp.vm = m
+	return &cp
+}
+
+// SetValueMapper 设置当前 DBCli 默认的结果转换方式
+func (d *DBCli) SetValueMapper(m ValueMapper) {
+	d.vm = m
+}
+
+// Typed 返回按列类型输出一致 Go 类型的 DBCli 副本，时间转换到 loc
+func (d *DBCli) Typed(loc *time.Location) *DBCli {
+	return d.WithValueMapper(TypedValues(loc))
+}
+
+// resultColumns 未设置 ValueMapper 时返回 nil，scanSQLRow 使用 NormalData
+func (d *DBCli) resultColumns(rows *sqlx.Rows) ([]*ResultColumn, error) {
+	if d.vm == nil {
+		return nil, nil
+	}
+	cts, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	var typer ResultTypeMapper
+	if sqlFunc, ok := GetSqlDialect(d.dbtype); ok {
+		typer, _ = sqlFunc.(ResultTypeMapper)
+	}
+	cols := make([]*ResultColumn, 0, len(cts))
+	for _, ct := range cts {
+		col := &ResultColumn{Name: ct.Name(), OrgDataType: ct.DatabaseTypeName(), Precision: -1, Scale: -1}
+		if p, s, ok := ct.DecimalSize(); ok {
+			col.Precision, col.Scale = p, s
+		}
+		if typer != nil {
+			col.DBFieldType = typer.ResultFieldType(ct)
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+// TypedValueMapper 按列类型输出一致的 Go 类型：
+// 整数为 int64，定点数为十进制字符串，浮点数为 float64，时间为 Location 时区的 time.Time，
+// 二进制为 []byte，JSON 为 json.RawMessage，位类型为 int64，其余为 string；没有类型信息的列保留驱动返回的值
+type TypedValueMapper struct {
+	// Location 时间转换到的时区，不带时区的时间字符串也按该时区解析；nil 时为 time.Local
+	Location *time.Location
+}
+
+// TypedValues 创建 TypedValueMapper
+func TypedValues(loc *time.Location) *TypedValueMapper {
+	return &TypedValueMapper{Location: loc}
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	time.DateOnly,
+	time.TimeOnly,
+}
+
+// MapValue implements ValueMapper.
+func (m *TypedValueMapper) MapValue(col *ResultColumn, v interface{}) (interface{}, error) {
+	if col.OrgDataType == "" {
+		// 表达式列等没有类型信息时保留驱动返回的类型
+		if t, ok := v.(time.Time); ok {
+			return t.In(m.location()), nil
+		}
+		return v, nil
+	}
+	switch col.DBFieldType {
+	case DBFieldTypeInt:
+		if n, ok := toInt64(v); ok {
+			return n, nil
+		}
+		// 声明为整数的列出现小数时按定点数处理，不丢弃小数部分
+		return decimalString(v), nil
+	case DBFieldTypeFloat:
+		if col.IsDecimal() {
+			return decimalString(v), nil
+		}
+		return toFloat64(v)
+	case DBFieldTypeTime:
+		return m.toTime(v)
+	case DBFieldTypeBinary:
+		switch x := v.(type) {
+		case []byte:
+			return append([]byte(nil), x...), nil
+		case string:
+			return []byte(x), nil
+		}
+	case DBFieldTypeJson:
+		switch x := v.(type) {
+		case []byte:
+			return json.RawMessage(append([]byte(nil), x...)), nil
+		case string:
+			return json.RawMessage(x), nil
+		default:
+			b, err := json.Marshal(x)
+			return json.RawMessage(b), err
+		}
+	case DBFieldTypeBit:
+		switch x := v.(type) {
+		case []byte:
+			var buf [8]byte
+			if len(x) > 8 {
+				return nil, fmt.Errorf("column %s: bit value too long", col.Name)
+			}
+			copy(buf[8-len(x):], x)
+			return int64(binary.BigEndian.Uint64(buf[:])), nil
+		case bool:
+			if x {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+		if n, ok := toInt64(v); ok {
+			return n, nil
+		}
+	}
+	switch x := v.(type) {
+	case []byte:
+		return string(x), nil
+	case string:
+		return x, nil
+	case time.Time:
+		return x.In(m.location()).Format(time.RFC3339Nano), nil
+	default:
+		return cyutil.ToStr(x), nil
+	}
+}
+
+func (m *TypedValueMapper) location() *time.Location {
+	if m.Location != nil {
+		return m.Location
+	}
+	return time.Local
+}
+
+func (m *TypedValueMapper) toTime(v interface{}) (interface{}, error) {
+	loc := m.location()
+	var s string
+	switch x := v.(type) {
+	case time.Time:
+		return x.In(loc), nil
+	case []byte:
+		s = string(x)
+	case string:
+		s = x
+	case int64:
+		// SQLite 等以 unix 秒保存时间
+		return time.Unix(x, 0).In(loc), nil
+	default:
+		return nil, fmt.Errorf("unsupported time value %T", v)
+	}
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "0000-00-00") {
+		return time.Time{}, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t.In(loc), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot parse time %q", s)
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return x, true
+	case int:
+		return int64(x), true
+	case int32:
+		return int64(x), true
+	case int16:
+		return int64(x), true
+	case int8:
+		return int64(x), true
+	case uint64:
+		if x > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(x), true
+	case uint32:
+		return int64(x), true
+	case bool:
+		if x {
+			return 1, true
+		}
+		return 0, true
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) < 1<<63 {
+			return int64(x), true
+		}
+	case float32:
+		return toInt64(float64(x))
+	case []byte:
+		return toInt64(string(x))
+	case string:
+		if n, err := strconv.ParseInt(strings.TrimSpace(x), 10, 64); err == nil {
+			return n, true
+		}
+	case fmt.Stringer:
+		// 驱动自定义的数值类型，如 Oracle Number
+		return toInt64(x.String())
+	}
+	return 0, false
+}
+
+func toFloat64(v interface{}) (interface{}, error) {
+	switch x := v.(type) {
+	case float64:
+		return x, nil
+	case float32:
+		return float64(x), nil
+	case []byte:
+		return strconv.ParseFloat(strings.TrimSpace(string(x)), 64)
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(x), 64)
+	}
+	if n, ok := toInt64(v); ok {
+		return float64(n), nil
+	}
+	return nil, fmt.Errorf("unsupported float value %T", v)
+}
+
+// decimalString 定点数的十进制字符串形式，浮点值按最短表示输出
+func decimalString(v interface{}) string {
+	switch x := v.(type) {
+	case []byte:
+		return strings.TrimSpace(string(x))
+	case string:
+		return strings.TrimSpace(x)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(x), 'f', -1, 32)
+	default:
+		return cyutil.ToStr(x)
+	}
+}
@@ -0,0 +1,125 @@
+package cydb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/fj1981/infrakit/pkg/cyutil"
+)
+
+// ErrStaleUpdate 乐观锁更新失败，数据已被其他操作修改
+var ErrStaleUpdate = errors.New("stale update")
+
+// StaleUpdateError 乐观锁冲突，版本号不匹配导致没有行被更新
+type StaleUpdateError struct {
+	Table   string
+	Column  string
+	Version any
+}
+
+func (e *StaleUpdateError) Error() string {
+	return fmt.Sprintf("stale update on %s: %s = %v no longer matches", e.Table, e.Column, e.Version)
+}
+
+func (e *StaleUpdateError) Is(target error) bool {
+	return target == ErrStaleUpdate
+}
+
+// WithVersion 开启乐观锁，UPDATE 时追加 column = :column 条件并将 column 自增 1
+// 数据中必须包含 column 的当前值，未更新到任何行时 Update 返回 *StaleUpdateError
+func WithVersion(column string) FuncWithBuilder {
+	return func(b SQLBuilder) SQLBuilder {
+		if qb, ok := b.(*sqlBuilder); ok {
+			qb.versionColumn = column
+		}
+		return b
+	}
+}
+
+// versioned 返回追加了版本条件的构建器副本
+func (qb *sqlBuilder) versioned() *sqlBuilder {
+	if qb.versionColumn == "" || qb.operationType != SQLOperationUpdate {
+		return qb
+	}
+	cp := *qb
+	expr := &SimpleExpr{Field: qb.versionColumn, Value: ADD(FIELD(qb.versionColumn), CONST(1))}
+	cp.columns = slices.Clone(qb.columns)
+	if idx := columnIndex(cp.columns, qb.versionColumn); idx >= 0 {
+		cp.columns[idx] = expr
+	} else {
+		cp.columns = append(cp.columns, expr)
+	}
+	if qb.whereClause != nil {
+		cp.whereClause = &whereGroup{operator: qb.whereClause.operator, conditions: slices.Clone(qb.whereClause.conditions)}
+	}
+	cp.Where(EQ(qb.versionColumn))
+	return &cp
+}
+
+func builderVersionColumn(builder SQLBuilder) string {
+	if qb, ok := builder.(*sqlBuilder); ok {
+		return qb.versionColumn
+	}
+	return ""
+}
+
+// checkVersion 校验数据中包含版本号
+func checkVersion(builder SQLBuilder, tableName string, data map[string]interface{}) error {
+	col := builderVersionColumn(builder)
+	if col == "" {
+		return nil
+	}
+	if _, ok := data[col]; !ok {
+		return &DatabaseError{
+			Code:    ErrCodeInvalidParam,
+			Message: fmt.Sprintf("version column %s is required to update %s", col, tableName),
+		}
+	}
+	return nil
+}
+
+func staleError(builder SQLBuilder, tableName string, data map[string]interface{}, affected int64) error {
+	col := builderVersionColumn(builder)
+	if col == "" || affected > 0 {
+		return nil
+	}
+	return &StaleUpdateError{Table: tableName, Column: col, Version: data[col]}
+}
+
+// UpdateVersioned 使用乐观锁更新结构体，字段名取 db 标签，更新成功后结构体中的版本号自增
+func UpdateVersioned[T any](cli *DBCli, tableName string, entity *T, column string, cc ...FuncWithBuilder) error {
+	data, err := cyutil.StructToMap(entity, "db")
+	if err != nil {
+		return err
+	}
+	if _, err := cli.Update(tableName, data, append(cc, WithVersion(column))...); err != nil {
+		return err
+	}
+	bumpVersionField(entity, column)
+	return nil
+}
+
+func bumpVersionField(entity any, column string) {
+	v := reflect.ValueOf(entity).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("db"), ",")[0]
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		if name != column {
+			continue
+		}
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			f.SetInt(f.Int() + 1)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			f.SetUint(f.Uint() + 1)
+		}
+		return
+	}
+}
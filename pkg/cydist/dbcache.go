@@ -0,0 +1,45 @@
+package cydist
+
+import (
+	"context"
+	"time"
+)
+
+// DBQueryCache adapts a CacheWrapper to the query cache used by cydb.DBCli.SetQueryCache.
+// Cached rows are indexed by table tags through the wrapper's base key index, so a write
+// in any process sharing the same Redis invalidates the results for that table.
+type DBQueryCache struct {
+	w *CacheWrapper
+}
+
+// NewDBQueryCache creates a query cache backed by the given CacheWrapper
+func NewDBQueryCache(w *CacheWrapper) *DBQueryCache {
+	return &DBQueryCache{w: w}
+}
+
+func (c *DBQueryCache) Get(ctx context.Context, key string) ([]map[string]interface{}, bool) {
+	var rows []map[string]interface{}
+	if err := c.w.Get(ctx, key, &rows); err != nil {
+		return nil, false
+	}
+	return rows, true
+}
+
+func (c *DBQueryCache) Set(ctx context.Context, key string, tags []string, rows []map[string]interface{}, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = c.w.options.TTL
+	}
+	if err := c.w.Set(ctx, key, rows, WithTTL(ttl)); err != nil {
+		return err
+	}
+	return c.w.setBaseKeyIndex(ctx, tags, key, ttl)
+}
+
+func (c *DBQueryCache) Invalidate(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		if err := c.w.ResetCacheByBaseKey(ctx, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
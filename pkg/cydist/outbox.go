@@ -0,0 +1,37 @@
+package cydist
+
+import (
+	"context"
+	"time"
+
+	"github.com/fj1981/infrakit/pkg/cylog"
+)
+
+// DBOutboxLocker adapts a DistLockManager to the relay lock used by cydb.OutboxRelay,
+// so only one process relays a given outbox table at a time.
+type DBOutboxLocker struct {
+	lm   *DistLockManager
+	wait time.Duration
+}
+
+// NewDBOutboxLocker creates an outbox locker; wait bounds how long a relay round waits for the lock
+func NewDBOutboxLocker(lm *DistLockManager, wait ...time.Duration) *DBOutboxLocker {
+	w := 500 * time.Millisecond
+	if len(wait) > 0 {
+		w = wait[0]
+	}
+	return &DBOutboxLocker{lm: lm, wait: w}
+}
+
+func (l *DBOutboxLocker) TryLock(ctx context.Context, key string) (func(), bool) {
+	distLock, err := l.lm.Lock(ctx, key, WithLockTimeout(l.wait))
+	if err != nil {
+		cylog.Debugf("outbox relay skipped, lock %s not acquired: %v", key, err)
+		return nil, false
+	}
+	return func() {
+		if ok, err := distLock.Unlock(); !ok {
+			cylog.Warnf("outbox relay failed to release lock %s: %v", key, err)
+		}
+	}, true
+}